@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/mongodb/migrations"
+	"log"
+)
+
+func main() {
+	flag.Usage = func() {
+		log.Print("usage: migrate up|status")
+	}
+	flag.Parse()
+
+	configs := app.RegisterAppConfigs()
+
+	client := mongodb.NewMongoDBClient(configs)
+
+	err := client.Connect(context.Background())
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer client.Disconnect(context.Background())
+
+	migrator := migrations.NewMigrator(client, configs)
+
+	switch command := flag.Arg(0); command {
+	case "up":
+		applied, err := migrator.Up(context.Background())
+
+		for _, name := range applied {
+			log.Printf("applied %s", name)
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("%d migrations applied", len(applied))
+	case "status":
+		statuses, err := migrator.Status(context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				log.Printf("pending  %s", status.Name)
+				continue
+			}
+
+			log.Printf("applied  %s at %s", status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+
+		diffs, err := mongodb.DiffIndexes(client, configs, context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, diff := range diffs {
+			if diff.Action == mongodb.IndexExtra {
+				log.Printf("index    %s.%s is not declared, up keeps it", diff.Collection, diff.Name)
+				continue
+			}
+
+			log.Printf("index    %s.%s needs %s", diff.Collection, diff.Name, diff.Action)
+		}
+	default:
+		flag.Usage()
+		log.Fatalf("unknown command %q", command)
+	}
+}
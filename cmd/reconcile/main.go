@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/reconciliation/service"
+	"github.com/regiszanandrea/posty/internal/user/repository/follower"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"log"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report the discrepancies, without fixing them")
+	batchSize := flag.Int("batch-size", 0, "number of users reconciled at a time, overrides app.reconciliation.batch-size")
+	flag.Parse()
+
+	configs := app.RegisterAppConfigs()
+
+	if *batchSize > 0 {
+		configs.Set("app.reconciliation.batch-size", *batchSize)
+	}
+
+	// the memory driver keeps nothing once the application that stored it stops
+	if configs.GetString("app.storage.driver") == "memory" {
+		log.Fatal("reconcile: the memory driver has nothing to reconcile, set app.storage.driver to mongodb or postgres")
+	}
+
+	client := mongodb.NewMongoDBClient(configs)
+
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		defer client.Disconnect(context.Background())
+	}
+
+	db, err := postgres.NewPostgresDB(configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	userRepository, err := user_repository.NewRepository(client, db, nil, configs)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	followerRepository, err := follower_repository.NewRepository(client, db, nil, configs)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	postRepository, err := post_repository.NewRepository(client, db, nil, configs)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reconciliationService := service.NewReconciliationService(
+		userRepository,
+		followerRepository,
+		postRepository,
+		configs,
+	)
+
+	report, err := reconciliationService.Reconcile(context.Background(), *dryRun)
+
+	for _, discrepancy := range report.Discrepancies {
+		log.Printf(
+			"user %s has %s %d instead of %d",
+			discrepancy.UserID,
+			discrepancy.Field,
+			discrepancy.Stored,
+			discrepancy.Actual,
+		)
+	}
+
+	log.Print(report.Summary())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/seed"
+	"log"
+	"time"
+)
+
+func main() {
+	options := &seed.Options{}
+	until := flag.String("until", "", "time of the last post in RFC 3339, defaults to now, set it to generate the same data again")
+
+	flag.IntVar(&options.Users, "users", 10, "number of users")
+	flag.IntVar(&options.Follows, "follows", 5, "average number of users each user follows")
+	flag.StringVar(&options.Distribution, "distribution", seed.DistributionUniform, "distribution of the followers: uniform or power-law")
+	flag.Float64Var(&options.Exponent, "exponent", 1.5, "exponent of the power-law distribution, greater than 1, the higher the more skewed")
+	flag.IntVar(&options.PostsPerUser, "posts", 5, "number of posts of each user")
+	flag.DurationVar(&options.Spread, "spread", 30*24*time.Hour, "period the posts are created along, until -until")
+	flag.Float64Var(&options.RepostRatio, "reposts", 0.1, "ratio of the posts that are reposts")
+	flag.Float64Var(&options.QuoteRatio, "quotes", 0.05, "ratio of the posts that are quotes")
+	flag.Int64Var(&options.Seed, "seed", 1, "seed of the random source, the same seed and options generate the same data")
+	flag.StringVar(&options.Password, "password", "password", "password of every user, empty to create users that can not log in")
+	flag.IntVar(&options.BatchSize, "batch-size", 1000, "number of documents inserted at a time")
+	flag.Parse()
+
+	options.Until = time.Now()
+
+	if *until != "" {
+		var err error
+
+		options.Until, err = time.Parse(time.RFC3339, *until)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	configs := app.RegisterAppConfigs()
+
+	options.PulledFollowers = configs.GetUint("app.timeline.fan-out-maximum-followers")
+
+	client := mongodb.NewMongoDBClient(configs)
+
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		defer client.Disconnect(context.Background())
+	}
+
+	db, err := postgres.NewPostgresDB(configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer db.Close()
+
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	writer, err := seed.NewWriter(client, db, configs)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := seed.Seed(context.Background(), options, writer)
+
+	if report != nil {
+		log.Print(report.Summary())
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -20,6 +20,11 @@ func RegisterAppConfigs() *viper.Viper {
 		panic(err)
 	}
 
+	// the same tests run against every driver, APP_STORAGE_DRIVER=memory needs no database
+	if err := viper.BindEnv("app.storage.driver", "APP_STORAGE_DRIVER"); err != nil {
+		panic(err)
+	}
+
 	if !viper.IsSet("app.env") {
 		panic("The APP_ENV variable must be set!")
 	}
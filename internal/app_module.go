@@ -2,9 +2,22 @@ package internal
 
 import (
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/auth"
 	"github.com/regiszanandrea/posty/internal/fiber"
+	"github.com/regiszanandrea/posty/internal/health"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/metrics"
 	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/mongodb/migrations"
 	"github.com/regiszanandrea/posty/internal/post"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/quota"
+	"github.com/regiszanandrea/posty/internal/ratelimit"
+	"github.com/regiszanandrea/posty/internal/reconciliation"
+	"github.com/regiszanandrea/posty/internal/search"
+	"github.com/regiszanandrea/posty/internal/timeline"
+	"github.com/regiszanandrea/posty/internal/timeout"
+	"github.com/regiszanandrea/posty/internal/tracing"
 	"github.com/regiszanandrea/posty/internal/user"
 
 	. "go.uber.org/fx"
@@ -14,15 +27,33 @@ var (
 	ApplicationModule = Options(
 		app.Module,
 		fiber.Module,
-		Provide(mongodb.NewMongoDBClient),
+		tracing.Module,
+		timeout.Module,
+		ratelimit.Module,
+		health.Module,
+		Provide(mongodb.NewMongoDBClient, postgres.NewPostgresDB, memory.NewDatabase),
+		auth.Module,
 		user.Module,
 		post.Module,
+		timeline.Module,
+		quota.Module,
+		search.Module,
+		reconciliation.Module,
 	)
 
 	ApplicationInvokables = Options(
+		metrics.Invokables,
+		tracing.Invokables,
+		Invoke(mongodb.RegisterMongoDB, migrations.RegisterMigrations, postgres.RegisterPostgres),
+		// hooks are stopped in reverse order, so the fan-out workers stop after the server drained
+		// the requests that queue posts, and before the databases they write to are disconnected
+		timeline.Invokables,
 		fiber.Invokables,
-		Invoke(mongodb.RegisterMongoDB),
+		auth.Invokables,
 		user.Invokables,
 		post.Invokables,
+		search.Invokables,
+		reconciliation.Invokables,
+		health.Invokables,
 	)
 )
@@ -0,0 +1,164 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+)
+
+type Kind string
+
+const (
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindRateLimited  Kind = "rate_limited"
+	KindTimeout      Kind = "timeout"
+	KindInternal     Kind = "internal"
+)
+
+var (
+	ErrNotFound = NotFound("not_found", "resource not found")
+	ErrTimeout  = New(KindTimeout, "timeout", "the request took too long to be processed")
+	ErrInternal = New(KindInternal, "internal", "internal error")
+)
+
+// Error is an error with a kind, that decides its HTTP status, and a code that
+// clients can rely on, as the message is free to change
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details []string
+	cause   error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors by code, so a copy with details or a cause is still the same error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by cause
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.cause = cause
+
+	return &wrapped
+}
+
+// WithDetails returns a copy of the error with details, like every invalid field of a request
+func (e *Error) WithDetails(details ...string) *Error {
+	detailed := *e
+	detailed.Details = details
+
+	return &detailed
+}
+
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// From returns err as an Error, the ones that are not typed yet are mapped
+// by their cause and are internal errors when it is unknown
+func From(err error) *Error {
+	var appErr *Error
+
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		return ErrTimeout.Wrap(err)
+	default:
+		return ErrInternal.Wrap(err)
+	}
+}
+
+// FromErrors returns the errors of a service as a single Error, the kind is the one of the
+// first error and when all of them are validation errors every message becomes a detail
+func FromErrors(errs []error) *Error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	first := From(errs[0])
+
+	if first.Kind != KindValidation || len(errs) == 1 {
+		return first
+	}
+
+	var details []string
+
+	for _, err := range errs {
+		if From(err).Kind != KindValidation {
+			return first
+		}
+
+		details = append(details, err.Error())
+	}
+
+	return New(KindValidation, "invalid_request", "the request is invalid").WithDetails(details...)
+}
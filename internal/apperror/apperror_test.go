@@ -0,0 +1,88 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"testing"
+)
+
+func TestAppError(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "App Error Suite")
+}
+
+var _ = Describe("App error suite test", func() {
+	Describe("Converting an error", func() {
+		Context("when it is already typed", func() {
+			It("keeps it, even when wrapped", func() {
+				err := NotFound("post_not_found", "post not found")
+
+				Expect(From(fmt.Errorf("finding post: %w", err))).To(Equal(err))
+			})
+		})
+
+		Context("when it is a missing document", func() {
+			It("is not found", func() {
+				err := From(mongo.ErrNoDocuments)
+
+				Expect(err.Status()).To(Equal(http.StatusNotFound))
+				Expect(errors.Is(err, mongo.ErrNoDocuments)).To(BeTrue())
+			})
+		})
+
+		Context("when the deadline was reached", func() {
+			It("is a timeout", func() {
+				Expect(From(context.DeadlineExceeded).Status()).To(Equal(http.StatusGatewayTimeout))
+			})
+		})
+
+		Context("when it is unknown", func() {
+			It("is internal and hides its message", func() {
+				err := From(errors.New("connection refused"))
+
+				Expect(err.Status()).To(Equal(http.StatusInternalServerError))
+				Expect(NewProblem(err, "/users").Detail).To(Equal(ErrInternal.Message))
+			})
+		})
+	})
+
+	Describe("Converting the errors of a service", func() {
+		Context("when all of them are validation errors", func() {
+			It("keeps every message as a detail", func() {
+				err := FromErrors([]error{
+					Validation("invalid_field", "field: UserID required"),
+					Validation("invalid_field", "field: Content max"),
+				})
+
+				Expect(err.Kind).To(Equal(KindValidation))
+				Expect(err.Details).To(ConsistOf("field: UserID required", "field: Content max"))
+			})
+		})
+
+		Context("when the first one is not a validation error", func() {
+			It("returns it", func() {
+				err := FromErrors([]error{
+					RateLimited("posts_limit_reached", "limit of posts by day reached"),
+					Validation("invalid_field", "field: Content max"),
+				})
+
+				Expect(err.Status()).To(Equal(http.StatusTooManyRequests))
+			})
+		})
+	})
+
+	Describe("Comparing errors", func() {
+		Context("when a copy has a cause", func() {
+			It("is still the same error", func() {
+				err := Conflict("duplicate_key", "there is already a key with this value")
+
+				Expect(errors.Is(err.Wrap(errors.New("E11000")), err)).To(BeTrue())
+			})
+		})
+	})
+})
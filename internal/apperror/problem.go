@@ -0,0 +1,37 @@
+package apperror
+
+import (
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body of an error response, extended with the code of the error
+type Problem struct {
+	Type     string   `json:"type"`
+	Title    string   `json:"title"`
+	Status   int      `json:"status"`
+	Detail   string   `json:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty"`
+	Code     string   `json:"code"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// NewProblem hides the message of internal errors, which may expose details of the infrastructure
+func NewProblem(err *Error, instance string) *Problem {
+	detail := err.Message
+
+	if err.Kind == KindInternal {
+		detail = ErrInternal.Message
+	}
+
+	return &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(err.Status()),
+		Status:   err.Status(),
+		Detail:   detail,
+		Instance: instance,
+		Code:     err.Code,
+		Errors:   err.Details,
+	}
+}
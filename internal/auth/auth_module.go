@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"github.com/regiszanandrea/posty/internal/auth/http"
+	"github.com/regiszanandrea/posty/internal/auth/http/handler"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/auth/service"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			Annotate(
+				service.NewAuthService,
+				As(new(service.Service)),
+			),
+			middleware.NewAuthMiddleware,
+		),
+		handler.Module,
+	)
+
+	Invokables = Options(
+		http.Invokables,
+	)
+)
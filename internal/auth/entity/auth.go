@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/regiszanandrea/posty/internal/apperror"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+var validate = validator.New()
+
+func Validate(loginRequest *LoginRequest) []error {
+	var errs []error
+	err := validate.Struct(loginRequest)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,11 @@
+package handler
+
+import (
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Provide(
+		NewLoginHandler,
+	)
+)
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/entity"
+	"github.com/regiszanandrea/posty/internal/auth/service"
+)
+
+type LoginHandler struct {
+	service service.Service
+}
+
+func NewLoginHandler(s service.Service) *LoginHandler {
+	return &LoginHandler{
+		service: s,
+	}
+}
+
+func (h *LoginHandler) Login(ctx *fiber.Ctx) error {
+	login := new(entity.LoginRequest)
+
+	if err := ctx.BodyParser(login); err != nil {
+		return apperror.Validation("invalid_body", err.Error())
+	}
+
+	token, errors := h.service.Login(ctx.UserContext(), login)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(token)
+}
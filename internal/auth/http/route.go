@@ -0,0 +1,17 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/auth/http/handler"
+	"github.com/regiszanandrea/posty/internal/timeout"
+)
+
+func RegisterAuthRoutes(
+	app *fiber.App,
+	loginHandler *handler.LoginHandler,
+	timeoutMiddleware *timeout.Middleware,
+) {
+	group := app.Group("/auth")
+
+	group.Post("/login", timeoutMiddleware.Handle("login"), loginHandler.Login)
+}
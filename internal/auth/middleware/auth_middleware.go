@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/service"
+	"strings"
+)
+
+const userIDKey = "auth_user_id"
+
+var ErrMissingToken = apperror.Unauthorized("missing_token", "missing bearer token")
+
+type AuthMiddleware struct {
+	service service.Service
+}
+
+func NewAuthMiddleware(s service.Service) *AuthMiddleware {
+	return &AuthMiddleware{
+		service: s,
+	}
+}
+
+// Handle rejects requests without a valid bearer token and keeps the
+// authenticated user id on the context to be read by the handlers
+func (m *AuthMiddleware) Handle(ctx *fiber.Ctx) error {
+	header := ctx.Get(fiber.HeaderAuthorization)
+
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ErrMissingToken
+	}
+
+	userId, err := m.service.Authenticate(strings.TrimPrefix(header, "Bearer "))
+
+	if err != nil {
+		return err
+	}
+
+	ctx.Locals(userIDKey, userId)
+
+	return ctx.Next()
+}
+
+func AuthenticatedUserID(ctx *fiber.Ctx) string {
+	userId, _ := ctx.Locals(userIDKey).(string)
+
+	return userId
+}
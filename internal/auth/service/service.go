@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/entity"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "invalid username or password")
+	ErrInvalidToken       = apperror.Unauthorized("invalid_token", "invalid or expired token")
+)
+
+type Service interface {
+	Login(ctx context.Context, loginRequest *entity.LoginRequest) (*entity.Token, []error)
+	Authenticate(token string) (string, error)
+}
+
+type AuthService struct {
+	userRepository user_repository.Repository
+	configs        *viper.Viper
+}
+
+func NewAuthService(userRepository user_repository.Repository, configs *viper.Viper) *AuthService {
+	return &AuthService{
+		userRepository: userRepository,
+		configs:        configs,
+	}
+}
+
+func (service *AuthService) Login(ctx context.Context, loginRequest *entity.LoginRequest) (*entity.Token, []error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	errs := entity.Validate(loginRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	user, err := service.userRepository.FindByUsername(ctx, loginRequest.Username)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	if user == nil || !user.CheckPassword(loginRequest.Password) {
+		return nil, []error{ErrInvalidCredentials}
+	}
+
+	ttl := service.configs.GetDuration("app.auth.token-ttl")
+
+	token, err := GenerateToken(user.ID.Hex(), ttl, service.configs.GetString("app.auth.secret"))
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return &entity.Token{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+	}, nil
+}
+
+// Authenticate validates the signed token and returns the user id on its subject
+func (service *AuthService) Authenticate(token string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+
+		return []byte(service.configs.GetString("app.auth.secret")), nil
+	})
+
+	if err != nil || !parsed.Valid || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+
+	return claims.Subject, nil
+}
+
+func GenerateToken(userId string, ttl time.Duration, secret string) (string, error) {
+	now := time.Now()
+
+	claims := jwt.RegisteredClaims{
+		Subject:   userId,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/auth/entity"
+	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
+	"testing"
+	"time"
+)
+
+func TestAuthService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Auth Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *AuthService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("AuthService suite test", func() {
+	Describe("Logging in", func() {
+		BeforeEach(func() {
+			service = NewAuthService(&user_mock.SuccessUserRepositoryMock{}, configs)
+		})
+
+		Context("when its given valid credentials", func() {
+			It("returns a token for the user", func() {
+				token, errs := service.Login(context.Background(), &entity.LoginRequest{Username: "testd", Password: "testd"})
+
+				Expect(errs).To(BeNil())
+				Expect(token.AccessToken).NotTo(BeEmpty())
+
+				userId, err := service.Authenticate(token.AccessToken)
+
+				Expect(err).To(BeNil())
+				Expect(userId).NotTo(BeEmpty())
+			})
+		})
+
+		Context("when its given a wrong password", func() {
+			It("returns invalid credentials", func() {
+				_, errs := service.Login(context.Background(), &entity.LoginRequest{Username: "testd", Password: "wrong"})
+
+				Expect(errs[0]).To(Equal(ErrInvalidCredentials))
+			})
+		})
+
+		Context("when its given a non-existent user", func() {
+			It("returns invalid credentials", func() {
+				service = NewAuthService(&user_mock.NotFoundUserRepositoryMock{}, configs)
+
+				_, errs := service.Login(context.Background(), &entity.LoginRequest{Username: "testd", Password: "testd"})
+
+				Expect(errs[0]).To(Equal(ErrInvalidCredentials))
+			})
+		})
+	})
+
+	Describe("Authenticating a token", func() {
+		BeforeEach(func() {
+			service = NewAuthService(&user_mock.SuccessUserRepositoryMock{}, configs)
+		})
+
+		Context("when its signed with another secret", func() {
+			It("returns invalid token", func() {
+				token, _ := GenerateToken("6223c394df1ec5873cbe22a1", time.Hour, "another-secret")
+
+				_, err := service.Authenticate(token)
+
+				Expect(err).To(Equal(ErrInvalidToken))
+			})
+		})
+
+		Context("when its expired", func() {
+			It("returns invalid token", func() {
+				token, _ := GenerateToken("6223c394df1ec5873cbe22a1", -time.Hour, configs.GetString("app.auth.secret"))
+
+				_, err := service.Authenticate(token)
+
+				Expect(err).To(Equal(ErrInvalidToken))
+			})
+		})
+	})
+})
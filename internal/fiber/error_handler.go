@@ -0,0 +1,43 @@
+package fiber
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// HandleError renders every error returned by the handlers as a problem+json body,
+// errors of fiber itself, like a route that does not exist, keep their status
+func HandleError(ctx *fiber.Ctx, err error) error {
+	var problem *apperror.Problem
+
+	var fiberErr *fiber.Error
+
+	if errors.As(err, &fiberErr) {
+		problem = &apperror.Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(fiberErr.Code),
+			Status:   fiberErr.Code,
+			Detail:   fiberErr.Message,
+			Instance: ctx.OriginalURL(),
+			Code:     strings.ReplaceAll(strings.ToLower(http.StatusText(fiberErr.Code)), " ", "_"),
+		}
+	} else {
+		appErr := apperror.From(err)
+
+		if appErr.Kind == apperror.KindInternal {
+			log.Printf("%s %s: %v", ctx.Method(), ctx.OriginalURL(), err)
+		}
+
+		problem = apperror.NewProblem(appErr, ctx.OriginalURL())
+	}
+
+	err = ctx.Status(problem.Status).JSON(problem)
+
+	ctx.Set(fiber.HeaderContentType, apperror.ProblemContentType)
+
+	return err
+}
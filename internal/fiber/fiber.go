@@ -14,8 +14,19 @@ var (
 	Invokables = Invoke(RegisterFiber)
 )
 
+// NewFiber reads the IP of the clients from app.fiber.proxy-header when it is set, which is only
+// trusted on requests from app.fiber.trusted-proxies, so the clients behind a load balancer do not
+// share the IP of the load balancer, like on the rate limits
 func NewFiber(configs *viper.Viper) *fiber.App {
-	return fiber.New(fiber.Config{DisableStartupMessage: configs.GetBool("app.fiber.disable-startup-message")})
+	trustedProxies := configs.GetStringSlice("app.fiber.trusted-proxies")
+
+	return fiber.New(fiber.Config{
+		DisableStartupMessage:   configs.GetBool("app.fiber.disable-startup-message"),
+		ErrorHandler:            HandleError,
+		ProxyHeader:             configs.GetString("app.fiber.proxy-header"),
+		EnableTrustedProxyCheck: len(trustedProxies) > 0,
+		TrustedProxies:          trustedProxies,
+	})
 }
 
 func RegisterFiber(
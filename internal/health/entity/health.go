@@ -0,0 +1,22 @@
+package entity
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+type Check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Readiness is ready only when every dependency check is ok and the application is not shutting down
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]*Check `json:"checks"`
+}
+
+func (readiness *Readiness) IsReady() bool {
+	return readiness.Status == StatusOK
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"github.com/regiszanandrea/posty/internal/health/service"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	. "go.uber.org/fx"
+	"time"
+)
+
+func RegisterMongoDBChecks(service service.Service, client *mongo.Client, configs *viper.Viper) {
+	if !mongodb.IsUsed(configs) {
+		return
+	}
+
+	service.Register("mongodb", func(ctx context.Context) error {
+		return mongodb.Ping(client, ctx)
+	})
+
+	service.Register("indexes", func(ctx context.Context) error {
+		return mongodb.CheckIndexes(client, configs, ctx)
+	})
+}
+
+func RegisterPostgresChecks(service service.Service, db *sql.DB, configs *viper.Viper) {
+	if !postgres.IsUsed(configs) {
+		return
+	}
+
+	service.Register("postgres", func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// RegisterDrain fails the readiness as the first step of the shutdown and waits for
+// app.health.shutdown-delay, so load balancers notice it before the server stops
+func RegisterDrain(lifecycle Lifecycle, service service.Service, configs *viper.Viper) {
+	lifecycle.Append(Hook{
+		OnStop: func(ctx context.Context) error {
+			service.Drain()
+
+			select {
+			case <-time.After(configs.GetDuration("app.health.shutdown-delay")):
+			case <-ctx.Done():
+			}
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,27 @@
+package health
+
+import (
+	"github.com/regiszanandrea/posty/internal/health/http"
+	"github.com/regiszanandrea/posty/internal/health/http/handler"
+	"github.com/regiszanandrea/posty/internal/health/service"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			Annotate(
+				service.NewHealthService,
+				As(new(service.Service)),
+			),
+		),
+		handler.Module,
+	)
+
+	// Invokables must be the last ones, hooks are stopped in reverse order
+	// and the drain has to happen before the server and the database stop
+	Invokables = Options(
+		http.Invokables,
+		Invoke(RegisterMongoDBChecks, RegisterPostgresChecks, RegisterDrain),
+	)
+)
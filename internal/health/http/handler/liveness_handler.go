@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/health/entity"
+)
+
+type LivenessHandler struct{}
+
+func NewLivenessHandler() *LivenessHandler {
+	return &LivenessHandler{}
+}
+
+// Live only tells that the process is up and serving requests, it does not check any dependency
+func (h *LivenessHandler) Live(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"status": entity.StatusOK})
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/health/service"
+)
+
+type ReadinessHandler struct {
+	service service.Service
+}
+
+func NewReadinessHandler(s service.Service) *ReadinessHandler {
+	return &ReadinessHandler{
+		service: s,
+	}
+}
+
+func (h *ReadinessHandler) Ready(ctx *fiber.Ctx) error {
+	readiness := h.service.Ready(ctx.Context())
+
+	if !readiness.IsReady() {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(readiness)
+	}
+
+	return ctx.JSON(readiness)
+}
@@ -0,0 +1,15 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/health/http/handler"
+)
+
+func RegisterHealthRoutes(
+	app *fiber.App,
+	livenessHandler *handler.LivenessHandler,
+	readinessHandler *handler.ReadinessHandler,
+) {
+	app.Get("/healthz", livenessHandler.Live)
+	app.Get("/readyz", readinessHandler.Ready)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/regiszanandrea/posty/internal/health/entity"
+	"github.com/spf13/viper"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrConfigNotLoaded = errors.New("config file was not loaded")
+
+// Check returns nil when the dependency it checks is ready
+type Check func(ctx context.Context) error
+
+type Service interface {
+	Register(name string, check Check)
+	Drain()
+	Ready(ctx context.Context) *entity.Readiness
+}
+
+type HealthService struct {
+	mutex    sync.RWMutex
+	checks   map[string]Check
+	draining int32
+	configs  *viper.Viper
+}
+
+func NewHealthService(configs *viper.Viper) *HealthService {
+	service := &HealthService{
+		checks:  make(map[string]Check),
+		configs: configs,
+	}
+
+	service.Register("config", service.checkConfig)
+
+	return service
+}
+
+func (service *HealthService) Register(name string, check Check) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.checks[name] = check
+}
+
+// Drain makes the application not ready anymore, so load balancers stop sending
+// traffic to it while the requests in flight are finished
+func (service *HealthService) Drain() {
+	atomic.StoreInt32(&service.draining, 1)
+}
+
+func (service *HealthService) Ready(ctx context.Context) *entity.Readiness {
+	readiness := &entity.Readiness{
+		Status: entity.StatusOK,
+		Checks: make(map[string]*entity.Check),
+	}
+
+	if atomic.LoadInt32(&service.draining) == 1 {
+		readiness.Status = entity.StatusDraining
+
+		return readiness
+	}
+
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	for name, check := range service.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, service.configs.GetDuration("app.health.check-timeout"))
+		err := check(checkCtx)
+		cancel()
+
+		if err != nil {
+			readiness.Status = entity.StatusFailing
+			readiness.Checks[name] = &entity.Check{Status: entity.StatusFailing, Error: err.Error()}
+
+			continue
+		}
+
+		readiness.Checks[name] = &entity.Check{Status: entity.StatusOK}
+	}
+
+	return readiness
+}
+
+func (service *HealthService) checkConfig(ctx context.Context) error {
+	if service.configs.ConfigFileUsed() == "" {
+		return ErrConfigNotLoaded
+	}
+
+	return nil
+}
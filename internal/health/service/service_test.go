@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/health/entity"
+	"github.com/spf13/viper"
+	"testing"
+)
+
+func TestHealthService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *HealthService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("HealthService suite test", func() {
+	BeforeEach(func() {
+		service = NewHealthService(configs)
+	})
+
+	Describe("Checking readiness", func() {
+		Context("when every check passes", func() {
+			It("is ready", func() {
+				service.Register("dependency", func(ctx context.Context) error {
+					return nil
+				})
+
+				readiness := service.Ready(context.Background())
+
+				Expect(readiness.IsReady()).To(BeTrue())
+				Expect(readiness.Checks["config"].Status).To(Equal(entity.StatusOK))
+				Expect(readiness.Checks["dependency"].Status).To(Equal(entity.StatusOK))
+			})
+		})
+
+		Context("when a check fails", func() {
+			It("is not ready and tells which check failed", func() {
+				service.Register("dependency", func(ctx context.Context) error {
+					return errors.New("unreachable")
+				})
+
+				readiness := service.Ready(context.Background())
+
+				Expect(readiness.IsReady()).To(BeFalse())
+				Expect(readiness.Checks["dependency"].Error).To(Equal("unreachable"))
+			})
+		})
+
+		Context("when the application is shutting down", func() {
+			It("is not ready", func() {
+				service.Drain()
+
+				readiness := service.Ready(context.Background())
+
+				Expect(readiness.IsReady()).To(BeFalse())
+				Expect(readiness.Status).To(Equal(entity.StatusDraining))
+			})
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"bytes"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	timeline_entity "github.com/regiszanandrea/posty/internal/timeline/entity"
+	user_entity "github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sync"
+	"time"
+)
+
+// Database keeps the documents of the memory repositories, which share it as the mongodb
+// ones share a database, so a follow changes the counters of its users and a quote reads
+// the quoted post. Repositories hold the lock for a whole operation, which makes each one
+// atomic as the transactions of the mongodb ones. Documents are copied in and out, so
+// changing what a repository returned does not change what is stored
+type Database struct {
+	sync.RWMutex
+	Users map[primitive.ObjectID]*user_entity.User
+	// Followers and Likes are kept in the order they were created, as collections are read
+	Followers []*user_entity.Follower
+	Posts     map[primitive.ObjectID]*post_entity.Post
+	Likes     []*post_entity.Like
+	Timelines map[primitive.ObjectID]*timeline_entity.Timeline
+	// PostVersions are the versions of the posts before their edits, from the oldest
+	PostVersions map[primitive.ObjectID][]*post_entity.PostVersion
+}
+
+func NewDatabase() *Database {
+	return &Database{
+		Users:        map[primitive.ObjectID]*user_entity.User{},
+		Posts:        map[primitive.ObjectID]*post_entity.Post{},
+		Timelines:    map[primitive.ObjectID]*timeline_entity.Timeline{},
+		PostVersions: map[primitive.ObjectID][]*post_entity.PostVersion{},
+	}
+}
+
+// Reset removes every document, as dropping the collections of a mongodb database does
+func (database *Database) Reset() {
+	database.Lock()
+	defer database.Unlock()
+
+	empty := NewDatabase()
+
+	database.Users = empty.Users
+	database.Followers = empty.Followers
+	database.Posts = empty.Posts
+	database.Likes = empty.Likes
+	database.Timelines = empty.Timelines
+	database.PostVersions = empty.PostVersions
+}
+
+// Time is t as MongoDB stores it, in UTC and with millisecond precision, which is also
+// the precision of the cursors
+func Time(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func Now() time.Time {
+	return Time(time.Now())
+}
+
+// Increment adds value to counter unless that takes it below zero, which is reported by returning false
+func Increment(counter *uint, value int) bool {
+	if value < 0 && *counter < uint(-value) {
+		return false
+	}
+
+	*counter = uint(int(*counter) + value)
+
+	return true
+}
+
+// CompareIDs orders ids as MongoDB does, by their bytes
+func CompareIDs(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+	"time"
+)
+
+const namespace = "posty"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the HTTP requests by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	MongoDBCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb",
+		Name:      "command_duration_seconds",
+		Help:      "Duration of the MongoDB commands by command name.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command"})
+
+	MongoDBCommandErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongodb",
+		Name:      "command_errors_total",
+		Help:      "Number of MongoDB commands that failed by command name.",
+	}, []string{"command"})
+
+	PostsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "posts",
+		Name:      "created_total",
+		Help:      "Number of posts created.",
+	})
+
+	PostsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "posts",
+		Name:      "rejected_total",
+		Help:      "Number of posts rejected by reason.",
+	}, []string{"reason"})
+
+	Follows = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "users",
+		Name:      "follows_total",
+		Help:      "Number of new follow relationships.",
+	})
+
+	Unfollows = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "users",
+		Name:      "unfollows_total",
+		Help:      "Number of removed follow relationships.",
+	})
+
+	RateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_requests_total",
+		Help:      "Number of requests rejected by the rate limit, by route group.",
+	}, []string{"group"})
+)
+
+const ReasonQuotaReached = "quota_reached"
+
+// NewCommandMonitor records the duration of every command sent to MongoDB, the
+// failed ones are counted as errors too
+func NewCommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			observeCommand(evt.CommandFinishedEvent)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			observeCommand(evt.CommandFinishedEvent)
+			MongoDBCommandErrors.WithLabelValues(evt.CommandName).Inc()
+		},
+	}
+}
+
+func observeCommand(evt event.CommandFinishedEvent) {
+	MongoDBCommandDuration.
+		WithLabelValues(evt.CommandName).
+		Observe(time.Duration(evt.DurationNanos).Seconds())
+}
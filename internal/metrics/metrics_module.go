@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	. "go.uber.org/fx"
+)
+
+var (
+	// Invokables must be the first ones, fiber only runs a middleware on the
+	// routes registered after it
+	Invokables = Invoke(RegisterMetrics)
+)
+
+func RegisterMetrics(app *fiber.App, configs *viper.Viper) {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler())
+
+	app.Use(Middleware)
+
+	app.Get(configs.GetString("app.metrics.path"), func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	})
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"time"
+)
+
+// Middleware labels the requests by the route pattern instead of the path,
+// so ids on the path do not create a new series for every request
+func Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+
+	err := c.Next()
+
+	// the error is rendered here so the status observed is the one sent
+	if err != nil {
+		if err := c.App().Config().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	HTTPRequestDuration.
+		WithLabelValues(c.Route().Path, c.Method(), strconv.Itoa(c.Response().StatusCode())).
+		Observe(time.Since(start).Seconds())
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
+
+var _ = Describe("Metrics middleware suite test", func() {
+	var app *fiber.App
+
+	BeforeEach(func() {
+		app = fiber.New()
+		app.Use(Middleware)
+
+		app.Get("/users/:id", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusCreated)
+		})
+
+		app.Get("/posts/:postId", func(c *fiber.Ctx) error {
+			return fiber.ErrNotFound
+		})
+	})
+
+	Context("when a route is requested", func() {
+		It("observes it by its pattern", func() {
+			before := requestCount("/users/:id", "GET", "201")
+
+			_, err := app.Test(httptest.NewRequest("GET", "/users/1", nil))
+			Expect(err).To(BeNil())
+
+			_, err = app.Test(httptest.NewRequest("GET", "/users/2", nil))
+			Expect(err).To(BeNil())
+
+			Expect(requestCount("/users/:id", "GET", "201")).To(BeEquivalentTo(before + 2))
+		})
+	})
+
+	Context("when a route returns an error", func() {
+		It("observes the status of the error", func() {
+			before := requestCount("/posts/:postId", "GET", "404")
+
+			_, err := app.Test(httptest.NewRequest("GET", "/posts/1", nil))
+			Expect(err).To(BeNil())
+
+			Expect(requestCount("/posts/:postId", "GET", "404")).To(BeEquivalentTo(before + 1))
+		})
+	})
+})
+
+func requestCount(route, method, status string) uint64 {
+	var metric dto.Metric
+
+	observer := HTTPRequestDuration.WithLabelValues(route, method, status)
+
+	if err := observer.(prometheus.Histogram).Write(&metric); err != nil {
+		panic(err)
+	}
+
+	return metric.GetHistogram().GetSampleCount()
+}
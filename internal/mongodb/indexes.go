@@ -0,0 +1,375 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sort"
+	"strings"
+)
+
+const (
+	// IndexCreate is a declared index missing on the collection
+	IndexCreate IndexAction = "create"
+	// IndexReplace is a declared index whose keys or options differ from the one with its name on the collection
+	IndexReplace IndexAction = "replace"
+	// IndexExtra is an index of the collection that is not declared, it is reported but never dropped
+	IndexExtra IndexAction = "extra"
+)
+
+var (
+	usersCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"username", -1}}, options.Index().SetUnique(true)),
+	}
+	postsCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"user_id", -1}}, nil),
+		index(bson.D{{"user_id", -1}, {"created_at", -1}}, nil),
+		index(bson.D{{"in_reply_to_id", 1}, {"created_at", 1}}, nil),
+		index(bson.D{{"hashtags", 1}, {"created_at", -1}, {"_id", -1}}, nil),
+		index(bson.D{{"mentions.user_id", 1}, {"created_at", -1}, {"_id", -1}}, nil),
+		index(bson.D{{"created_at", -1}}, nil),
+		// posts are written in many languages, so words are not stemmed
+		index(bson.D{{"content", "text"}}, options.Index().SetDefaultLanguage("none")),
+	}
+	// the versions of a post are read from the oldest
+	postVersionsCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"post_id", 1}, {"_id", 1}}, nil),
+	}
+	followersCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"follower_id", -1}}, nil),
+		index(bson.D{{"user_id", -1}}, nil),
+		index(bson.D{{"follower_id", -1}, {"_id", -1}}, nil),
+		index(bson.D{{"follower_id", 1}, {"user_id", 1}}, options.Index().SetUnique(true)),
+		index(bson.D{{"user_id", -1}, {"_id", -1}}, nil),
+	}
+	likesCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"user_id", -1}, {"post_id", -1}}, options.Index().SetUnique(true)),
+		index(bson.D{{"post_id", -1}, {"created_at", -1}}, nil),
+		index(bson.D{{"user_id", -1}, {"created_at", -1}}, nil),
+	}
+	timelinesCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"entries.post_id", -1}}, nil),
+	}
+	// the buckets of the rate limits are removed once they are full again
+	rateLimitsCollectionIndexes = []mongo.IndexModel{
+		index(bson.D{{"expires_at", 1}}, options.Index().SetExpireAfterSeconds(0)),
+	}
+
+	// collectionsIndexes are the indexes of each collection, by the config key of its name
+	collectionsIndexes = []struct {
+		configKey string
+		indexes   []mongo.IndexModel
+	}{
+		{"app.mongodb.user-collection", usersCollectionIndexes},
+		{"app.mongodb.follower-collection", followersCollectionIndexes},
+		{"app.mongodb.post-collection", postsCollectionIndexes},
+		{"app.mongodb.post-version-collection", postVersionsCollectionIndexes},
+		{"app.mongodb.timeline-collection", timelinesCollectionIndexes},
+		{"app.mongodb.like-collection", likesCollectionIndexes},
+		{"app.mongodb.rate-limit-collection", rateLimitsCollectionIndexes},
+	}
+)
+
+type IndexAction string
+
+// IndexDiff is a difference between the declared indexes of a collection and the ones it has
+type IndexDiff struct {
+	Collection string
+	Name       string
+	Action     IndexAction
+}
+
+// index names the index as MongoDB does when no name is given, so the indexes
+// created before they had names are matched by name too
+func index(keys bson.D, opts *options.IndexOptions) mongo.IndexModel {
+	if opts == nil {
+		opts = options.Index()
+	}
+
+	var parts []string
+
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+
+	return mongo.IndexModel{Keys: keys, Options: opts.SetName(strings.Join(parts, "_"))}
+}
+
+// CreateIndexes creates the declared indexes that are missing and replaces the ones whose keys or
+// options changed, indexes are matched by name. Indexes that are not declared are left as they are
+func CreateIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
+	return createIndexes(client, configs, ctx, func(configKey string, name string) bool {
+		return true
+	})
+}
+
+// CreateCollectionIndexes creates or replaces only the declared indexes of the collection of configKey that
+// are on names, so the migrations apply them in order with the steps that prepare their data
+func CreateCollectionIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context, configKey string, names []string) error {
+	return createIndexes(client, configs, ctx, func(key string, name string) bool {
+		if key != configKey {
+			return false
+		}
+
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// DeclaredIndexes returns the names of the declared indexes, by the config key of their collection
+func DeclaredIndexes() map[string][]string {
+	names := map[string][]string{}
+
+	for _, collectionIndexes := range collectionsIndexes {
+		for _, model := range collectionIndexes.indexes {
+			names[collectionIndexes.configKey] = append(names[collectionIndexes.configKey], *model.Options.Name)
+		}
+	}
+
+	return names
+}
+
+func createIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context, filter func(configKey string, name string) bool) error {
+	return forEachCollection(client, configs, ctx, func(configKey string, collection *mongo.Collection, diffs []*IndexDiff, declared map[string]mongo.IndexModel) error {
+		for _, diff := range diffs {
+			if !filter(configKey, diff.Name) {
+				continue
+			}
+
+			switch diff.Action {
+			case IndexReplace:
+				if _, err := collection.Indexes().DropOne(ctx, diff.Name); err != nil {
+					return err
+				}
+			case IndexExtra:
+				continue
+			}
+
+			if _, err := collection.Indexes().CreateOne(ctx, declared[diff.Name]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DiffIndexes returns the differences between the declared indexes and the ones of the collections
+func DiffIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) ([]*IndexDiff, error) {
+	var result []*IndexDiff
+
+	err := forEachCollection(client, configs, ctx, func(configKey string, collection *mongo.Collection, diffs []*IndexDiff, declared map[string]mongo.IndexModel) error {
+		result = append(result, diffs...)
+
+		return nil
+	})
+
+	return result, err
+}
+
+// CheckIndexes checks that every declared index was created as it is declared
+func CheckIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
+	diffs, err := DiffIndexes(client, configs, ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		if diff.Action != IndexExtra {
+			return fmt.Errorf("index %s of collection %s needs %s", diff.Name, diff.Collection, diff.Action)
+		}
+	}
+
+	return nil
+}
+
+func forEachCollection(
+	client *mongo.Client,
+	configs *viper.Viper,
+	ctx context.Context,
+	fn func(configKey string, collection *mongo.Collection, diffs []*IndexDiff, declared map[string]mongo.IndexModel) error,
+) error {
+	for _, collectionIndexes := range collectionsIndexes {
+		collection := client.Database(
+			configs.GetString("app.mongodb.database"),
+		).Collection(
+			configs.GetString(collectionIndexes.configKey),
+		)
+
+		cursor, err := collection.Indexes().List(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		var existing []bson.D
+
+		if err = cursor.All(ctx, &existing); err != nil {
+			return err
+		}
+
+		declared := map[string]mongo.IndexModel{}
+
+		for _, model := range collectionIndexes.indexes {
+			declared[*model.Options.Name] = model
+		}
+
+		if err := fn(collectionIndexes.configKey, collection, diffCollectionIndexes(collection.Name(), collectionIndexes.indexes, existing), declared); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffCollectionIndexes compares the declared indexes with the existing ones, as listed by listIndexes
+func diffCollectionIndexes(collection string, declared []mongo.IndexModel, existing []bson.D) []*IndexDiff {
+	var diffs []*IndexDiff
+
+	existingByName := map[string]bson.D{}
+
+	for _, spec := range existing {
+		name, _ := lookup(spec, "name").(string)
+		existingByName[name] = spec
+	}
+
+	declaredNames := map[string]bool{}
+
+	for _, model := range declared {
+		name := *model.Options.Name
+		declaredNames[name] = true
+
+		spec, ok := existingByName[name]
+
+		switch {
+		case !ok:
+			diffs = append(diffs, &IndexDiff{Collection: collection, Name: name, Action: IndexCreate})
+		case !sameIndex(model, spec):
+			diffs = append(diffs, &IndexDiff{Collection: collection, Name: name, Action: IndexReplace})
+		}
+	}
+
+	var extra []string
+
+	for name := range existingByName {
+		if name != "_id_" && !declaredNames[name] {
+			extra = append(extra, name)
+		}
+	}
+
+	sort.Strings(extra)
+
+	for _, name := range extra {
+		diffs = append(diffs, &IndexDiff{Collection: collection, Name: name, Action: IndexExtra})
+	}
+
+	return diffs
+}
+
+// sameIndex compares the keys of model and the options it sets with spec. Text indexes are listed
+// with _fts and _ftsx keys, and their fields are the ones weighted
+func sameIndex(model mongo.IndexModel, spec bson.D) bool {
+	opts := model.Options
+
+	var keys, textFields []string
+
+	for _, key := range model.Keys.(bson.D) {
+		if key.Value == "text" {
+			textFields = append(textFields, key.Key)
+			continue
+		}
+
+		keys = append(keys, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+
+	var specKeys, specTextFields []string
+
+	specKey, _ := lookup(spec, "key").(bson.D)
+
+	for _, key := range specKey {
+		if key.Key == "_fts" || key.Key == "_ftsx" {
+			continue
+		}
+
+		value, ok := number(key.Value)
+
+		if !ok {
+			return false
+		}
+
+		specKeys = append(specKeys, fmt.Sprintf("%s_%d", key.Key, value))
+	}
+
+	weights, _ := lookup(spec, "weights").(bson.D)
+
+	for _, weight := range weights {
+		specTextFields = append(specTextFields, weight.Key)
+	}
+
+	sort.Strings(textFields)
+	sort.Strings(specTextFields)
+
+	if strings.Join(keys, ",") != strings.Join(specKeys, ",") ||
+		strings.Join(textFields, ",") != strings.Join(specTextFields, ",") {
+		return false
+	}
+
+	unique, _ := lookup(spec, "unique").(bool)
+
+	if unique != (opts.Unique != nil && *opts.Unique) {
+		return false
+	}
+
+	expireAfterSeconds, expires := number(lookup(spec, "expireAfterSeconds"))
+
+	if expires != (opts.ExpireAfterSeconds != nil) || expires && expireAfterSeconds != int64(*opts.ExpireAfterSeconds) {
+		return false
+	}
+
+	if len(textFields) > 0 {
+		defaultLanguage := "english"
+
+		if opts.DefaultLanguage != nil {
+			defaultLanguage = *opts.DefaultLanguage
+		}
+
+		if language, _ := lookup(spec, "default_language").(string); language != defaultLanguage {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookup(document bson.D, key string) interface{} {
+	for _, element := range document {
+		if element.Key == key {
+			return element.Value
+		}
+	}
+
+	return nil
+}
+
+// number reads a number as MongoDB may list it, in any of its numeric types
+func number(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case float64:
+		return int64(value), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,106 @@
+package mongodb
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"testing"
+)
+
+func TestMongoDB(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MongoDB Suite")
+}
+
+var _ = Describe("MongoDB suite test", func() {
+	Describe("Naming the indexes", func() {
+		It("names them as MongoDB does when no name is given", func() {
+			Expect(*index(bson.D{{"content", "text"}}, nil).Options.Name).To(Equal("content_text"))
+			Expect(*index(bson.D{{"hashtags", 1}, {"created_at", -1}, {"_id", -1}}, nil).Options.Name).
+				To(Equal("hashtags_1_created_at_-1__id_-1"))
+		})
+	})
+
+	Describe("Diffing the indexes of a collection", func() {
+		declared := []mongo.IndexModel{
+			index(bson.D{{"username", -1}}, options.Index().SetUnique(true)),
+			index(bson.D{{"expires_at", 1}}, options.Index().SetExpireAfterSeconds(0)),
+			index(bson.D{{"content", "text"}}, options.Index().SetDefaultLanguage("none")),
+		}
+
+		idIndex := bson.D{{"v", int32(2)}, {"key", bson.D{{"_id", int32(1)}}}, {"name", "_id_"}}
+		usernameIndex := bson.D{
+			{"v", int32(2)}, {"key", bson.D{{"username", int32(-1)}}}, {"name", "username_-1"}, {"unique", true},
+		}
+		expiresAtIndex := bson.D{
+			{"v", int32(2)}, {"key", bson.D{{"expires_at", int32(1)}}}, {"name", "expires_at_1"}, {"expireAfterSeconds", int32(0)},
+		}
+		contentIndex := bson.D{
+			{"v", int32(2)},
+			{"key", bson.D{{"_fts", "text"}, {"_ftsx", int32(1)}}},
+			{"name", "content_text"},
+			{"weights", bson.D{{"content", int32(1)}}},
+			{"default_language", "none"},
+			{"language_override", "language"},
+			{"textIndexVersion", int32(3)},
+		}
+
+		Context("when the collection has the declared indexes", func() {
+			It("finds no differences", func() {
+				diffs := diffCollectionIndexes("users", declared, []bson.D{idIndex, usernameIndex, expiresAtIndex, contentIndex})
+
+				Expect(diffs).To(BeEmpty())
+			})
+		})
+
+		Context("when a declared index is missing", func() {
+			It("creates it", func() {
+				diffs := diffCollectionIndexes("users", declared, []bson.D{idIndex, usernameIndex, contentIndex})
+
+				Expect(diffs).To(Equal([]*IndexDiff{{Collection: "users", Name: "expires_at_1", Action: IndexCreate}}))
+			})
+		})
+
+		Context("when the options of an index changed", func() {
+			It("replaces it", func() {
+				notUnique := bson.D{{"v", int32(2)}, {"key", bson.D{{"username", int32(-1)}}}, {"name", "username_-1"}}
+				otherTTL := bson.D{
+					{"v", int32(2)}, {"key", bson.D{{"expires_at", int32(1)}}}, {"name", "expires_at_1"}, {"expireAfterSeconds", int32(60)},
+				}
+				stemmed := bson.D{
+					{"v", int32(2)},
+					{"key", bson.D{{"_fts", "text"}, {"_ftsx", int32(1)}}},
+					{"name", "content_text"},
+					{"weights", bson.D{{"content", int32(1)}}},
+					{"default_language", "english"},
+				}
+
+				diffs := diffCollectionIndexes("users", declared, []bson.D{idIndex, notUnique, otherTTL, stemmed})
+
+				Expect(diffs).To(Equal([]*IndexDiff{
+					{Collection: "users", Name: "username_-1", Action: IndexReplace},
+					{Collection: "users", Name: "expires_at_1", Action: IndexReplace},
+					{Collection: "users", Name: "content_text", Action: IndexReplace},
+				}))
+			})
+		})
+
+		Context("when the collection has indexes that are not declared", func() {
+			It("reports them in order", func() {
+				other := bson.D{{"v", int32(2)}, {"key", bson.D{{"other", int32(1)}}}, {"name", "other_1"}}
+				another := bson.D{{"v", int32(2)}, {"key", bson.D{{"another", int32(1)}}}, {"name", "another_1"}}
+
+				diffs := diffCollectionIndexes("users", declared, []bson.D{
+					idIndex, other, usernameIndex, expiresAtIndex, contentIndex, another,
+				})
+
+				Expect(diffs).To(Equal([]*IndexDiff{
+					{Collection: "users", Name: "another_1", Action: IndexExtra},
+					{Collection: "users", Name: "other_1", Action: IndexExtra},
+				}))
+			})
+		})
+	})
+})
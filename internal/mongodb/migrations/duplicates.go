@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// removeDuplicateFollowersAndLikes keeps the oldest of the follows of the same users and of the likes of
+// the same post by the same user, stored before they had unique indexes, and takes the ones removed out of
+// the counters they were added to. Each pair is fixed in a transaction, so it is done once
+func removeDuplicateFollowersAndLikes(ctx context.Context, database *mongo.Database, configs *viper.Viper) error {
+	users := database.Collection(configs.GetString("app.mongodb.user-collection"))
+	posts := database.Collection(configs.GetString("app.mongodb.post-collection"))
+
+	err := removeDuplicates(
+		ctx,
+		database.Collection(configs.GetString("app.mongodb.follower-collection")),
+		"follower_id",
+		"user_id",
+		func(ctx mongo.SessionContext, followerId primitive.ObjectID, userId primitive.ObjectID, removed int64) error {
+			if err := decrement(ctx, users, followerId, "following_count", removed); err != nil {
+				return err
+			}
+
+			return decrement(ctx, users, userId, "followers_count", removed)
+		},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return removeDuplicates(
+		ctx,
+		database.Collection(configs.GetString("app.mongodb.like-collection")),
+		"user_id",
+		"post_id",
+		func(ctx mongo.SessionContext, userId primitive.ObjectID, postId primitive.ObjectID, removed int64) error {
+			return decrement(ctx, posts, postId, "likes_count", removed)
+		},
+	)
+}
+
+// removeDuplicates removes all but the oldest document of each pair of first and second on the collection,
+// calling removed with the pair and how many documents of it were removed in the same transaction
+func removeDuplicates(
+	ctx context.Context,
+	collection *mongo.Collection,
+	first string,
+	second string,
+	removed func(ctx mongo.SessionContext, first primitive.ObjectID, second primitive.ObjectID, count int64) error,
+) error {
+	cursor, err := collection.Aggregate(
+		ctx,
+		bson.A{
+			bson.D{{"$sort", bson.D{{"_id", 1}}}},
+			bson.D{{"$group", bson.D{
+				{"_id", bson.D{{"first", "$" + first}, {"second", "$" + second}}},
+				{"ids", bson.D{{"$push", "$_id"}}},
+			}}},
+			bson.D{{"$match", bson.D{{"ids.1", bson.D{{"$exists", true}}}}}},
+		},
+		options.Aggregate().SetAllowDiskUse(true),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var duplicate struct {
+			Pair struct {
+				First  primitive.ObjectID `bson:"first"`
+				Second primitive.ObjectID `bson:"second"`
+			} `bson:"_id"`
+			IDs []primitive.ObjectID `bson:"ids"`
+		}
+
+		if err := cursor.Decode(&duplicate); err != nil {
+			return err
+		}
+
+		err := mongodb.Transaction(ctx, collection.Database().Client(), func(ctx mongo.SessionContext) error {
+			result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": duplicate.IDs[1:]}})
+
+			if err != nil || result.DeletedCount == 0 {
+				return err
+			}
+
+			return removed(ctx, duplicate.Pair.First, duplicate.Pair.Second, result.DeletedCount)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// decrement takes value out of the counter field of the document, unless it would go below zero,
+// which is left to the reconciliation
+func decrement(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, field string, value int64) error {
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.D{{"_id", id}, {field, bson.D{{"$gte", value}}}},
+		bson.D{{"$inc", bson.D{{field, -value}}}},
+	)
+
+	return err
+}
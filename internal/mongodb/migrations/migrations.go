@@ -0,0 +1,275 @@
+package migrations
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	. "go.uber.org/fx"
+	"log"
+	"time"
+)
+
+// the document of schema_migrations held while migrating, so instances starting together migrate once
+const lockID = "lock"
+
+// Migration is a step applied once by Up and recorded on schema_migrations: it creates or replaces the
+// declared indexes on Indexes, by the config key of their collection, and then runs Up when it is set.
+// Index changes are steps too, so a data migration can prepare the data of an index before it. Steps run
+// without a transaction and may be interrupted, so they have to be safe to run again
+type Migration struct {
+	Name    string
+	Indexes map[string][]string
+	Up      func(ctx context.Context, database *mongo.Database, configs *viper.Viper) error
+}
+
+// Migrations are applied in this order, new ones are appended with the next number on their name
+var Migrations = []*Migration{
+	{Name: "0001_backfill_post_hashtags", Up: backfillPostHashtags},
+	{Name: "0002_create_indexes", Indexes: map[string][]string{
+		"app.mongodb.user-collection": {"username_-1"},
+		"app.mongodb.follower-collection": {
+			"follower_id_-1", "user_id_-1", "follower_id_-1__id_-1", "user_id_-1__id_-1",
+		},
+		"app.mongodb.post-collection": {
+			"user_id_-1",
+			"user_id_-1_created_at_-1",
+			"in_reply_to_id_1_created_at_1",
+			"hashtags_1_created_at_-1__id_-1",
+			"mentions.user_id_1_created_at_-1__id_-1",
+			"created_at_-1",
+			"content_text",
+		},
+		"app.mongodb.timeline-collection":   {"entries.post_id_-1"},
+		"app.mongodb.like-collection":       {"post_id_-1_created_at_-1", "user_id_-1_created_at_-1"},
+		"app.mongodb.rate-limit-collection": {"expires_at_1"},
+	}},
+	{Name: "0003_remove_duplicate_followers_and_likes", Up: removeDuplicateFollowersAndLikes},
+	{Name: "0004_create_unique_follower_and_like_indexes", Indexes: map[string][]string{
+		"app.mongodb.follower-collection": {"follower_id_1_user_id_1"},
+		"app.mongodb.like-collection":     {"user_id_-1_post_id_-1"},
+	}},
+	{Name: "0005_create_post_version_indexes", Indexes: map[string][]string{
+		"app.mongodb.post-version-collection": {"post_id_1__id_1"},
+	}},
+}
+
+// Status is a migration and when it was applied, AppliedAt is nil while it is pending
+type Status struct {
+	Name      string
+	AppliedAt *time.Time
+}
+
+type record struct {
+	Name      string    `bson:"_id"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+type Migrator struct {
+	client     *mongo.Client
+	database   *mongo.Database
+	collection *mongo.Collection
+	configs    *viper.Viper
+	migrations []*Migration
+}
+
+func NewMigrator(client *mongo.Client, configs *viper.Viper) *Migrator {
+	database := client.Database(configs.GetString("app.mongodb.database"))
+
+	return &Migrator{
+		client:     client,
+		database:   database,
+		collection: database.Collection(configs.GetString("app.mongodb.migration-collection")),
+		configs:    configs,
+		migrations: Migrations,
+	}
+}
+
+// RegisterMigrations applies the indexes and the pending migrations on the start, after the
+// client is connected by mongodb.RegisterMongoDB, unless app.migrations.auto is off
+func RegisterMigrations(lifecycle Lifecycle, client *mongo.Client, configs *viper.Viper) {
+	if !mongodb.IsUsed(configs) || !configs.GetBool("app.migrations.auto") {
+		return
+	}
+
+	lifecycle.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			applied, err := NewMigrator(client, configs).Up(ctx)
+
+			for _, name := range applied {
+				log.Printf("migration %s applied", name)
+			}
+
+			return err
+		},
+	})
+}
+
+// Up applies the pending migrations in order and returns the names of the ones applied
+func (migrator *Migrator) Up(ctx context.Context) ([]string, error) {
+	unlock, err := migrator.lock(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	statuses, err := migrator.Status(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+
+	for i, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+
+		if err := migrator.apply(ctx, migrator.migrations[i]); err != nil {
+			return applied, err
+		}
+
+		_, err := migrator.collection.InsertOne(ctx, record{Name: status.Name, AppliedAt: time.Now()})
+
+		if err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, status.Name)
+	}
+
+	return applied, nil
+}
+
+func (migrator *Migrator) apply(ctx context.Context, migration *Migration) error {
+	for configKey, names := range migration.Indexes {
+		if err := mongodb.CreateCollectionIndexes(migrator.client, migrator.configs, ctx, configKey, names); err != nil {
+			return err
+		}
+	}
+
+	if migration.Up == nil {
+		return nil
+	}
+
+	return migration.Up(ctx, migrator.database, migrator.configs)
+}
+
+// Status returns every migration in the order they are applied
+func (migrator *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	cursor, err := migrator.collection.Find(ctx, bson.M{"applied_at": bson.M{"$exists": true}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*record
+
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := map[string]time.Time{}
+
+	for _, record := range records {
+		applied[record.Name] = record.AppliedAt
+	}
+
+	var statuses []*Status
+
+	for _, migration := range migrator.migrations {
+		status := &Status{Name: migration.Name}
+
+		if appliedAt, ok := applied[migration.Name]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// lock waits for the lock of the migrations, which expires after app.migrations.lock-ttl in case
+// the instance holding it stops without releasing it. It is renewed every third of the ttl until
+// it is released, so a migration running for longer than the ttl keeps it
+func (migrator *Migrator) lock(ctx context.Context) (func(), error) {
+	token := primitive.NewObjectID()
+	ttl := migrator.configs.GetDuration("app.migrations.lock-ttl")
+
+	for {
+		now := time.Now()
+
+		// the upsert only inserts when the lock is not held, otherwise its _id is a duplicate
+		_, err := migrator.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": lockID, "locked_until": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"token": token, "locked_until": now.Add(ttl)}},
+			options.Update().SetUpsert(true),
+		)
+
+		if err == nil {
+			break
+		}
+
+		if !mongodb.IsDup(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				migrator.renew(token, ttl)
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+
+		_, _ = migrator.collection.DeleteOne(context.Background(), bson.M{"_id": lockID, "token": token})
+	}, nil
+}
+
+// renew extends the lock while it is still held with token
+func (migrator *Migrator) renew(token primitive.ObjectID, ttl time.Duration) {
+	result, err := migrator.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": lockID, "token": token},
+		bson.M{"$set": bson.M{"locked_until": time.Now().Add(ttl)}},
+	)
+
+	if err != nil {
+		log.Printf("failed to renew the lock of the migrations: %v", err)
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		log.Print("the lock of the migrations was taken by another instance")
+	}
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Migrations Suite")
+}
+
+var _ = Describe("Migrations suite test", func() {
+	// step returns the position of the migration that applies the index, or -1
+	step := func(configKey string, name string) int {
+		for i, migration := range Migrations {
+			for _, index := range migration.Indexes[configKey] {
+				if index == name {
+					return i
+				}
+			}
+		}
+
+		return -1
+	}
+
+	It("applies every declared index on a migration", func() {
+		for configKey, names := range mongodb.DeclaredIndexes() {
+			for _, name := range names {
+				Expect(step(configKey, name)).NotTo(Equal(-1), "%s of %s", name, configKey)
+			}
+		}
+	})
+
+	It("applies only declared indexes", func() {
+		declared := mongodb.DeclaredIndexes()
+
+		for _, migration := range Migrations {
+			for configKey, names := range migration.Indexes {
+				Expect(declared[configKey]).To(ContainElements(names), migration.Name)
+			}
+		}
+	})
+
+	It("removes the duplicate followers and likes before their unique indexes", func() {
+		removal := -1
+
+		for i, migration := range Migrations {
+			if migration.Name == "0003_remove_duplicate_followers_and_likes" {
+				removal = i
+			}
+		}
+
+		Expect(removal).NotTo(Equal(-1))
+		Expect(step("app.mongodb.follower-collection", "follower_id_1_user_id_1")).To(BeNumerically(">", removal))
+		Expect(step("app.mongodb.like-collection", "user_id_-1_post_id_-1")).To(BeNumerically(">", removal))
+	})
+
+	It("names the migrations once", func() {
+		names := map[string]bool{}
+
+		for _, migration := range Migrations {
+			Expect(names).NotTo(HaveKey(migration.Name))
+			names[migration.Name] = true
+		}
+	})
+})
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backfillPostHashtags extracts the hashtags of the posts created before they were, so they are on the
+// hashtag timelines and trends. Posts without hashtags get an empty list, which marks them as done
+func backfillPostHashtags(ctx context.Context, database *mongo.Database, configs *viper.Viper) error {
+	posts := database.Collection(configs.GetString("app.mongodb.post-collection"))
+
+	filter := bson.M{"hashtags": bson.M{"$exists": false}}
+
+	for {
+		cursor, err := posts.Find(
+			ctx,
+			filter,
+			options.Find().
+				SetProjection(bson.D{{"content", 1}}).
+				SetLimit(int64(configs.GetInt("app.migrations.batch-size"))),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		var batch []struct {
+			ID      primitive.ObjectID `bson:"_id"`
+			Content string             `bson:"content"`
+		}
+
+		if err := cursor.All(ctx, &batch); err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		var models []mongo.WriteModel
+
+		for _, post := range batch {
+			hashtags := entity.ExtractHashtags(post.Content)
+
+			if hashtags == nil {
+				hashtags = []string{}
+			}
+
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": post.ID, "hashtags": bson.M{"$exists": false}}).
+				SetUpdate(bson.M{"$set": bson.M{"hashtags": hashtags}}),
+			)
+		}
+
+		if _, err := posts.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+}
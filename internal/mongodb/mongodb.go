@@ -3,48 +3,21 @@ package mongodb
 import (
 	"context"
 	"errors"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/metrics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	. "go.uber.org/fx"
 
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 var (
-	usersCollectionIndexes = []mongo.IndexModel{
-		{
-			Keys: bson.M{
-				"username": -1,
-			}, Options: options.Index().SetUnique(true),
-		},
-	}
-	postsCollectionIndexes = []mongo.IndexModel{
-		{
-			Keys: bson.M{
-				"user_id": -1,
-			}, Options: nil,
-		},
-		{
-			Keys: bson.D{
-				{"user_id", -1},
-				{"created_at", -1},
-			}, Options: nil,
-		},
-	}
-	followersCollectionIndexes = []mongo.IndexModel{
-		{
-			Keys: bson.M{
-				"follower_id": -1,
-			}, Options: nil,
-		},
-		{
-			Keys: bson.M{
-				"user_id": -1,
-			}, Options: nil,
-		},
-	}
-	ErrDuplicateKey = errors.New("there is already a key with this value")
+	ErrDuplicateKey = apperror.Conflict("duplicate_key", "there is already a key with this value")
+	ErrInvalidID    = apperror.Validation("invalid_id", "invalid id")
 )
 
 func NewMongoDBClient(configs *viper.Viper) *mongo.Client {
@@ -54,7 +27,10 @@ func NewMongoDBClient(configs *viper.Viper) *mongo.Client {
 		configs.GetString("app.mongodb.host") + ":" +
 		configs.GetString("app.mongodb.port")
 
-	clientOptions := options.Client().ApplyURI(connectUrl)
+	clientOptions := options.Client().
+		ApplyURI(connectUrl).
+		SetDirect(configs.GetBool("app.mongodb.direct-connection")).
+		SetMonitor(newCommandMonitor(metrics.NewCommandMonitor(), otelmongo.NewMonitor()))
 
 	client, err := mongo.NewClient(clientOptions)
 
@@ -65,23 +41,29 @@ func NewMongoDBClient(configs *viper.Viper) *mongo.Client {
 	return client
 }
 
-func RegisterMongoDB(lifecycle Lifecycle, client *mongo.Client, configs *viper.Viper) {
-	lifecycle.Append(Hook{
-		OnStart: func(ctx context.Context) error {
-
-			err := client.Connect(ctx)
-
-			if err != nil {
-				return err
-			}
+// IsStorage is whether the repositories are set to MongoDB on app.storage.driver
+func IsStorage(configs *viper.Viper) bool {
+	return configs.GetString("app.storage.driver") == "mongodb"
+}
 
-			err = CreateIndexes(client, configs, ctx)
+// IsUsed is whether the repositories, the rate limits or the search are set to MongoDB,
+// the client is only connected when one of them is
+func IsUsed(configs *viper.Viper) bool {
+	return IsStorage(configs) ||
+		configs.GetString("app.rate-limits.store") == "mongodb" ||
+		configs.GetString("app.search.backend") == "mongodb"
+}
 
-			if err != nil {
-				return err
-			}
+// RegisterMongoDB connects the client on the start, the indexes and the migrations
+// are applied after it by migrations.RegisterMigrations
+func RegisterMongoDB(lifecycle Lifecycle, client *mongo.Client, configs *viper.Viper) {
+	if !IsUsed(configs) {
+		return
+	}
 
-			return nil
+	lifecycle.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			return client.Connect(ctx)
 		},
 		OnStop: func(ctx context.Context) error {
 			return client.Disconnect(ctx)
@@ -89,92 +71,26 @@ func RegisterMongoDB(lifecycle Lifecycle, client *mongo.Client, configs *viper.V
 	})
 }
 
-func CreateIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
-	err := CreateUsersCollectionIndexes(client, configs, ctx)
-
-	if err != nil {
-		return err
-	}
-
-	err = CreateFollowersCollectionIndexes(client, configs, ctx)
-
-	if err != nil {
-		return err
-	}
-
-	return CreatePostsCollectionIndexes(client, configs, ctx)
-}
-
-func CreateUsersCollectionIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
-	usersCollection := client.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.user-collection"),
-	)
-
-	err := CreateCollectionIndexes(usersCollection, usersCollectionIndexes, ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func CreateFollowersCollectionIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
-	followersCollection := client.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.follower-collection"),
-	)
+// Transaction runs fn in a multi-document transaction, which needs MongoDB running as a replica set
+func Transaction(ctx context.Context, client *mongo.Client, fn func(ctx mongo.SessionContext) error) error {
+	session, err := client.StartSession()
 
-	err := CreateCollectionIndexes(followersCollection, followersCollectionIndexes, ctx)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func CreatePostsCollectionIndexes(client *mongo.Client, configs *viper.Viper, ctx context.Context) error {
-	postsCollection := client.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.post-collection"),
-	)
+	defer session.EndSession(ctx)
 
-	err := CreateCollectionIndexes(postsCollection, postsCollectionIndexes, ctx)
-	if err != nil {
-		return err
-	}
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
 
-	return nil
+	return err
 }
 
-func CreateCollectionIndexes(collection *mongo.Collection, collectionIndexes []mongo.IndexModel, ctx context.Context) error {
-	cursor, err := collection.Indexes().List(ctx)
-	if err != nil {
-		return err
-	}
-
-	var indices []mongo.IndexSpecification
-	err = cursor.All(nil, &indices)
-
-	if err != nil {
-		return err
-	}
-
-	// this +1 references to _id index that is already created when you create the collection
-	if len(indices) == len(collectionIndexes)+1 {
-		return nil
-	}
-
-	_, err = collection.Indexes().CreateMany(ctx, collectionIndexes)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+// Ping checks that the primary of the database can be reached
+func Ping(client *mongo.Client, ctx context.Context) error {
+	return client.Ping(ctx, readpref.Primary())
 }
 
 func IsDup(err error) bool {
@@ -188,3 +104,14 @@ func IsDup(err error) bool {
 	}
 	return false
 }
+
+// ObjectIDFromHex is primitive.ObjectIDFromHex answering ErrInvalidID to an invalid id
+func ObjectIDFromHex(id string) (primitive.ObjectID, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidID.Wrap(err)
+	}
+
+	return objectId, nil
+}
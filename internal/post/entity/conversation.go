@@ -0,0 +1,71 @@
+package entity
+
+import "sort"
+
+type ConversationRequest struct {
+	PostID string `json:"post_id" validate:"required"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit" validate:"min=0"`
+	Depth  int    `json:"depth" validate:"min=0"`
+}
+
+// Reply is a direct reply of a post along with the replies under it, up to
+// the requested depth, in no particular order
+type Reply struct {
+	Post        `bson:",inline"`
+	Descendants []*Post `bson:"descendants"`
+}
+
+type Thread struct {
+	Post    *Post     `json:"post"`
+	Replies []*Thread `json:"replies"`
+}
+
+// Conversation is paginated by the direct replies of Root, each one carrying its whole subtree
+type Conversation struct {
+	Root       *Post     `json:"root"`
+	Replies    []*Thread `json:"replies"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func NewConversation(root *Post, replies []*Reply, limit int) *Conversation {
+	conversation := &Conversation{
+		Root:    root,
+		Replies: []*Thread{},
+	}
+
+	for _, reply := range replies {
+		conversation.Replies = append(conversation.Replies, newThread(reply))
+	}
+
+	if len(replies) > 0 && len(replies) == limit {
+		conversation.NextCursor = NewCursor(&replies[len(replies)-1].Post)
+	}
+
+	return conversation
+}
+
+func newThread(reply *Reply) *Thread {
+	thread := &Thread{Post: &reply.Post, Replies: []*Thread{}}
+	threads := map[string]*Thread{reply.ID.Hex(): thread}
+
+	// a reply is always created after the post it answers, so sorting by
+	// creation places every parent before its children
+	sort.SliceStable(reply.Descendants, func(i, j int) bool {
+		return reply.Descendants[i].CreatedAt.Before(reply.Descendants[j].CreatedAt)
+	})
+
+	for _, descendant := range reply.Descendants {
+		parent, ok := threads[descendant.InReplyToID.Hex()]
+
+		if !ok {
+			continue
+		}
+
+		child := &Thread{Post: descendant, Replies: []*Thread{}}
+		parent.Replies = append(parent.Replies, child)
+		threads[descendant.ID.Hex()] = child
+	}
+
+	return thread
+}
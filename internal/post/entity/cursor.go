@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"encoding/base64"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCursor = apperror.Validation("invalid_cursor", "invalid cursor")
+
+// Cursor points to the last item returned on a page, listings are ordered by
+// created_at and _id, so both are needed to resume them without skips
+type Cursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+func NewCursor(post *Post) string {
+	return EncodeCursor(post.CreatedAt, post.ID)
+}
+
+func EncodeCursor(createdAt time.Time, id primitive.ObjectID) string {
+	raw := strconv.FormatInt(createdAt.UnixMilli(), 10) + ":" + id.Hex()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	objectId, err := primitive.ObjectIDFromHex(parts[1])
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{
+		CreatedAt: time.UnixMilli(millis),
+		ID:        objectId,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+const maximumHashtagLength = 100
+
+// a hashtag starts after a character that can not be part of a word, like on "#go" or "(#go)"
+// but not on "c#" or "url/#anchor", and has a letter or an underscore, so "#1" is not a hashtag
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}\p{M}_&/#])#([\p{L}\p{N}\p{M}_]*[\p{L}_][\p{L}\p{N}\p{M}_]*)`)
+
+// ExtractHashtags returns the hashtags of content normalized and without duplicates,
+// in the order they first appear
+func ExtractHashtags(content string) []string {
+	var hashtags []string
+
+	seen := map[string]bool{}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		hashtag := NormalizeHashtag(match[1])
+
+		if len([]rune(hashtag)) > maximumHashtagLength || seen[hashtag] {
+			continue
+		}
+
+		seen[hashtag] = true
+		hashtags = append(hashtags, hashtag)
+	}
+
+	return hashtags
+}
+
+// NormalizeHashtag makes #Go, #GO and go the same hashtag
+func NormalizeHashtag(hashtag string) string {
+	return strings.ToLower(strings.TrimPrefix(hashtag, "#"))
+}
+
+type TrendingHashtag struct {
+	Tag   string `bson:"_id" json:"tag"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type ListHashtagPostsRequest struct {
+	Tag    string `json:"tag" validate:"required,max=100"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+type ListTrendingHashtagsRequest struct {
+	Limit int `json:"limit" validate:"min=0,max=100"`
+}
+
+type TrendingHashtagList struct {
+	Data []*TrendingHashtag `json:"data"`
+}
+
+func NewTrendingHashtagList(hashtags []*TrendingHashtag) *TrendingHashtagList {
+	list := &TrendingHashtagList{Data: hashtags}
+
+	if list.Data == nil {
+		list.Data = []*TrendingHashtag{}
+	}
+
+	return list
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Like struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	PostID    primitive.ObjectID `bson:"post_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+type LikeRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	PostID string `json:"post_id" validate:"required"`
+}
+
+type ListLikesRequest struct {
+	PostID string `json:"post_id" validate:"required"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+type ListLikedPostsRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
+type LikeList struct {
+	Data       []*Like `json:"data"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func NewLikeList(likes []*Like, limit int) *LikeList {
+	list := &LikeList{Data: likes}
+
+	if list.Data == nil {
+		list.Data = []*Like{}
+	}
+
+	if len(likes) > 0 && len(likes) == limit {
+		last := likes[len(likes)-1]
+		list.NextCursor = EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return list
+}
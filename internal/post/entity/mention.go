@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"regexp"
+	"unicode/utf8"
+)
+
+// usernames are alphanumeric with up to 14 characters, longer words are not mentions
+const maximumUsernameLength = 14
+
+// a mention starts after a character that can not be part of a word or an email, so "me@host" is not one
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}\p{M}_.@])@([\p{L}\p{N}\p{M}_]+)`)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// Mention is a user referenced on the content of a post, Start and End are the offsets
+// of "@username" on the content counted in characters (unicode code points), End exclusive
+type Mention struct {
+	UserID   primitive.ObjectID `bson:"user_id"`
+	Username string             `bson:"username"`
+	Start    int                `bson:"start"`
+	End      int                `bson:"end"`
+}
+
+// ExtractMentions returns every "@username" of content in order, a user mentioned twice
+// has two mentions. They are not resolved yet, so UserID is not set
+func ExtractMentions(content string) []*Mention {
+	var mentions []*Mention
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		username := content[match[2]:match[3]]
+
+		if len(username) > maximumUsernameLength || !usernamePattern.MatchString(username) {
+			continue
+		}
+
+		// the match may include the character before the "@"
+		start := utf8.RuneCountInString(content[:match[2]-1])
+
+		mentions = append(mentions, &Mention{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + utf8.RuneCountInString(username),
+		})
+	}
+
+	return mentions
+}
+
+// Usernames returns the usernames of mentions without duplicates
+func Usernames(mentions []*Mention) []string {
+	var usernames []string
+
+	seen := map[string]bool{}
+
+	for _, mention := range mentions {
+		if !seen[mention.Username] {
+			seen[mention.Username] = true
+			usernames = append(usernames, mention.Username)
+		}
+	}
+
+	return usernames
+}
+
+type ListMentionsRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
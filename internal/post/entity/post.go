@@ -1,59 +1,187 @@
 package entity
 
 import (
-	"errors"
 	"github.com/go-playground/validator/v10"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/mongodb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
 
+// Post is a repost or a quote when ParentID is set and a reply when InReplyToID is,
+// replies keep the id of the first post of the thread on ConversationID
 type Post struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	UserID     primitive.ObjectID `bson:"user_id"`
-	ParentID   primitive.ObjectID `bson:"parent_id,omitempty"`
-	QuotedPost *QuotedPost        `bson:"quoted_post,omitempty"`
-	Content    string             `bson:"content,omitempty"`
-	CreatedAt  time.Time          `bson:"created_at"`
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserID          primitive.ObjectID `bson:"user_id"`
+	ParentID        primitive.ObjectID `bson:"parent_id,omitempty"`
+	QuotedPost      *QuotedPost        `bson:"quoted_post,omitempty"`
+	InReplyToID     primitive.ObjectID `bson:"in_reply_to_id,omitempty"`
+	InReplyToUserID primitive.ObjectID `bson:"in_reply_to_user_id,omitempty"`
+	ConversationID  primitive.ObjectID `bson:"conversation_id,omitempty"`
+	Content         string             `bson:"content,omitempty"`
+	Hashtags        []string           `bson:"hashtags,omitempty"`
+	Mentions        []*Mention         `bson:"mentions,omitempty"`
+	LikesCount      uint               `bson:"likes_count"`
+	RepliesCount    uint               `bson:"replies_count"`
+	CreatedAt       time.Time          `bson:"created_at"`
+	EditedAt        *time.Time         `bson:"edited_at,omitempty" json:",omitempty"`
+	DeletedAt       *time.Time         `bson:"deleted_at,omitempty" json:",omitempty"`
 }
 
+func (post *Post) IsReply() bool {
+	return !post.InReplyToID.IsZero()
+}
+
+// IsRepost is whether the post only shares its parent, without content of its own to edit
+func (post *Post) IsRepost() bool {
+	return !post.ParentID.IsZero() && post.Content == ""
+}
+
+// QuotedPost is the post referenced by ParentID, when it is deleted only its
+// ID is kept and Deleted is set, so clients can render a tombstone in its place
 type QuotedPost struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	UserID    primitive.ObjectID `bson:"user_id"`
+	UserID    primitive.ObjectID `bson:"user_id,omitempty"`
 	Content   string             `bson:"content,omitempty"`
-	CreatedAt time.Time          `bson:"created_at"`
+	CreatedAt time.Time          `bson:"created_at,omitempty"`
+	EditedAt  *time.Time         `bson:"edited_at,omitempty" json:",omitempty"`
+	Deleted   bool               `bson:"deleted,omitempty"`
+}
+
+// PostVersion is the content a post had before an edit, CreatedAt is when it was
+// published, at the creation of the post or at the edit before it
+type PostVersion struct {
+	Content   string     `bson:"content"`
+	Hashtags  []string   `bson:"hashtags,omitempty"`
+	Mentions  []*Mention `bson:"mentions,omitempty"`
+	CreatedAt time.Time  `bson:"created_at"`
 }
 
 type CreatePostRequest struct {
-	UserID   string `json:"user_id" validate:"required"`
-	ParentID string `json:"parent_id"`
-	Content  string `json:"content" validate:"max=777"`
+	UserID      string `json:"user_id" validate:"required"`
+	ParentID    string `json:"parent_id"`
+	InReplyToID string `json:"in_reply_to_id"`
+	Content     string `json:"content" validate:"max=777"`
+}
+
+func NewPost(createPostRequest *CreatePostRequest) (*Post, error) {
+	userId, err := mongodb.ObjectIDFromHex(createPostRequest.UserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	post := &Post{
+		UserID:   userId,
+		Content:  createPostRequest.Content,
+		Hashtags: ExtractHashtags(createPostRequest.Content),
+	}
+
+	if createPostRequest.ParentID != "" {
+		post.ParentID, err = mongodb.ObjectIDFromHex(createPostRequest.ParentID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if createPostRequest.InReplyToID != "" {
+		post.InReplyToID, err = mongodb.ObjectIDFromHex(createPostRequest.InReplyToID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return post, nil
+}
+
+type EditPostRequest struct {
+	UserID  string `json:"user_id" validate:"required"`
+	PostID  string `json:"post_id" validate:"required"`
+	Content string `json:"content" validate:"required,max=777"`
+}
+
+type PostHistoryRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	PostID string `json:"post_id" validate:"required"`
+}
+
+// PostHistory is a post along with the versions it had before its edits, from the oldest
+type PostHistory struct {
+	Post     *Post          `json:"post"`
+	Versions []*PostVersion `json:"versions"`
+}
+
+func NewPostHistory(post *Post, versions []*PostVersion) *PostHistory {
+	history := &PostHistory{Post: post, Versions: versions}
+
+	if history.Versions == nil {
+		history.Versions = []*PostVersion{}
+	}
+
+	return history
+}
+
+type DeletePostRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	PostID string `json:"post_id" validate:"required"`
 }
 
 type ListPostRequest struct {
 	UserID string `json:"user_id" validate:"required"`
-	Page   int    `json:"page" validate:"required"`
+	Cursor string `json:"cursor"`
 	Limit  int    `json:"limit"`
 }
 
 type ListFeedRequest struct {
 	UserID string `json:"user_id" validate:"required"`
-	Page   int    `json:"page" validate:"required"`
+	Cursor string `json:"cursor"`
 	Limit  int    `json:"limit"`
 }
 
+type PostList struct {
+	Data       []*Post `json:"data"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func NewPostList(posts []*Post, limit int) *PostList {
+	list := &PostList{Data: posts}
+
+	if list.Data == nil {
+		list.Data = []*Post{}
+	}
+
+	if len(posts) > 0 && len(posts) == limit {
+		list.NextCursor = NewCursor(posts[len(posts)-1])
+	}
+
+	return list
+}
+
 var validate = validator.New()
 
 func Validate(createPostRequest *CreatePostRequest) []error {
 	var errs []error
 
 	if createPostRequest.Content == "" && createPostRequest.ParentID == "" {
-		errs = append(errs, errors.New("field content must be present when field parentID is not"))
+		errs = append(errs, apperror.Validation("missing_content", "field content must be present when field parentID is not"))
+	}
+
+	if createPostRequest.InReplyToID != "" {
+		if createPostRequest.ParentID != "" {
+			errs = append(errs, apperror.Validation("quoted_reply", "field parentID must not be present on replies"))
+		}
+
+		if createPostRequest.Content == "" {
+			errs = append(errs, apperror.Validation("missing_content", "field content must be present on replies"))
+		}
 	}
 
 	err := validate.Struct(createPostRequest)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
-			errs = append(errs, errors.New("field: "+err.StructField()+" "+err.Tag()))
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
 		}
 	}
 
@@ -66,7 +194,7 @@ func ValidateStruct(st interface{}) []error {
 	err := validate.Struct(st)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
-			errs = append(errs, errors.New("field: "+err.StructField()+" "+err.Tag()))
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
 		}
 	}
 
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type ConversationGetterHandler struct {
+	service service.Service
+}
+
+func NewConversationGetterHandler(s service.Service) *ConversationGetterHandler {
+	return &ConversationGetterHandler{
+		service: s,
+	}
+}
+
+func (h *ConversationGetterHandler) GetConversation(ctx *fiber.Ctx) error {
+	request := new(entity.ConversationRequest)
+
+	if err := ctx.QueryParser(request); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	request.PostID = ctx.Params("postId")
+
+	conversation, errors := h.service.GetConversation(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(conversation)
+}
@@ -7,7 +7,18 @@ import (
 var (
 	Module = Provide(
 		NewPostCreatorHandler,
+		NewPostDeleterHandler,
+		NewPostEditorHandler,
+		NewPostHistoryGetterHandler,
 		NewPostListerHandler,
 		NewFeedListerHandler,
+		NewLikePostHandler,
+		NewUnlikePostHandler,
+		NewLikeListerHandler,
+		NewLikedPostListerHandler,
+		NewConversationGetterHandler,
+		NewHashtagPostsListerHandler,
+		NewTrendingHashtagsListerHandler,
+		NewMentionsListerHandler,
 	)
 )
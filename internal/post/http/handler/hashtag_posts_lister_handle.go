@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type HashtagPostsListerHandler struct {
+	service service.Service
+}
+
+func NewHashtagPostsListerHandler(s service.Service) *HashtagPostsListerHandler {
+	return &HashtagPostsListerHandler{
+		service: s,
+	}
+}
+
+func (h *HashtagPostsListerHandler) ListPosts(ctx *fiber.Ctx) error {
+	list := new(entity.ListHashtagPostsRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	list.Tag = ctx.Params("tag")
+
+	posts, errors := h.service.ListPostsByHashtag(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(posts)
+}
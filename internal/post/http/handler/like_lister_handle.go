@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type LikeListerHandler struct {
+	service service.Service
+}
+
+func NewLikeListerHandler(s service.Service) *LikeListerHandler {
+	return &LikeListerHandler{
+		service: s,
+	}
+}
+
+func (h *LikeListerHandler) ListLikes(ctx *fiber.Ctx) error {
+	list := new(entity.ListLikesRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	list.PostID = ctx.Params("postId")
+
+	likes, errors := h.service.ListLikes(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(likes)
+}
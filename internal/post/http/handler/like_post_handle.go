@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type LikePostHandler struct {
+	service service.Service
+}
+
+func NewLikePostHandler(s service.Service) *LikePostHandler {
+	return &LikePostHandler{
+		service: s,
+	}
+}
+
+func (h *LikePostHandler) LikePost(ctx *fiber.Ctx) error {
+	request := &entity.LikeRequest{
+		UserID: ctx.Params("id"),
+		PostID: ctx.Params("postId"),
+	}
+
+	if request.UserID != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only like as itself")
+	}
+
+	errors := h.service.LikePost(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(fiber.Map{"message": "post liked with success"})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type LikedPostListerHandler struct {
+	service service.Service
+}
+
+func NewLikedPostListerHandler(s service.Service) *LikedPostListerHandler {
+	return &LikedPostListerHandler{
+		service: s,
+	}
+}
+
+func (h *LikedPostListerHandler) ListLikedPosts(ctx *fiber.Ctx) error {
+	list := new(entity.ListLikedPostsRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	list.UserID = ctx.Params("id")
+
+	posts, errors := h.service.ListLikedPosts(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(posts)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type MentionsListerHandler struct {
+	service service.Service
+}
+
+func NewMentionsListerHandler(s service.Service) *MentionsListerHandler {
+	return &MentionsListerHandler{
+		service: s,
+	}
+}
+
+func (h *MentionsListerHandler) ListMentions(ctx *fiber.Ctx) error {
+	list := new(entity.ListMentionsRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	list.UserID = ctx.Params("id")
+
+	posts, errors := h.service.ListMentions(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(posts)
+}
@@ -1,21 +1,30 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
 	"github.com/regiszanandrea/posty/internal/post/entity"
 	"github.com/regiszanandrea/posty/internal/post/service"
-	userService "github.com/regiszanandrea/posty/internal/user/service"
+	quotaEntity "github.com/regiszanandrea/posty/internal/quota/entity"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderPostQuotaLimit     = "X-Post-Quota-Limit"
+	HeaderPostQuotaRemaining = "X-Post-Quota-Remaining"
+	HeaderPostQuotaReset     = "X-Post-Quota-Reset"
 )
 
 type PostCreatorHandler struct {
-	service     service.Service
-	userService userService.Service
+	service service.Service
 }
 
-func NewPostCreatorHandler(s service.Service, us userService.Service) *PostCreatorHandler {
+func NewPostCreatorHandler(s service.Service) *PostCreatorHandler {
 	return &PostCreatorHandler{
-		service:     s,
-		userService: us,
+		service: s,
 	}
 }
 
@@ -23,30 +32,36 @@ func (h *PostCreatorHandler) CreatePost(ctx *fiber.Ctx) error {
 	post := new(entity.CreatePostRequest)
 
 	if err := ctx.BodyParser(post); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return apperror.Validation("invalid_body", err.Error())
 	}
 
 	post.UserID = ctx.Params("id")
 
-	id, errors := h.service.CreatePost(post)
+	if post.UserID != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only post as itself")
+	}
+
+	id, quota, errs := h.service.CreatePost(ctx.UserContext(), post)
 
-	if errors != nil {
-		var errorsStr []string
+	if errs != nil {
+		if errors.Is(errs[0], service.ErrPostsQuotaReached) && quota != nil {
+			setQuotaHeaders(ctx, quota)
 
-		for _, e := range errors {
-			errorsStr = append(errorsStr, e.Error())
+			retryAfter := int(time.Until(quota.ResetAt).Seconds()) + 1
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
 		}
 
-		return ctx.Status(fiber.StatusBadRequest).JSON(errorsStr)
+		return apperror.FromErrors(errs)
 	}
 
-	err := h.userService.IncreaseNumberOfPosts(post.UserID)
-
-	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(err.Error())
-	}
+	setQuotaHeaders(ctx, quota)
 
 	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": id})
 }
+
+// setQuotaHeaders tells the client how many posts it can still create
+func setQuotaHeaders(ctx *fiber.Ctx, quota *quotaEntity.Quota) {
+	ctx.Set(HeaderPostQuotaLimit, strconv.Itoa(quota.Limit))
+	ctx.Set(HeaderPostQuotaRemaining, strconv.Itoa(quota.Remaining))
+	ctx.Set(HeaderPostQuotaReset, strconv.FormatInt(quota.ResetAt.Unix(), 10))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type PostDeleterHandler struct {
+	service service.Service
+}
+
+func NewPostDeleterHandler(s service.Service) *PostDeleterHandler {
+	return &PostDeleterHandler{
+		service: s,
+	}
+}
+
+func (h *PostDeleterHandler) DeletePost(ctx *fiber.Ctx) error {
+	request := &entity.DeletePostRequest{
+		UserID: ctx.Params("id"),
+		PostID: ctx.Params("postId"),
+	}
+
+	if request.UserID != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only delete its own posts")
+	}
+
+	errors := h.service.DeletePost(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(fiber.Map{"message": "post deleted with success"})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type PostEditorHandler struct {
+	service service.Service
+}
+
+func NewPostEditorHandler(s service.Service) *PostEditorHandler {
+	return &PostEditorHandler{
+		service: s,
+	}
+}
+
+func (h *PostEditorHandler) EditPost(ctx *fiber.Ctx) error {
+	request := new(entity.EditPostRequest)
+
+	if err := ctx.BodyParser(request); err != nil {
+		return apperror.Validation("invalid_body", err.Error())
+	}
+
+	request.UserID = ctx.Params("id")
+	request.PostID = ctx.Params("postId")
+
+	if request.UserID != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only edit its own posts")
+	}
+
+	post, errors := h.service.EditPost(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(post)
+}
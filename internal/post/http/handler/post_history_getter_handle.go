@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type PostHistoryGetterHandler struct {
+	service service.Service
+}
+
+func NewPostHistoryGetterHandler(s service.Service) *PostHistoryGetterHandler {
+	return &PostHistoryGetterHandler{
+		service: s,
+	}
+}
+
+func (h *PostHistoryGetterHandler) GetPostHistory(ctx *fiber.Ctx) error {
+	request := &entity.PostHistoryRequest{
+		UserID: ctx.Params("id"),
+		PostID: ctx.Params("postId"),
+	}
+
+	history, errors := h.service.GetPostHistory(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(history)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
 	"github.com/regiszanandrea/posty/internal/post/entity"
 	"github.com/regiszanandrea/posty/internal/post/service"
 )
@@ -20,23 +21,15 @@ func (h *PostListerHandler) ListLastPosts(ctx *fiber.Ctx) error {
 	list := new(entity.ListPostRequest)
 
 	if err := ctx.QueryParser(list); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return apperror.Validation("invalid_query", err.Error())
 	}
 
 	list.UserID = ctx.Params("id")
 
-	posts, errors := h.service.ListLastPostByUser(list)
+	posts, errors := h.service.ListLastPostByUser(ctx.UserContext(), list)
 
 	if errors != nil {
-		var errorsStr []string
-
-		for _, e := range errors {
-			errorsStr = append(errorsStr, e.Error())
-		}
-
-		return ctx.Status(fiber.StatusBadRequest).JSON(errorsStr)
+		return apperror.FromErrors(errors)
 	}
 
 	return ctx.JSON(posts)
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type TrendingHashtagsListerHandler struct {
+	service service.Service
+}
+
+func NewTrendingHashtagsListerHandler(s service.Service) *TrendingHashtagsListerHandler {
+	return &TrendingHashtagsListerHandler{
+		service: s,
+	}
+}
+
+func (h *TrendingHashtagsListerHandler) ListTrendingHashtags(ctx *fiber.Ctx) error {
+	list := new(entity.ListTrendingHashtagsRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	hashtags, errors := h.service.ListTrendingHashtags(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(hashtags)
+}
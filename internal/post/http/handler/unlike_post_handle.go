@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/service"
+)
+
+type UnlikePostHandler struct {
+	service service.Service
+}
+
+func NewUnlikePostHandler(s service.Service) *UnlikePostHandler {
+	return &UnlikePostHandler{
+		service: s,
+	}
+}
+
+func (h *UnlikePostHandler) UnlikePost(ctx *fiber.Ctx) error {
+	request := &entity.LikeRequest{
+		UserID: ctx.Params("id"),
+		PostID: ctx.Params("postId"),
+	}
+
+	if request.UserID != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only unlike as itself")
+	}
+
+	errors := h.service.UnlikePost(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(fiber.Map{"message": "post unliked with success"})
+}
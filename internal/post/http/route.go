@@ -2,22 +2,59 @@ package http
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
 	"github.com/regiszanandrea/posty/internal/post/http/handler"
+	"github.com/regiszanandrea/posty/internal/ratelimit"
+	"github.com/regiszanandrea/posty/internal/timeout"
 )
 
 func RegisterPostRoutes(
 	app *fiber.App,
 	postCreatorHandler *handler.PostCreatorHandler,
+	postDeleterHandler *handler.PostDeleterHandler,
+	postEditorHandler *handler.PostEditorHandler,
+	postHistoryGetterHandler *handler.PostHistoryGetterHandler,
 	postListerHandler *handler.PostListerHandler,
 	feedListerHandler *handler.FeedListerHandler,
+	likePostHandler *handler.LikePostHandler,
+	unlikePostHandler *handler.UnlikePostHandler,
+	likeListerHandler *handler.LikeListerHandler,
+	likedPostListerHandler *handler.LikedPostListerHandler,
+	conversationGetterHandler *handler.ConversationGetterHandler,
+	hashtagPostsListerHandler *handler.HashtagPostsListerHandler,
+	trendingHashtagsListerHandler *handler.TrendingHashtagsListerHandler,
+	mentionsListerHandler *handler.MentionsListerHandler,
+	authMiddleware *middleware.AuthMiddleware,
+	timeoutMiddleware *timeout.Middleware,
+	rateLimitMiddleware *ratelimit.Middleware,
 ) {
 
 	group := app.Group("/users/:id")
 
-	group.Get("/feed", feedListerHandler.ListFeed)
+	group.Get("/feed", timeoutMiddleware.Handle("list-feed"), rateLimitMiddleware.Handle("feed"), feedListerHandler.ListFeed)
+	group.Get("/mentions", timeoutMiddleware.Handle("list-mentions"), mentionsListerHandler.ListMentions)
+
+	groupLike := group.Group("/likes")
+
+	groupLike.Get("/", timeoutMiddleware.Handle("list-liked-posts"), likedPostListerHandler.ListLikedPosts)
+	groupLike.Post("/:postId", timeoutMiddleware.Handle("like-post"), authMiddleware.Handle, likePostHandler.LikePost)
+	groupLike.Delete("/:postId", timeoutMiddleware.Handle("unlike-post"), authMiddleware.Handle, unlikePostHandler.UnlikePost)
 
 	groupPost := group.Group("/posts")
 
-	groupPost.Post("/", postCreatorHandler.CreatePost)
-	groupPost.Get("/", postListerHandler.ListLastPosts)
+	groupPost.Post("/", timeoutMiddleware.Handle("create-post"), authMiddleware.Handle, rateLimitMiddleware.Handle("posts"), postCreatorHandler.CreatePost)
+	groupPost.Get("/", timeoutMiddleware.Handle("list-posts"), postListerHandler.ListLastPosts)
+	groupPost.Delete("/:postId", timeoutMiddleware.Handle("delete-post"), authMiddleware.Handle, rateLimitMiddleware.Handle("posts"), postDeleterHandler.DeletePost)
+	groupPost.Patch("/:postId", timeoutMiddleware.Handle("edit-post"), authMiddleware.Handle, rateLimitMiddleware.Handle("posts"), postEditorHandler.EditPost)
+	groupPost.Get("/:postId/history", timeoutMiddleware.Handle("get-post-history"), postHistoryGetterHandler.GetPostHistory)
+
+	groupPostById := app.Group("/posts/:postId")
+
+	groupPostById.Get("/likes", timeoutMiddleware.Handle("list-likes"), likeListerHandler.ListLikes)
+	groupPostById.Get("/conversation", timeoutMiddleware.Handle("get-conversation"), conversationGetterHandler.GetConversation)
+
+	groupHashtag := app.Group("/hashtags")
+
+	groupHashtag.Get("/trending", timeoutMiddleware.Handle("list-trending-hashtags"), rateLimitMiddleware.Handle("hashtags"), trendingHashtagsListerHandler.ListTrendingHashtags)
+	groupHashtag.Get("/:tag/posts", timeoutMiddleware.Handle("list-hashtag-posts"), rateLimitMiddleware.Handle("hashtags"), hashtagPostsListerHandler.ListPosts)
 }
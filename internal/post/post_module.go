@@ -4,6 +4,7 @@ import (
 	"github.com/regiszanandrea/posty/internal/post/http"
 	"github.com/regiszanandrea/posty/internal/post/http/handler"
 	"github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/post/repository/like"
 	"github.com/regiszanandrea/posty/internal/post/service"
 	. "go.uber.org/fx"
 )
@@ -11,10 +12,8 @@ import (
 var (
 	Module = Options(
 		Provide(
-			Annotate(
-				post_repository.NewPostRepository,
-				As(new(post_repository.Repository)),
-			),
+			post_repository.NewRepository,
+			like_repository.NewRepository,
 			Annotate(
 				service.NewPostService,
 				As(new(service.Service)),
@@ -0,0 +1,217 @@
+package like_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type Repository interface {
+	Like(ctx context.Context, userId, postId string) (string, error)
+	Unlike(ctx context.Context, userId, postId string) (bool, error)
+	GetByPost(ctx context.Context, postId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error)
+	GetByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error)
+}
+
+// NewRepository returns the repository of the driver set on app.storage.driver
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewLikeRepository(client, configs), nil
+	case "memory":
+		return NewMemoryLikeRepository(database), nil
+	case "postgres":
+		return NewPostgresLikeRepository(db), nil
+	default:
+		return nil, fmt.Errorf("like_repository: unknown storage driver %q", driver)
+	}
+}
+
+type LikeRepository struct {
+	collection      *mongo.Collection
+	postsCollection *mongo.Collection
+}
+
+func NewLikeRepository(client *mongo.Client, configs *viper.Viper) *LikeRepository {
+	likesCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.like-collection"),
+	)
+
+	postsCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.post-collection"),
+	)
+
+	return &LikeRepository{
+		collection:      likesCollection,
+		postsCollection: postsCollection,
+	}
+}
+
+// Like creates the like and increments the likes of the post in a single transaction,
+// liking twice returns mongodb.ErrDuplicateKey and changes nothing
+func (repo *LikeRepository) Like(ctx context.Context, userId, postId string) (string, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return "", err
+	}
+
+	var objectId primitive.ObjectID
+
+	err = mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		result, err := repo.collection.InsertOne(ctx, entity.Like{
+			UserID:    userObjectId,
+			PostID:    postObjectId,
+			CreatedAt: time.Now(),
+		})
+
+		if err != nil {
+			if mongodb.IsDup(err) {
+				return mongodb.ErrDuplicateKey
+			}
+			return err
+		}
+
+		objectId = result.InsertedID.(primitive.ObjectID)
+
+		return repo.incrementLikes(ctx, postObjectId, 1)
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return objectId.Hex(), nil
+}
+
+// Unlike removes the like and decrements the likes of the post in a single transaction,
+// it returns false when there was no like and nothing was changed
+func (repo *LikeRepository) Unlike(ctx context.Context, userId, postId string) (bool, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+
+	err = mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		result, err := repo.collection.DeleteOne(ctx, bson.M{
+			"user_id": userObjectId,
+			"post_id": postObjectId,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		removed = result.DeletedCount > 0
+
+		if !removed {
+			return nil
+		}
+
+		return repo.incrementLikes(ctx, postObjectId, -1)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+func (repo *LikeRepository) GetByPost(ctx context.Context, postId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.find(ctx, bson.D{{"post_id", objectId}}, cursor, limit)
+}
+
+func (repo *LikeRepository) GetByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.find(ctx, bson.D{{"user_id", objectId}}, cursor, limit)
+}
+
+func (repo *LikeRepository) find(ctx context.Context, filter bson.D, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	var result []*entity.Like
+
+	if cursor != nil {
+		filter = append(filter, bson.E{
+			"$or",
+			bson.A{
+				bson.D{{"created_at", bson.D{{"$lt", cursor.CreatedAt}}}},
+				bson.D{
+					{"created_at", cursor.CreatedAt},
+					{"_id", bson.D{{"$lt", cursor.ID}}},
+				},
+			},
+		})
+	}
+
+	curr, err := repo.collection.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"created_at", -1}, {"_id", -1}}).
+			SetLimit(int64(limit)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var like entity.Like
+		if err := curr.Decode(&like); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &like)
+	}
+
+	return result, curr.Err()
+}
+
+func (repo *LikeRepository) incrementLikes(ctx context.Context, postId primitive.ObjectID, value int) error {
+	_, err := repo.postsCollection.UpdateOne(ctx, bson.M{"_id": postId},
+		bson.D{{"$inc", bson.D{{"likes_count", value}}}},
+	)
+
+	return err
+}
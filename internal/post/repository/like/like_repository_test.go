@@ -0,0 +1,209 @@
+package like_repository
+
+import (
+	"context"
+	"database/sql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+func TestLikeRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LikeRepository Suite")
+}
+
+var (
+	likeRepository Repository
+	postRepository post_repository.Repository
+	db             *sql.DB
+	client         *mongo.Client
+	configs        *viper.Viper
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+
+	client = mongodb.NewMongoDBClient(configs)
+
+	// the suite runs against the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		err = mongodb.CreateIndexes(client, configs, context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	database := memory.NewDatabase()
+
+	likeRepository, err = NewRepository(client, db, database, configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	postRepository, err = post_repository.NewRepository(client, db, database, configs)
+
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = AfterSuite(func() {
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE likes, posts")
+
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
+	for _, collection := range []string{"app.mongodb.like-collection", "app.mongodb.post-collection"} {
+		_, err := client.Database(
+			configs.GetString("app.mongodb.database"),
+		).Collection(
+			configs.GetString(collection),
+		).DeleteMany(context.Background(), bson.M{})
+
+		if err != nil {
+			panic(err)
+		}
+	}
+})
+
+var _ = Describe("LikeRepository suite test", func() {
+	Describe("Liking a post", func() {
+		Context("when the user did not like it yet", func() {
+			It("creates the like without error", func() {
+				id, err := likeRepository.Like(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(primitive.IsValidObjectID(id)).To(BeTrue())
+			})
+		})
+
+		Context("when the post exists", func() {
+			It("counts the like on it and discounts it when unliked", func() {
+				userId := primitive.NewObjectID().Hex()
+				postId, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
+
+				_, err := likeRepository.Like(context.Background(), userId, postId)
+				Expect(err).To(BeNil())
+
+				_, err = likeRepository.Like(context.Background(), userId, postId)
+				Expect(err).To(Equal(mongodb.ErrDuplicateKey))
+
+				post, _ := postRepository.Find(context.Background(), postId)
+				Expect(post.LikesCount).To(Equal(uint(1)))
+
+				_, _ = likeRepository.Unlike(context.Background(), userId, postId)
+				_, _ = likeRepository.Unlike(context.Background(), userId, postId)
+
+				post, _ = postRepository.Find(context.Background(), postId)
+				Expect(post.LikesCount).To(BeZero())
+			})
+		})
+
+		Context("when the user already liked it", func() {
+			It("returns duplicate key error", func() {
+				userId := primitive.NewObjectID().Hex()
+				postId := primitive.NewObjectID().Hex()
+
+				_, _ = likeRepository.Like(context.Background(), userId, postId)
+				_, err := likeRepository.Like(context.Background(), userId, postId)
+
+				Expect(err).To(Equal(mongodb.ErrDuplicateKey))
+			})
+		})
+	})
+
+	Describe("Unliking a post", func() {
+		Context("when the user liked it", func() {
+			It("removes the like", func() {
+				userId := primitive.NewObjectID().Hex()
+				postId := primitive.NewObjectID().Hex()
+
+				_, _ = likeRepository.Like(context.Background(), userId, postId)
+
+				removed, err := likeRepository.Unlike(context.Background(), userId, postId)
+
+				Expect(err).To(BeNil())
+				Expect(removed).To(BeTrue())
+			})
+		})
+
+		Context("when the user did not like it", func() {
+			It("removes nothing", func() {
+				removed, err := likeRepository.Unlike(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(removed).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Getting likes of a post", func() {
+		Context("when its given a cursor", func() {
+			It("returns only the likes after it", func() {
+				postId := primitive.NewObjectID().Hex()
+				limit := 3
+
+				for i := 0; i < 5; i++ {
+					_, _ = likeRepository.Like(context.Background(), primitive.NewObjectID().Hex(), postId)
+				}
+
+				firstPage, err := likeRepository.GetByPost(context.Background(), postId, nil, limit)
+
+				Expect(err).To(BeNil())
+				Expect(firstPage).To(HaveLen(limit))
+
+				last := firstPage[len(firstPage)-1]
+				cursor := &entity.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+
+				secondPage, err := likeRepository.GetByPost(context.Background(), postId, cursor, limit)
+
+				Expect(err).To(BeNil())
+				Expect(secondPage).To(HaveLen(2))
+			})
+		})
+	})
+})
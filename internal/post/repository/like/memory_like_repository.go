@@ -0,0 +1,162 @@
+package like_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+// MemoryLikeRepository keeps the likes on a memory.Database, a user likes a post only once as on the LikeRepository
+type MemoryLikeRepository struct {
+	database *memory.Database
+}
+
+func NewMemoryLikeRepository(database *memory.Database) *MemoryLikeRepository {
+	return &MemoryLikeRepository{
+		database: database,
+	}
+}
+
+func (repo *MemoryLikeRepository) Like(ctx context.Context, userId, postId string) (string, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return "", err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if repo.find(userObjectId, postObjectId) >= 0 {
+		return "", mongodb.ErrDuplicateKey
+	}
+
+	like := &entity.Like{
+		ID:        primitive.NewObjectID(),
+		UserID:    userObjectId,
+		PostID:    postObjectId,
+		CreatedAt: memory.Now(),
+	}
+
+	repo.database.Likes = append(repo.database.Likes, like)
+
+	repo.incrementLikes(postObjectId, 1)
+
+	return like.ID.Hex(), nil
+}
+
+func (repo *MemoryLikeRepository) Unlike(ctx context.Context, userId, postId string) (bool, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.find(userObjectId, postObjectId)
+
+	if i < 0 {
+		return false, nil
+	}
+
+	likes := repo.database.Likes
+
+	repo.database.Likes = append(append([]*entity.Like{}, likes[:i]...), likes[i+1:]...)
+
+	repo.incrementLikes(postObjectId, -1)
+
+	return true, nil
+}
+
+func (repo *MemoryLikeRepository) GetByPost(ctx context.Context, postId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(cursor, limit, func(like *entity.Like) bool {
+		return like.PostID == objectId
+	}), nil
+}
+
+func (repo *MemoryLikeRepository) GetByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(cursor, limit, func(like *entity.Like) bool {
+		return like.UserID == objectId
+	}), nil
+}
+
+// last returns the likes that match from the newest, after the cursor
+func (repo *MemoryLikeRepository) last(cursor *entity.Cursor, limit int, match func(like *entity.Like) bool) []*entity.Like {
+	repo.database.RLock()
+
+	var result []*entity.Like
+
+	for _, like := range repo.database.Likes {
+		if match(like) && (cursor == nil || before(like, cursor.CreatedAt, cursor.ID)) {
+			found := *like
+			result = append(result, &found)
+		}
+	}
+
+	repo.database.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[j], result[i].CreatedAt, result[i].ID)
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// find returns the index of the like or -1, the lock must be held
+func (repo *MemoryLikeRepository) find(userId, postId primitive.ObjectID) int {
+	for i, like := range repo.database.Likes {
+		if like.UserID == userId && like.PostID == postId {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func before(like *entity.Like, createdAt time.Time, id primitive.ObjectID) bool {
+	return like.CreatedAt.Before(createdAt) ||
+		like.CreatedAt.Equal(createdAt) && memory.CompareIDs(like.ID, id) < 0
+}
+
+// incrementLikes changes the post as the like one does, the lock must be held
+func (repo *MemoryLikeRepository) incrementLikes(postId primitive.ObjectID, value int) {
+	if post, ok := repo.database.Posts[postId]; ok {
+		updated := *post
+		memory.Increment(&updated.LikesCount, value)
+		repo.database.Posts[postId] = &updated
+	}
+}
@@ -0,0 +1,182 @@
+package like_repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+)
+
+// PostgresLikeRepository keeps the likes on the likes table, a user likes a post only once as on the LikeRepository
+type PostgresLikeRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLikeRepository(db *sql.DB) *PostgresLikeRepository {
+	return &PostgresLikeRepository{
+		db: db,
+	}
+}
+
+// Like creates the like and increments the likes of the post in a single transaction,
+// as the LikeRepository does
+func (repo *PostgresLikeRepository) Like(ctx context.Context, userId, postId string) (string, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return "", err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return "", err
+	}
+
+	id := primitive.NewObjectID()
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO likes (id, user_id, post_id, created_at) VALUES ($1, $2, $3, $4)`,
+			id.Hex(),
+			userObjectId.Hex(),
+			postObjectId.Hex(),
+			postgres.Now(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return incrementLikes(ctx, tx, postObjectId, 1)
+	})
+
+	if err != nil {
+		if postgres.IsDup(err) {
+			return "", mongodb.ErrDuplicateKey
+		}
+		return "", err
+	}
+
+	return id.Hex(), nil
+}
+
+// Unlike removes the like and decrements the likes of the post in a single transaction
+func (repo *PostgresLikeRepository) Unlike(ctx context.Context, userId, postId string) (bool, error) {
+	userObjectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	postObjectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM likes WHERE user_id = $1 AND post_id = $2`,
+			userObjectId.Hex(),
+			postObjectId.Hex(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		removed = true
+
+		return incrementLikes(ctx, tx, postObjectId, -1)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+func (repo *PostgresLikeRepository) GetByPost(ctx context.Context, postId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(ctx, "post_id", objectId, cursor, limit)
+}
+
+func (repo *PostgresLikeRepository) GetByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(ctx, "user_id", objectId, cursor, limit)
+}
+
+// last returns the likes whose column is id from the newest, after the cursor
+func (repo *PostgresLikeRepository) last(ctx context.Context, column string, id primitive.ObjectID, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	query := `SELECT id, user_id, post_id, created_at FROM likes WHERE ` + column + ` = $1`
+	args := []interface{}{id.Hex()}
+
+	if cursor != nil {
+		query += ` AND (created_at, id) < ($2, $3)`
+		args = append(args, postgres.Time(cursor.CreatedAt), cursor.ID.Hex())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, query+` ORDER BY created_at DESC, id DESC LIMIT `+strconv.Itoa(limit), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.Like
+
+	for rows.Next() {
+		var like entity.Like
+		if err := rows.Scan(
+			postgres.ScanID(&like.ID),
+			postgres.ScanID(&like.UserID),
+			postgres.ScanID(&like.PostID),
+			&like.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		like.CreatedAt = like.CreatedAt.UTC()
+		result = append(result, &like)
+	}
+
+	return result, rows.Err()
+}
+
+// incrementLikes never takes the likes of the post below zero, as the incrementField of the PostgresPostRepository
+func incrementLikes(ctx context.Context, tx *sql.Tx, postId primitive.ObjectID, value int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE posts SET likes_count = likes_count + $2 WHERE id = $1 AND likes_count + $2 >= 0`,
+		postId.Hex(),
+		value,
+	)
+
+	return err
+}
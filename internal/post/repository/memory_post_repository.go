@@ -0,0 +1,499 @@
+package post_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+// MemoryPostRepository keeps the posts on a memory.Database, with the same soft deletes,
+// keyset pagination and tombstones of quoted posts as the PostRepository
+type MemoryPostRepository struct {
+	database *memory.Database
+}
+
+func NewMemoryPostRepository(database *memory.Database) *MemoryPostRepository {
+	return &MemoryPostRepository{
+		database: database,
+	}
+}
+
+func (repo *MemoryPostRepository) Create(ctx context.Context, post *entity.Post) (string, error) {
+	post.CreatedAt = memory.Now()
+
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+
+	stored := *post
+	stored.QuotedPost = nil
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	repo.database.Posts[stored.ID] = &stored
+	repo.incrementPosts(post.UserID, 1)
+
+	if post.IsReply() {
+		repo.incrementReplies(post.InReplyToID, 1)
+	}
+
+	return post.ID.Hex(), nil
+}
+
+func (repo *MemoryPostRepository) Delete(ctx context.Context, id string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	post, ok := repo.database.Posts[objectId]
+
+	if !ok || post.DeletedAt != nil {
+		return false, nil
+	}
+
+	deletedAt := memory.Now()
+
+	deleted := *post
+	deleted.DeletedAt = &deletedAt
+
+	repo.database.Posts[objectId] = &deleted
+	repo.incrementPosts(post.UserID, -1)
+
+	if post.IsReply() {
+		repo.incrementReplies(post.InReplyToID, -1)
+	}
+
+	return true, nil
+}
+
+func (repo *MemoryPostRepository) Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	stored, ok := repo.database.Posts[post.ID]
+
+	if !ok || stored.DeletedAt != nil || stored.CreatedAt.Before(since) {
+		return false, nil
+	}
+
+	version := &entity.PostVersion{
+		Content:   stored.Content,
+		Hashtags:  stored.Hashtags,
+		Mentions:  stored.Mentions,
+		CreatedAt: stored.CreatedAt,
+	}
+
+	if stored.EditedAt != nil {
+		version.CreatedAt = *stored.EditedAt
+	}
+
+	repo.database.PostVersions[post.ID] = append(repo.database.PostVersions[post.ID], version)
+
+	editedAt := memory.Now()
+
+	stored.Content = post.Content
+	stored.Hashtags = post.Hashtags
+	stored.Mentions = post.Mentions
+	stored.EditedAt = &editedAt
+
+	post.EditedAt = &editedAt
+
+	return true, nil
+}
+
+func (repo *MemoryPostRepository) GetHistory(ctx context.Context, id string) ([]*entity.PostVersion, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var versions []*entity.PostVersion
+
+	for _, version := range repo.database.PostVersions[objectId] {
+		copied := *version
+		versions = append(versions, &copied)
+	}
+
+	return versions, nil
+}
+
+// incrementPosts changes the posts of the user as the post one does, the lock must be held
+func (repo *MemoryPostRepository) incrementPosts(userId primitive.ObjectID, value int) {
+	if user, ok := repo.database.Users[userId]; ok {
+		updated := *user
+
+		if memory.Increment(&updated.PostsCount, value) {
+			repo.database.Users[userId] = &updated
+		}
+	}
+}
+
+// incrementReplies changes the replied post as the PostRepository does in the transaction of the reply, the lock must be held
+func (repo *MemoryPostRepository) incrementReplies(postId primitive.ObjectID, value int) {
+	if post, ok := repo.database.Posts[postId]; ok {
+		updated := *post
+		memory.Increment(&updated.RepliesCount, value)
+		repo.database.Posts[postId] = &updated
+	}
+}
+
+func (repo *MemoryPostRepository) Find(ctx context.Context, id string) (*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts := repo.last(nil, -1, func(post *entity.Post) bool {
+		return post.ID == objectId
+	})
+
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	return posts[0], nil
+}
+
+func (repo *MemoryPostRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	postsObjectId, err := toObjectIDs(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[primitive.ObjectID]bool{}
+
+	for _, objId := range postsObjectId {
+		wanted[objId] = true
+	}
+
+	return repo.last(nil, -1, func(post *entity.Post) bool {
+		return wanted[post.ID]
+	}), nil
+}
+
+// GetReplies walks the replies of each direct reply as the $graphLookup of the PostRepository,
+// up to depth levels and never through a deleted reply
+func (repo *MemoryPostRepository) GetReplies(ctx context.Context, postId string, cursor *entity.Cursor, limit, depth int) ([]*entity.Reply, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var direct []*entity.Post
+
+	for _, post := range repo.database.Posts {
+		if post.InReplyToID == objectId && post.DeletedAt == nil && (cursor == nil || after(post, cursor)) {
+			direct = append(direct, post)
+		}
+	}
+
+	sort.Slice(direct, func(i, j int) bool {
+		return after(direct[j], &entity.Cursor{CreatedAt: direct[i].CreatedAt, ID: direct[i].ID})
+	})
+
+	if len(direct) > limit {
+		direct = direct[:limit]
+	}
+
+	var result []*entity.Reply
+
+	for _, post := range direct {
+		reply := &entity.Reply{Post: *post}
+		reply.QuotedPost = repo.quotedPost(post)
+
+		if depth > 1 {
+			reply.Descendants = repo.descendants(post.ID, depth-1)
+		}
+
+		result = append(result, reply)
+	}
+
+	return result, nil
+}
+
+// descendants returns the replies under the post up to levels below it, the lock must be held
+func (repo *MemoryPostRepository) descendants(postId primitive.ObjectID, levels int) []*entity.Post {
+	descendants := []*entity.Post{}
+
+	parents := map[primitive.ObjectID]bool{postId: true}
+
+	for level := 0; level < levels && len(parents) > 0; level++ {
+		children := map[primitive.ObjectID]bool{}
+
+		for _, post := range repo.database.Posts {
+			if parents[post.InReplyToID] && post.DeletedAt == nil {
+				found := *post
+				found.QuotedPost = repo.quotedPost(post)
+				descendants = append(descendants, &found)
+				children[post.ID] = true
+			}
+		}
+
+		parents = children
+	}
+
+	return descendants
+}
+
+func (repo *MemoryPostRepository) GetLastByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(cursor, limit, func(post *entity.Post) bool {
+		return post.UserID == objectId
+	}), nil
+}
+
+func (repo *MemoryPostRepository) GetLastByUsers(ctx context.Context, users, following []string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	followingObjectId, err := toObjectIDs(following)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authors := map[primitive.ObjectID]bool{}
+
+	for _, objId := range usersObjectId {
+		authors[objId] = true
+	}
+
+	// posts that are not replies have no in_reply_to_user_id, which is the zero id
+	repliedUsers := map[primitive.ObjectID]bool{primitive.NilObjectID: true}
+
+	for _, objId := range followingObjectId {
+		repliedUsers[objId] = true
+	}
+
+	return repo.last(cursor, limit, func(post *entity.Post) bool {
+		return authors[post.UserID] && (following == nil || repliedUsers[post.InReplyToUserID])
+	}), nil
+}
+
+func (repo *MemoryPostRepository) GetLastByHashtag(ctx context.Context, hashtag string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	return repo.last(cursor, limit, func(post *entity.Post) bool {
+		for _, postHashtag := range post.Hashtags {
+			if postHashtag == hashtag {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+func (repo *MemoryPostRepository) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) ([]*entity.TrendingHashtag, error) {
+	counts := map[string]int{}
+
+	repo.database.RLock()
+
+	for _, post := range repo.database.Posts {
+		if post.DeletedAt == nil && !post.CreatedAt.Before(since) {
+			for _, hashtag := range post.Hashtags {
+				counts[hashtag]++
+			}
+		}
+	}
+
+	repo.database.RUnlock()
+
+	var result []*entity.TrendingHashtag
+
+	for hashtag, count := range counts {
+		result = append(result, &entity.TrendingHashtag{Tag: hashtag, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+
+		return result[i].Tag < result[j].Tag
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryPostRepository) GetLastByMention(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(cursor, limit, func(post *entity.Post) bool {
+		for _, mention := range post.Mentions {
+			if mention.UserID == objectId {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+func (repo *MemoryPostRepository) CountByUserSince(ctx context.Context, id string, since time.Time) (int, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	count := 0
+
+	for _, post := range repo.database.Posts {
+		if post.UserID == objectId && !post.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (repo *MemoryPostRepository) FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var oldest *time.Time
+
+	for _, post := range repo.database.Posts {
+		if post.UserID == objectId && !post.CreatedAt.Before(since) && (oldest == nil || post.CreatedAt.Before(*oldest)) {
+			createdAt := post.CreatedAt
+			oldest = &createdAt
+		}
+	}
+
+	return oldest, nil
+}
+
+func (repo *MemoryPostRepository) CountByUsers(ctx context.Context, users []string) (map[string]int64, error) {
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[primitive.ObjectID]bool{}
+
+	for _, objId := range usersObjectId {
+		wanted[objId] = true
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	result := make(map[string]int64)
+
+	for _, post := range repo.database.Posts {
+		if wanted[post.UserID] && post.DeletedAt == nil {
+			result[post.UserID.Hex()]++
+		}
+	}
+
+	return result, nil
+}
+
+// last returns the posts that were not deleted and match, from the newest and after the cursor,
+// with their quoted posts. A negative limit returns all of them
+func (repo *MemoryPostRepository) last(cursor *entity.Cursor, limit int, match func(post *entity.Post) bool) []*entity.Post {
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var result []*entity.Post
+
+	for _, post := range repo.database.Posts {
+		if post.DeletedAt == nil && match(post) && (cursor == nil || before(post, cursor)) {
+			found := *post
+			found.QuotedPost = repo.quotedPost(post)
+			result = append(result, &found)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[j], &entity.Cursor{CreatedAt: result[i].CreatedAt, ID: result[i].ID})
+	})
+
+	if limit >= 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// quotedPost is the post referenced by ParentID or its tombstone, the lock must be held
+func (repo *MemoryPostRepository) quotedPost(post *entity.Post) *entity.QuotedPost {
+	quoted, ok := repo.database.Posts[post.ParentID]
+
+	if post.ParentID.IsZero() || !ok {
+		return nil
+	}
+
+	if quoted.DeletedAt != nil {
+		return &entity.QuotedPost{ID: quoted.ID, Deleted: true}
+	}
+
+	return &entity.QuotedPost{
+		ID:        quoted.ID,
+		UserID:    quoted.UserID,
+		Content:   quoted.Content,
+		CreatedAt: quoted.CreatedAt,
+		EditedAt:  quoted.EditedAt,
+	}
+}
+
+// before is whether the post comes after the cursor on a listing from the newest
+func before(post *entity.Post, cursor *entity.Cursor) bool {
+	return post.CreatedAt.Before(cursor.CreatedAt) ||
+		post.CreatedAt.Equal(cursor.CreatedAt) && memory.CompareIDs(post.ID, cursor.ID) < 0
+}
+
+// after is whether the post comes after the cursor on a listing from the oldest
+func after(post *entity.Post, cursor *entity.Cursor) bool {
+	return post.CreatedAt.After(cursor.CreatedAt) ||
+		post.CreatedAt.Equal(cursor.CreatedAt) && memory.CompareIDs(post.ID, cursor.ID) > 0
+}
@@ -2,165 +2,668 @@ package post_repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
 	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/post/entity"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
 type Repository interface {
-	Create(userId, content, parentId string) (string, error)
-	GetLastByUser(userId string, page, limit int) ([]*entity.Post, error)
-	GetLastByUsers(users []string, page, limit int) ([]*entity.Post, error)
-	GetNumberOfUsersPostsByDay(id string, day time.Time) (int, error)
+	Create(ctx context.Context, post *entity.Post) (string, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error)
+	GetHistory(ctx context.Context, id string) ([]*entity.PostVersion, error)
+	Find(ctx context.Context, id string) (*entity.Post, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*entity.Post, error)
+	GetReplies(ctx context.Context, postId string, cursor *entity.Cursor, limit, depth int) ([]*entity.Reply, error)
+	GetLastByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error)
+	GetLastByUsers(ctx context.Context, users, following []string, cursor *entity.Cursor, limit int) ([]*entity.Post, error)
+	GetLastByHashtag(ctx context.Context, hashtag string, cursor *entity.Cursor, limit int) ([]*entity.Post, error)
+	GetTrendingHashtags(ctx context.Context, since time.Time, limit int) ([]*entity.TrendingHashtag, error)
+	GetLastByMention(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error)
+	CountByUserSince(ctx context.Context, id string, since time.Time) (int, error)
+	FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error)
+	CountByUsers(ctx context.Context, users []string) (map[string]int64, error)
+}
+
+// NewRepository returns the repository of the driver set on app.storage.driver
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewPostRepository(client, configs), nil
+	case "memory":
+		return NewMemoryPostRepository(database), nil
+	case "postgres":
+		return NewPostgresPostRepository(db), nil
+	default:
+		return nil, fmt.Errorf("post_repository: unknown storage driver %q", driver)
+	}
 }
 
 type PostRepository struct {
-	collection *mongo.Collection
+	collection         *mongo.Collection
+	versionsCollection *mongo.Collection
+	usersCollection    *mongo.Collection
+}
+
+// postVersion is a version of the post of PostID on the versions collection
+type postVersion struct {
+	PostID             primitive.ObjectID `bson:"post_id"`
+	entity.PostVersion `bson:",inline"`
 }
 
 func NewPostRepository(client *mongo.Client, configs *viper.Viper) *PostRepository {
-	postsCollection := client.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.post-collection"),
-	)
+	database := client.Database(configs.GetString("app.mongodb.database"))
 
 	return &PostRepository{
-		collection: postsCollection,
+		collection:         database.Collection(configs.GetString("app.mongodb.post-collection")),
+		versionsCollection: database.Collection(configs.GetString("app.mongodb.post-version-collection")),
+		usersCollection:    database.Collection(configs.GetString("app.mongodb.user-collection")),
 	}
 }
 
-func (repo *PostRepository) Create(userId, parentId, content string) (string, error) {
-	objectId, err := primitive.ObjectIDFromHex(userId)
+// Create inserts the post and increments the posts of its user, and a reply the replies of the
+// replied post, in the same transaction
+func (repo *PostRepository) Create(ctx context.Context, post *entity.Post) (string, error) {
+	post.CreatedAt = time.Now()
+
+	err := mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		result, err := repo.collection.InsertOne(ctx, post)
+
+		if err != nil {
+			if mongodb.IsDup(err) {
+				return mongodb.ErrDuplicateKey
+			}
+			return err
+		}
+
+		post.ID = result.InsertedID.(primitive.ObjectID)
+
+		if err := repo.incrementPosts(ctx, post.UserID, 1); err != nil {
+			return err
+		}
+
+		if !post.IsReply() {
+			return nil
+		}
+
+		return repo.incrementField(ctx, post.InReplyToID.Hex(), "replies_count", 1)
+	})
 
 	if err != nil {
 		return "", err
 	}
 
-	post := entity.Post{
-		UserID:    objectId,
-		Content:   content,
-		CreatedAt: time.Now(),
+	return post.ID.Hex(), nil
+}
+
+// Delete is a soft delete, the post stays on the collection so reposts and
+// quotes referencing it can still show that it existed. It returns whether this
+// call deleted the post, so concurrent deletes do not both run their side effects.
+// The posts of its user, and the replies of the replied post for a reply, are
+// decremented in the same transaction
+func (repo *PostRepository) Delete(ctx context.Context, id string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
 	}
 
-	if parentId != "" {
-		parentId, err := primitive.ObjectIDFromHex(userId)
+	deleted := false
+
+	err = mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		var post entity.Post
+
+		err := repo.collection.FindOneAndUpdate(
+			ctx,
+			bson.M{"_id": objectId, "deleted_at": bson.M{"$exists": false}},
+			bson.D{
+				{"$set", bson.D{{"deleted_at", time.Now()}}},
+			},
+			options.FindOneAndUpdate().SetProjection(bson.D{{"user_id", 1}, {"in_reply_to_id", 1}}),
+		).Decode(&post)
+
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
 
 		if err != nil {
-			return "", err
+			return err
 		}
 
-		post.ParentID = parentId
-	}
+		deleted = true
 
-	result, err := repo.collection.InsertOne(context.TODO(), post)
+		if err := repo.incrementPosts(ctx, post.UserID, -1); err != nil {
+			return err
+		}
+
+		if !post.IsReply() {
+			return nil
+		}
+
+		return repo.incrementField(ctx, post.InReplyToID.Hex(), "replies_count", -1)
+	})
 
 	if err != nil {
-		if mongodb.IsDup(err) {
-			return "", mongodb.ErrDuplicateKey
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// Edit replaces the content, hashtags and mentions of the post unless it was deleted or created
+// before since, returning whether it did. The previous ones are inserted on the versions collection
+// in the same transaction, so concurrent edits do not lose a version and reading the post does not
+// read its versions
+func (repo *PostRepository) Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error) {
+	editedAt := time.Now()
+
+	set := bson.D{{"content", post.Content}, {"edited_at", editedAt}}
+	unset := bson.D{}
+
+	if len(post.Hashtags) > 0 {
+		set = append(set, bson.E{"hashtags", post.Hashtags})
+	} else {
+		unset = append(unset, bson.E{"hashtags", ""})
+	}
+
+	if len(post.Mentions) > 0 {
+		set = append(set, bson.E{"mentions", post.Mentions})
+	} else {
+		unset = append(unset, bson.E{"mentions", ""})
+	}
+
+	update := bson.D{{"$set", set}}
+
+	if len(unset) > 0 {
+		update = append(update, bson.E{"$unset", unset})
+	}
+
+	edited := false
+
+	err := mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		edited = false
+
+		var previous entity.Post
+
+		err := repo.collection.FindOneAndUpdate(
+			ctx,
+			bson.D{
+				{"_id", post.ID},
+				{"deleted_at", bson.D{{"$exists", false}}},
+				{"created_at", bson.D{{"$gte", since}}},
+			},
+			update,
+			options.FindOneAndUpdate().SetProjection(bson.D{
+				{"content", 1}, {"hashtags", 1}, {"mentions", 1}, {"created_at", 1}, {"edited_at", 1},
+			}),
+		).Decode(&previous)
+
+		if err == mongo.ErrNoDocuments {
+			return nil
 		}
-		return "", err
+
+		if err != nil {
+			return err
+		}
+
+		// a version was published when the post was created or at the edit before it
+		createdAt := previous.CreatedAt
+
+		if previous.EditedAt != nil {
+			createdAt = *previous.EditedAt
+		}
+
+		_, err = repo.versionsCollection.InsertOne(ctx, postVersion{
+			PostID: post.ID,
+			PostVersion: entity.PostVersion{
+				Content:   previous.Content,
+				Hashtags:  previous.Hashtags,
+				Mentions:  previous.Mentions,
+				CreatedAt: createdAt,
+			},
+		})
+
+		if err != nil {
+			return err
+		}
+
+		edited = true
+
+		return nil
+	})
+
+	if err != nil || !edited {
+		return false, err
 	}
 
-	objectId = result.InsertedID.(primitive.ObjectID)
+	post.EditedAt = &editedAt
 
-	return objectId.Hex(), err
+	return true, nil
 }
 
-func (repo *PostRepository) GetLastByUser(userId string, page, limit int) ([]*entity.Post, error) {
-	objectId, err := primitive.ObjectIDFromHex(userId)
+// GetHistory returns the versions the post had before its edits, from the oldest
+func (repo *PostRepository) GetHistory(ctx context.Context, id string) ([]*entity.PostVersion, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*entity.Post
+	cursor, err := repo.versionsCollection.Find(
+		ctx,
+		bson.M{"post_id": objectId},
+		options.Find().SetSort(bson.D{{"_id", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
 
-	matchStage := bson.D{{"$match", bson.D{{"user_id", objectId}}}}
+	var versions []*entity.PostVersion
 
-	sortStage := generateSortStage()
-	paginationStage := generatePaginationStage(page, limit)
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// incrementPosts never takes the posts of the user below zero, as UserRepository.IncrementField
+func (repo *PostRepository) incrementPosts(ctx context.Context, userId primitive.ObjectID, value int) error {
+	filter := bson.M{"_id": userId}
+
+	if value < 0 {
+		filter["posts_count"] = bson.M{"$gte": -value}
+	}
+
+	_, err := repo.usersCollection.UpdateOne(ctx, filter, bson.D{{"$inc", bson.D{{"posts_count", value}}}})
+
+	return err
+}
+
+func (repo *PostRepository) incrementField(ctx context.Context, id, field string, value int) error {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.collection.UpdateOne(ctx, bson.M{"_id": objectId},
+		bson.D{
+			{"$inc",
+				bson.D{
+					{field, value},
+				},
+			},
+		},
+	)
+
+	return err
+}
+
+func (repo *PostRepository) Find(ctx context.Context, id string) (*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := repo.aggregate(ctx, mongo.Pipeline{
+		generateMatchStage(bson.D{{"_id", objectId}}, nil),
+		generateLookUpStage(),
+		generateQuotedPostStage(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	return posts[0], nil
+}
+
+func (repo *PostRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	postsObjectId, err := toObjectIDs(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.aggregate(ctx, mongo.Pipeline{
+		generateMatchStage(bson.D{{"_id", bson.D{{"$in", postsObjectId}}}}, nil),
+		generateSortStage(-1),
+		generateLookUpStage(),
+		generateQuotedPostStage(),
+	})
+}
+
+// GetReplies returns the direct replies of a post from the oldest to the newest, each one
+// with the replies under it up to depth levels, the first level included
+func (repo *PostRepository) GetReplies(ctx context.Context, postId string, cursor *entity.Cursor, limit, depth int) ([]*entity.Reply, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		generateAscendingMatchStage(bson.D{{"in_reply_to_id", objectId}}, cursor),
+		generateSortStage(1),
+		generateLimitStage(limit),
+		generateLookUpStage(),
+		generateQuotedPostStage(),
+	}
+
+	if depth > 1 {
+		pipeline = append(pipeline, bson.D{
+			{
+				"$graphLookup",
+				bson.D{
+					{"from", repo.collection.Name()},
+					{"startWith", "$_id"},
+					{"connectFromField", "_id"},
+					{"connectToField", "in_reply_to_id"},
+					{"as", "descendants"},
+					{"maxDepth", depth - 2},
+					{"restrictSearchWithMatch", bson.D{{"deleted_at", bson.D{{"$exists", false}}}}},
+				},
+			},
+		})
+
+		// $graphLookup returns the descendants as they are stored, so they are read again
+		// with their quoted posts as the direct replies are
+		pipeline = append(pipeline, bson.D{
+			{
+				"$lookup",
+				bson.D{
+					{"from", repo.collection.Name()},
+					{"let", bson.D{{"descendants", "$descendants._id"}}},
+					{"pipeline", mongo.Pipeline{
+						{{"$match", bson.D{{"$expr", bson.D{{"$in", bson.A{"$_id", "$$descendants"}}}}}}},
+						generateLookUpStage(),
+						generateQuotedPostStage(),
+					}},
+					{"as", "descendants"},
+				},
+			},
+		})
+	}
+
+	var result []*entity.Reply
+
+	curr, err := repo.collection.Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var reply entity.Reply
+		if err := curr.Decode(&reply); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &reply)
+	}
+
+	return result, curr.Err()
+}
+
+func (repo *PostRepository) GetLastByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matchStage := generateMatchStage(bson.D{{"user_id", objectId}}, cursor)
+
+	sortStage := generateSortStage(-1)
 	limitStage := generateLimitStage(limit)
 	lookUpStage := generateLookUpStage()
 	quotedPostStage := generateQuotedPostStage()
 
-	curr, err := repo.collection.Aggregate(context.TODO(), mongo.Pipeline{
+	return repo.aggregate(ctx, mongo.Pipeline{
 		matchStage,
 		sortStage,
-		paginationStage,
 		limitStage,
 		lookUpStage,
 		quotedPostStage,
 	})
+}
+
+// GetLastByUsers returns the posts of users for a feed, their replies are only
+// returned when the replied user is on following too, or all of them when following is nil
+func (repo *PostRepository) GetLastByUsers(ctx context.Context, users, following []string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	usersObjectId, err := toObjectIDs(users)
 
 	if err != nil {
 		return nil, err
 	}
 
-	for curr.Next(context.TODO()) {
-		var post entity.Post
-		if err := curr.Decode(&post); err != nil {
+	filter := bson.D{
+		{
+			"user_id",
+			bson.D{
+				{"$in", usersObjectId},
+			},
+		},
+	}
+
+	if following != nil {
+		followingObjectId, err := toObjectIDs(following)
+
+		if err != nil {
 			return nil, err
 		}
 
-		result = append(result, &post)
+		// posts that are not replies have no in_reply_to_user_id, which matches nil
+		repliedUsers := bson.A{nil}
+
+		for _, objId := range followingObjectId {
+			repliedUsers = append(repliedUsers, objId)
+		}
+
+		filter = append(filter, bson.E{"in_reply_to_user_id", bson.D{{"$in", repliedUsers}}})
 	}
 
-	return result, nil
+	matchStage := generateMatchStage(filter, cursor)
+
+	sortStage := generateSortStage(-1)
+	limitStage := generateLimitStage(limit)
+	lookUpStage := generateLookUpStage()
+	quotedPostStage := generateQuotedPostStage()
+
+	return repo.aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		sortStage,
+		limitStage,
+		lookUpStage,
+		quotedPostStage,
+	})
 }
 
-func (repo *PostRepository) GetLastByUsers(users []string, page, limit int) ([]*entity.Post, error) {
-	var result []*entity.Post
+func (repo *PostRepository) GetLastByHashtag(ctx context.Context, hashtag string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	matchStage := generateMatchStage(bson.D{{"hashtags", hashtag}}, cursor)
 
-	var usersObjectId []primitive.ObjectID
+	sortStage := generateSortStage(-1)
+	limitStage := generateLimitStage(limit)
+	lookUpStage := generateLookUpStage()
+	quotedPostStage := generateQuotedPostStage()
 
-	for _, user := range users {
-		objId, err := primitive.ObjectIDFromHex(user)
-		if err != nil {
-			return nil, err
-		}
+	return repo.aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		sortStage,
+		limitStage,
+		lookUpStage,
+		quotedPostStage,
+	})
+}
+
+// GetTrendingHashtags returns the hashtags used on most posts created since the given time,
+// ties are ordered by the hashtag so the ranking is stable
+func (repo *PostRepository) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) ([]*entity.TrendingHashtag, error) {
+	curr, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		{{"$match", bson.D{
+			{"created_at", bson.D{{"$gte", primitive.NewDateTimeFromTime(since)}}},
+			{"deleted_at", bson.D{{"$exists", false}}},
+			{"hashtags.0", bson.D{{"$exists", true}}},
+		}}},
+		{{"$project", bson.D{{"hashtags", 1}}}},
+		{{"$unwind", "$hashtags"}},
+		{{"$group", bson.D{{"_id", "$hashtags"}, {"count", bson.D{{"$sum", 1}}}}}},
+		{{"$sort", bson.D{{"count", -1}, {"_id", 1}}}},
+		generateLimitStage(limit),
+	})
 
-		usersObjectId = append(usersObjectId, objId)
+	if err != nil {
+		return nil, err
 	}
 
-	matchStage := bson.D{
-		{
-			"$match",
-			bson.D{
-				{
-					"user_id",
-					bson.D{
-						{"$in", usersObjectId},
-					},
-				},
-			},
-		},
+	var result []*entity.TrendingHashtag
+
+	if err := curr.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLastByMention returns the posts that mention the user, from the newest
+func (repo *PostRepository) GetLastByMention(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
 	}
 
-	sortStage := generateSortStage()
-	paginationStage := generatePaginationStage(page, limit)
+	matchStage := generateMatchStage(bson.D{{"mentions.user_id", objectId}}, cursor)
+
+	sortStage := generateSortStage(-1)
 	limitStage := generateLimitStage(limit)
 	lookUpStage := generateLookUpStage()
 	quotedPostStage := generateQuotedPostStage()
 
-	curr, err := repo.collection.Aggregate(context.TODO(), mongo.Pipeline{
+	return repo.aggregate(ctx, mongo.Pipeline{
 		matchStage,
 		sortStage,
-		paginationStage,
 		limitStage,
 		lookUpStage,
 		quotedPostStage,
 	})
+}
+
+// CountByUserSince counts the posts created by the user since the given time, deleted ones included
+func (repo *PostRepository) CountByUserSince(ctx context.Context, id string, since time.Time) (int, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	postNumber, err := repo.collection.CountDocuments(ctx, bson.M{"user_id": objectId, "created_at": bson.M{
+		"$gte": primitive.NewDateTimeFromTime(since),
+	}})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(postNumber), nil
+}
+
+// FindOldestCreatedAtSince returns when the oldest post created by the user since the given
+// time was created, deleted ones included, or nil when there is none
+func (repo *PostRepository) FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var post entity.Post
+
+	err = repo.collection.FindOne(
+		ctx,
+		bson.M{"user_id": objectId, "created_at": bson.M{"$gte": primitive.NewDateTimeFromTime(since)}},
+		options.FindOne().SetSort(bson.D{{"created_at", 1}}).SetProjection(bson.D{{"created_at", 1}}),
+	).Decode(&post)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &post.CreatedAt, nil
+}
+
+// CountByUsers returns how many posts that were not deleted each user has, users without posts are left out
+func (repo *PostRepository) CountByUsers(ctx context.Context, users []string) (map[string]int64, error) {
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	curr, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		generateMatchStage(bson.D{{"user_id", bson.D{{"$in", usersObjectId}}}}, nil),
+		{{"$group", bson.D{{"_id", "$user_id"}, {"count", bson.D{{"$sum", 1}}}}}},
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	for curr.Next(context.TODO()) {
+	result := make(map[string]int64)
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var count struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		}
+		if err := curr.Decode(&count); err != nil {
+			return nil, err
+		}
+
+		result[count.ID.Hex()] = count.Count
+	}
+
+	return result, curr.Err()
+}
+
+func (repo *PostRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline) ([]*entity.Post, error) {
+	var result []*entity.Post
+
+	curr, err := repo.collection.Aggregate(ctx, pipeline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
 		var post entity.Post
 		if err := curr.Decode(&post); err != nil {
 			return nil, err
@@ -169,39 +672,59 @@ func (repo *PostRepository) GetLastByUsers(users []string, page, limit int) ([]*
 		result = append(result, &post)
 	}
 
-	return result, nil
+	return result, curr.Err()
 }
 
-func (repo *PostRepository) GetNumberOfUsersPostsByDay(id string, day time.Time) (int, error) {
-	day = time.Date(day.Year(), day.Month(), day.Day(), 6, 0, 0, day.Nanosecond(), day.Location())
+func toObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	var result []primitive.ObjectID
 
-	objectId, err := primitive.ObjectIDFromHex(id)
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return 0, err
+		result = append(result, objId)
 	}
 
-	postNumber, err := repo.collection.CountDocuments(context.TODO(), bson.M{"user_id": objectId, "created_at": bson.M{
-		"$gte": primitive.NewDateTimeFromTime(day),
-	}})
+	return result, nil
+}
 
-	if err != nil {
-		return 0, err
-	}
+// generateMatchStage applies the keyset condition of the cursor over the filter,
+// so the user_id, created_at index is used as a range scan instead of skipping documents
+func generateMatchStage(filter bson.D, cursor *entity.Cursor) bson.D {
+	return generateKeysetMatchStage(filter, cursor, "$lt")
+}
 
-	return int(postNumber), nil
+// generateAscendingMatchStage is the generateMatchStage of listings from the oldest to the newest
+func generateAscendingMatchStage(filter bson.D, cursor *entity.Cursor) bson.D {
+	return generateKeysetMatchStage(filter, cursor, "$gt")
 }
 
-func generateSortStage() bson.D {
+func generateKeysetMatchStage(filter bson.D, cursor *entity.Cursor, operator string) bson.D {
+	filter = append(filter, bson.E{"deleted_at", bson.D{{"$exists", false}}})
+
+	if cursor != nil {
+		filter = append(filter, bson.E{
+			"$or",
+			bson.A{
+				bson.D{{"created_at", bson.D{{operator, cursor.CreatedAt}}}},
+				bson.D{
+					{"created_at", cursor.CreatedAt},
+					{"_id", bson.D{{operator, cursor.ID}}},
+				},
+			},
+		})
+	}
+
 	return bson.D{
-		{"$sort", bson.D{{"created_at", -1}}},
+		{"$match", filter},
 	}
 }
 
-func generatePaginationStage(page int, limit int) bson.D {
-	skip := int64(page*limit - limit)
+func generateSortStage(order int) bson.D {
 	return bson.D{
-		{"$skip", skip},
+		{"$sort", bson.D{{"created_at", order}, {"_id", order}}},
 	}
 }
 
@@ -225,6 +748,7 @@ func generateLookUpStage() bson.D {
 	}
 }
 
+// generateQuotedPostStage replaces a deleted quoted post by a tombstone with only its id
 func generateQuotedPostStage() bson.D {
 	return bson.D{
 		{
@@ -232,7 +756,18 @@ func generateQuotedPostStage() bson.D {
 			bson.D{
 				{
 					"quoted_post",
-					bson.D{{"$first", "$quoted_post"}},
+					bson.D{
+						{"$let", bson.D{
+							{"vars", bson.D{{"quoted", bson.D{{"$first", "$quoted_post"}}}}},
+							{"in", bson.D{
+								{"$cond", bson.A{
+									bson.D{{"$ifNull", bson.A{"$$quoted.deleted_at", false}}},
+									bson.D{{"_id", "$$quoted._id"}, {"deleted", true}},
+									"$$quoted",
+								}},
+							}},
+						}},
+					},
 				},
 			},
 		},
@@ -2,11 +2,16 @@ package post_repository
 
 import (
 	"context"
+	"database/sql"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
 	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	user_entity "github.com/regiszanandrea/posty/internal/user/entity"
+	user_repository "github.com/regiszanandrea/posty/internal/user/repository/user"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,7 +26,9 @@ func TestFollowerRepository(t *testing.T) {
 }
 
 var (
-	postRepository *PostRepository
+	postRepository Repository
+	userRepository user_repository.Repository
+	db             *sql.DB
 	client         *mongo.Client
 	configs        *viper.Viper
 )
@@ -31,34 +38,81 @@ var _ = BeforeSuite(func() {
 
 	client = mongodb.NewMongoDBClient(configs)
 
-	err := client.Connect(context.Background())
+	// the suite runs against the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		err = mongodb.CreateIndexes(client, configs, context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
 
 	if err != nil {
 		panic(err)
 	}
 
-	err = mongodb.CreateIndexes(client, configs, context.Background())
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	database := memory.NewDatabase()
+
+	postRepository, err = NewRepository(client, db, database, configs)
 
 	if err != nil {
 		panic(err)
 	}
 
-	postRepository = NewPostRepository(
-		client, configs,
-	)
+	userRepository, err = user_repository.NewRepository(client, db, database, configs)
+
+	if err != nil {
+		panic(err)
+	}
 })
 
 var _ = AfterSuite(func() {
-	postsCollection := client.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.post-collection"),
-	)
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE posts, post_versions, users")
 
-	_, err := postsCollection.DeleteMany(context.Background(), bson.M{})
+		if err != nil {
+			panic(err)
+		}
 
-	if err != nil {
-		panic(err)
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
+	for _, collection := range []string{
+		"app.mongodb.post-collection",
+		"app.mongodb.post-version-collection",
+		"app.mongodb.user-collection",
+	} {
+		_, err := client.Database(
+			configs.GetString("app.mongodb.database"),
+		).Collection(
+			configs.GetString(collection),
+		).DeleteMany(context.Background(), bson.M{})
+
+		if err != nil {
+			panic(err)
+		}
 	}
 })
 
@@ -66,7 +120,7 @@ var _ = Describe("PostRepository suite test", func() {
 	Describe("Creating a post", func() {
 		Context("when its given a valid post", func() {
 			It("creates it without error", func() {
-				id, err := postRepository.Create(primitive.NewObjectID().Hex(), "this is a post", "")
+				id, err := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(id)).To(BeTrue())
@@ -75,22 +129,20 @@ var _ = Describe("PostRepository suite test", func() {
 
 		Context("when its given a quote-post", func() {
 			It("creates it without error", func() {
-				request := entity.CreatePostRequest{
-					UserID:  primitive.NewObjectID().Hex(),
+				post := &entity.Post{
+					UserID:  primitive.NewObjectID(),
 					Content: "this is a post",
 				}
 
-				id, _ := postRepository.Create(request.UserID, request.Content, "")
-
-				parentId, _ := primitive.ObjectIDFromHex(id)
+				_, _ = postRepository.Create(context.Background(), post)
 
-				quotePost := entity.CreatePostRequest{
-					UserID:   primitive.NewObjectID().Hex(),
+				quotePost := &entity.Post{
+					UserID:   primitive.NewObjectID(),
 					Content:  "this is a quote-post",
-					ParentID: parentId.Hex(),
+					ParentID: post.ID,
 				}
 
-				id, err := postRepository.Create(quotePost.UserID, quotePost.Content, quotePost.ParentID)
+				id, err := postRepository.Create(context.Background(), quotePost)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(id)).To(BeTrue())
@@ -98,40 +150,218 @@ var _ = Describe("PostRepository suite test", func() {
 		})
 	})
 
-	Describe("Getting number of posts by day", func() {
-		Context("when its given two posts on a day", func() {
+	Describe("Deleting a post", func() {
+		Context("when its given an existing post", func() {
+			It("does not return it anymore", func() {
+				id, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
+
+				deleted, err := postRepository.Delete(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(deleted).To(BeTrue())
+
+				post, err := postRepository.Find(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(post).To(BeNil())
+			})
+		})
+
+		Context("when its given a post of an existing user", func() {
+			It("counts it on the posts of the user until it is deleted", func() {
+				userId, _ := userRepository.Create(context.Background(), &user_entity.User{
+					Username:  "testPostsCount",
+					CreatedAt: time.Now(),
+				})
+
+				user, _ := primitive.ObjectIDFromHex(userId)
+
+				id, _ := postRepository.Create(context.Background(), &entity.Post{UserID: user, Content: "this is a post"})
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: user, Content: "this is another post"})
+
+				found, _ := userRepository.Find(context.Background(), userId)
+
+				Expect(found.PostsCount).To(Equal(uint(2)))
+
+				_, _ = postRepository.Delete(context.Background(), id)
+				_, _ = postRepository.Delete(context.Background(), id)
+
+				found, _ = userRepository.Find(context.Background(), userId)
+
+				Expect(found.PostsCount).To(Equal(uint(1)))
+			})
+		})
+
+		Context("when its already deleted", func() {
+			It("returns that it did not delete it", func() {
+				id, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
+
+				_, _ = postRepository.Delete(context.Background(), id)
+
+				deleted, err := postRepository.Delete(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(deleted).To(BeFalse())
+			})
+		})
+
+		Context("when its quoted by another post", func() {
+			It("renders a tombstone on the quote", func() {
+				user := primitive.NewObjectID()
+
+				post := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				id, _ := postRepository.Create(context.Background(), post)
+				quoteId, _ := postRepository.Create(context.Background(), &entity.Post{UserID: user, ParentID: post.ID, Content: "this is a quote-post"})
+
+				_, _ = postRepository.Delete(context.Background(), id)
+
+				quote, err := postRepository.Find(context.Background(), quoteId)
+
+				Expect(err).To(BeNil())
+				Expect(quote.QuotedPost).NotTo(BeNil())
+				Expect(quote.QuotedPost.ID.Hex()).To(Equal(id))
+				Expect(quote.QuotedPost.Deleted).To(BeTrue())
+				Expect(quote.QuotedPost.Content).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Editing a post", func() {
+		Context("when its given a post created since the given time", func() {
+			It("replaces it and keeps the previous versions, from the oldest", func() {
+				user := primitive.NewObjectID()
+				post := &entity.Post{UserID: user, Content: "this is a pots #typo", Hashtags: []string{"typo"}}
+				id, _ := postRepository.Create(context.Background(), post)
+				since := post.CreatedAt.Add(-time.Minute)
+
+				edited, err := postRepository.Edit(context.Background(), &entity.Post{
+					ID:      post.ID,
+					Content: "this is a post for @maria",
+					Mentions: []*entity.Mention{
+						{UserID: user, Username: "maria", Start: 19, End: 25},
+					},
+				}, since)
+
+				Expect(err).To(BeNil())
+				Expect(edited).To(BeTrue())
+
+				first, _ := postRepository.Find(context.Background(), id)
+
+				// the content is literal, not a field of the post
+				edited, err = postRepository.Edit(context.Background(), &entity.Post{ID: post.ID, Content: "$content #Fixed", Hashtags: []string{"fixed"}}, since)
+
+				Expect(err).To(BeNil())
+				Expect(edited).To(BeTrue())
+
+				found, err := postRepository.Find(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(found.Content).To(Equal("$content #Fixed"))
+				Expect(found.Hashtags).To(Equal([]string{"fixed"}))
+				Expect(found.Mentions).To(BeEmpty())
+				Expect(found.EditedAt).NotTo(BeNil())
+				Expect(found.CreatedAt).To(BeTemporally("~", post.CreatedAt, time.Millisecond))
+
+				versions, err := postRepository.GetHistory(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(versions).To(HaveLen(2))
+				Expect(versions[0].Content).To(Equal("this is a pots #typo"))
+				Expect(versions[0].Hashtags).To(Equal([]string{"typo"}))
+				Expect(versions[0].CreatedAt).To(BeTemporally("~", post.CreatedAt, time.Millisecond))
+				Expect(versions[1].Content).To(Equal("this is a post for @maria"))
+				Expect(versions[1].Mentions).To(HaveLen(1))
+				Expect(versions[1].Mentions[0].UserID).To(Equal(user))
+				Expect(versions[1].CreatedAt).To(Equal(*first.EditedAt))
+
+				posts, _ := postRepository.GetLastByHashtag(context.Background(), "typo", nil, 10)
+
+				Expect(posts).To(BeEmpty())
+			})
+		})
+
+		Context("when its given a post created before the given time", func() {
+			It("does not edit it", func() {
+				post := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				id, _ := postRepository.Create(context.Background(), post)
+
+				edited, err := postRepository.Edit(context.Background(), &entity.Post{ID: post.ID, Content: "too late"}, post.CreatedAt.Add(time.Minute))
+
+				Expect(err).To(BeNil())
+				Expect(edited).To(BeFalse())
+
+				found, _ := postRepository.Find(context.Background(), id)
+				versions, _ := postRepository.GetHistory(context.Background(), id)
+
+				Expect(found.Content).To(Equal("this is a post"))
+				Expect(found.EditedAt).To(BeNil())
+				Expect(versions).To(BeEmpty())
+			})
+		})
+
+		Context("when its given a deleted post", func() {
+			It("does not edit it", func() {
+				post := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				id, _ := postRepository.Create(context.Background(), post)
+
+				_, _ = postRepository.Delete(context.Background(), id)
+
+				edited, err := postRepository.Edit(context.Background(), &entity.Post{ID: post.ID, Content: "too late"}, post.CreatedAt.Add(-time.Minute))
+
+				Expect(err).To(BeNil())
+				Expect(edited).To(BeFalse())
+			})
+		})
+
+		Context("when its quoted by another post", func() {
+			It("shows the edited content on the quote", func() {
+				post := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a pots"}
+				_, _ = postRepository.Create(context.Background(), post)
+				quoteId, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), ParentID: post.ID, Content: "this is a quote-post"})
+
+				_, _ = postRepository.Edit(context.Background(), &entity.Post{ID: post.ID, Content: "this is a post"}, post.CreatedAt.Add(-time.Minute))
+
+				quote, err := postRepository.Find(context.Background(), quoteId)
+
+				Expect(err).To(BeNil())
+				Expect(quote.QuotedPost.Content).To(Equal("this is a post"))
+				Expect(quote.QuotedPost.EditedAt).NotTo(BeNil())
+			})
+		})
+	})
+
+	Describe("Counting the posts of a user since a time", func() {
+		Context("when its given two posts since then", func() {
 			It("returns two posts", func() {
 				numberOfPostsExpected := 2
 
 				user := primitive.NewObjectID()
 				createPosts(user, numberOfPostsExpected)
 
-				numberOfPosts, err := postRepository.GetNumberOfUsersPostsByDay(user.Hex(), time.Now())
+				numberOfPosts, err := postRepository.CountByUserSince(context.Background(), user.Hex(), time.Now().Add(-time.Hour))
 
 				Expect(err).To(BeNil())
 				Expect(numberOfPosts).To(Equal(numberOfPostsExpected))
 			})
 		})
 
-		Context("when its given a user without posts on a day", func() {
+		Context("when its given a user without posts", func() {
 			It("returns no posts", func() {
-				_, _ = postRepository.Create(primitive.NewObjectID().Hex(), "this is a post", "")
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
 
-				numberOfPosts, err := postRepository.GetNumberOfUsersPostsByDay(primitive.NewObjectID().Hex(), time.Now())
+				numberOfPosts, err := postRepository.CountByUserSince(context.Background(), primitive.NewObjectID().Hex(), time.Now().Add(-time.Hour))
 
 				Expect(err).To(BeNil())
 				Expect(numberOfPosts).To(Equal(0))
 			})
 		})
 
-		Context("when its given a user with posts but not at that day", func() {
+		Context("when its given a user with posts but before that time", func() {
 			It("returns no posts", func() {
-				_, _ = postRepository.Create(primitive.NewObjectID().Hex(), "this is a post", "")
+				user := primitive.NewObjectID()
+				createPosts(user, 1)
 
-				numberOfPosts, err := postRepository.GetNumberOfUsersPostsByDay(
-					primitive.NewObjectID().Hex(),
-					time.Now().AddDate(0, 0, 3),
-				)
+				numberOfPosts, err := postRepository.CountByUserSince(context.Background(), user.Hex(), time.Now().Add(time.Hour))
 
 				Expect(err).To(BeNil())
 				Expect(numberOfPosts).To(Equal(0))
@@ -139,17 +369,117 @@ var _ = Describe("PostRepository suite test", func() {
 		})
 	})
 
+	Describe("Getting the last posts of a hashtag", func() {
+		Context("when there are posts with the hashtag", func() {
+			It("returns them from the newest, without the deleted ones", func() {
+				hashtag := "tag" + primitive.NewObjectID().Hex()
+
+				first, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + hashtag, Hashtags: []string{hashtag}})
+				second, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + hashtag, Hashtags: []string{hashtag}})
+				deleted, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + hashtag, Hashtags: []string{hashtag}})
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"})
+
+				_, _ = postRepository.Delete(context.Background(), deleted)
+
+				posts, err := postRepository.GetLastByHashtag(context.Background(), hashtag, nil, 10)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(HaveLen(2))
+				Expect(posts[0].ID.Hex()).To(Equal(second))
+				Expect(posts[1].ID.Hex()).To(Equal(first))
+			})
+		})
+	})
+
+	Describe("Getting the last posts mentioning a user", func() {
+		Context("when there are posts mentioning the user", func() {
+			It("returns them", func() {
+				user := primitive.NewObjectID()
+				mention := []*entity.Mention{{UserID: user, Username: "test", Start: 0, End: 5}}
+
+				id, _ := postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "@test", Mentions: mention})
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: user, Content: "this is a post"})
+
+				posts, err := postRepository.GetLastByMention(context.Background(), user.Hex(), nil, 10)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(HaveLen(1))
+				Expect(posts[0].ID.Hex()).To(Equal(id))
+				Expect(posts[0].Mentions[0].UserID).To(Equal(user))
+			})
+		})
+	})
+
+	Describe("Getting the trending hashtags", func() {
+		Context("when there are hashtags used since the given time", func() {
+			It("counts the posts of each one", func() {
+				popular := "popular" + primitive.NewObjectID().Hex()
+				other := "other" + primitive.NewObjectID().Hex()
+
+				for i := 0; i < 3; i++ {
+					_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + popular, Hashtags: []string{popular}})
+				}
+
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + popular + " #" + other, Hashtags: []string{popular, other}})
+
+				hashtags, err := postRepository.GetTrendingHashtags(context.Background(), time.Now().Add(-time.Minute), 100)
+
+				Expect(err).To(BeNil())
+				Expect(hashtags).To(ContainElement(&entity.TrendingHashtag{Tag: popular, Count: 4}))
+				Expect(hashtags).To(ContainElement(&entity.TrendingHashtag{Tag: other, Count: 1}))
+			})
+		})
+
+		Context("when the hashtags were used before the given time", func() {
+			It("leaves them out", func() {
+				hashtag := "old" + primitive.NewObjectID().Hex()
+
+				_, _ = postRepository.Create(context.Background(), &entity.Post{UserID: primitive.NewObjectID(), Content: "#" + hashtag, Hashtags: []string{hashtag}})
+
+				hashtags, err := postRepository.GetTrendingHashtags(context.Background(), time.Now().Add(time.Minute), 100)
+
+				Expect(err).To(BeNil())
+				Expect(hashtags).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Finding the oldest post of a user since a time", func() {
+		Context("when the user has posts since then", func() {
+			It("returns when the first one was created", func() {
+				user := primitive.NewObjectID()
+				since := time.Now().Add(-time.Hour)
+
+				createPosts(user, 2)
+
+				createdAt, err := postRepository.FindOldestCreatedAtSince(context.Background(), user.Hex(), since)
+
+				Expect(err).To(BeNil())
+				Expect(createdAt).NotTo(BeNil())
+				Expect(createdAt.After(since)).To(BeTrue())
+			})
+		})
+
+		Context("when the user has no posts since then", func() {
+			It("returns nil", func() {
+				createdAt, err := postRepository.FindOldestCreatedAtSince(context.Background(), primitive.NewObjectID().Hex(), time.Now())
+
+				Expect(err).To(BeNil())
+				Expect(createdAt).To(BeNil())
+			})
+		})
+	})
+
 	Describe("Getting last posts by user", func() {
 		Context("when its given five posts", func() {
 			It("returns all posts", func() {
 				numberOfPostsExpected := 5
-				page := 1
 				limit := 5
 
 				user := primitive.NewObjectID()
 				createPosts(user, numberOfPostsExpected)
 
-				posts, err := postRepository.GetLastByUser(user.Hex(), page, limit)
+				posts, err := postRepository.GetLastByUser(context.Background(), user.Hex(), nil, limit)
 
 				Expect(err).To(BeNil())
 				Expect(len(posts)).To(Equal(numberOfPostsExpected))
@@ -159,7 +489,6 @@ var _ = Describe("PostRepository suite test", func() {
 		Context("when its given posts from other users", func() {
 			It("returns only posts from the user specified", func() {
 				numberOfPostsExpected := 3
-				page := 1
 				limit := 5
 
 				user := primitive.NewObjectID()
@@ -171,7 +500,7 @@ var _ = Describe("PostRepository suite test", func() {
 				anotherUser = primitive.NewObjectID()
 				createPosts(anotherUser, 2)
 
-				posts, err := postRepository.GetLastByUser(user.Hex(), page, limit)
+				posts, err := postRepository.GetLastByUser(context.Background(), user.Hex(), nil, limit)
 
 				Expect(err).To(BeNil())
 				Expect(len(posts)).To(Equal(numberOfPostsExpected))
@@ -185,7 +514,6 @@ var _ = Describe("PostRepository suite test", func() {
 		Context("when its given posts from other users", func() {
 			It("returns only posts from the user specified", func() {
 				numberOfPostsExpected := 3
-				page := 1
 				limit := 5
 
 				user := primitive.NewObjectID()
@@ -197,7 +525,7 @@ var _ = Describe("PostRepository suite test", func() {
 				anotherUser = primitive.NewObjectID()
 				createPosts(anotherUser, 2)
 
-				posts, err := postRepository.GetLastByUser(user.Hex(), page, limit)
+				posts, err := postRepository.GetLastByUser(context.Background(), user.Hex(), nil, limit)
 
 				Expect(err).To(BeNil())
 				Expect(len(posts)).To(Equal(numberOfPostsExpected))
@@ -211,16 +539,47 @@ var _ = Describe("PostRepository suite test", func() {
 		Context("when its given search by second page of getting last users posts", func() {
 			It("returns only posts from the second page", func() {
 				numberOfPostsExpected := 10
-				page := 2
 				limit := 5
 
 				user := primitive.NewObjectID()
 				createPosts(user, numberOfPostsExpected)
 
-				posts, err := postRepository.GetLastByUser(user.Hex(), page, limit)
+				firstPage, err := postRepository.GetLastByUser(context.Background(), user.Hex(), nil, limit)
+
+				Expect(err).To(BeNil())
+
+				cursor, err := entity.DecodeCursor(entity.NewCursor(firstPage[len(firstPage)-1]))
+
+				Expect(err).To(BeNil())
+
+				posts, err := postRepository.GetLastByUser(context.Background(), user.Hex(), cursor, limit)
 
 				Expect(err).To(BeNil())
 				Expect(len(posts)).To(Equal(limit))
+
+				for _, post := range posts {
+					for _, previous := range firstPage {
+						Expect(post.ID).NotTo(Equal(previous.ID))
+					}
+				}
+			})
+		})
+
+		Context("when its given a cursor from the last post", func() {
+			It("returns no posts", func() {
+				limit := 5
+
+				user := primitive.NewObjectID()
+				createPosts(user, limit)
+
+				firstPage, _ := postRepository.GetLastByUser(context.Background(), user.Hex(), nil, limit)
+
+				cursor, _ := entity.DecodeCursor(entity.NewCursor(firstPage[len(firstPage)-1]))
+
+				posts, err := postRepository.GetLastByUser(context.Background(), user.Hex(), cursor, limit)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(BeEmpty())
 			})
 		})
 
@@ -231,7 +590,6 @@ var _ = Describe("PostRepository suite test", func() {
 			It("returns only posts from the users", func() {
 				numberOfPosts := 2
 				numberOfUsers := 10
-				page := 2
 				limit := 5
 
 				var users []string
@@ -241,7 +599,11 @@ var _ = Describe("PostRepository suite test", func() {
 					createPosts(user, numberOfPosts)
 				}
 
-				posts, err := postRepository.GetLastByUsers(users, page, limit)
+				firstPage, _ := postRepository.GetLastByUsers(context.Background(), users, users, nil, limit)
+
+				cursor, _ := entity.DecodeCursor(entity.NewCursor(firstPage[len(firstPage)-1]))
+
+				posts, err := postRepository.GetLastByUsers(context.Background(), users, users, cursor, limit)
 
 				Expect(err).To(BeNil())
 				Expect(len(posts)).To(Equal(limit))
@@ -251,13 +613,150 @@ var _ = Describe("PostRepository suite test", func() {
 				}
 			})
 		})
+
+		Context("when there are replies to users that are not followed", func() {
+			It("returns only the replies to followed users", func() {
+				user := primitive.NewObjectID()
+				followed := primitive.NewObjectID()
+				notFollowed := primitive.NewObjectID()
+
+				followedPost := &entity.Post{UserID: followed, Content: "this is a post"}
+				notFollowedPost := &entity.Post{UserID: notFollowed, Content: "this is a post"}
+
+				_, _ = postRepository.Create(context.Background(), followedPost)
+				_, _ = postRepository.Create(context.Background(), notFollowedPost)
+
+				replyId, _ := postRepository.Create(context.Background(), newReply(user, followedPost))
+				_, _ = postRepository.Create(context.Background(), newReply(user, notFollowedPost))
+
+				posts, err := postRepository.GetLastByUsers(
+					context.Background(),
+					[]string{user.Hex()},
+					[]string{user.Hex(), followed.Hex()},
+					nil,
+					5,
+				)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(HaveLen(1))
+				Expect(posts[0].ID.Hex()).To(Equal(replyId))
+			})
+		})
+	})
+
+	Describe("Counting the replies of a post", func() {
+		Context("when replies are created and deleted", func() {
+			It("keeps the count of the replied post", func() {
+				root := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				_, _ = postRepository.Create(context.Background(), root)
+
+				reply := newReply(primitive.NewObjectID(), root)
+				_, _ = postRepository.Create(context.Background(), reply)
+				_, _ = postRepository.Create(context.Background(), newReply(primitive.NewObjectID(), root))
+
+				post, _ := postRepository.Find(context.Background(), root.ID.Hex())
+				Expect(post.RepliesCount).To(Equal(uint(2)))
+
+				_, _ = postRepository.Delete(context.Background(), reply.ID.Hex())
+				_, _ = postRepository.Delete(context.Background(), reply.ID.Hex())
+
+				post, _ = postRepository.Find(context.Background(), root.ID.Hex())
+				Expect(post.RepliesCount).To(Equal(uint(1)))
+			})
+		})
+	})
+
+	Describe("Getting replies of a post", func() {
+		Context("when its given a depth", func() {
+			It("returns the replies under the post up to it", func() {
+				root := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				_, _ = postRepository.Create(context.Background(), root)
+
+				reply := newReply(primitive.NewObjectID(), root)
+				_, _ = postRepository.Create(context.Background(), reply)
+
+				secondLevel := newReply(primitive.NewObjectID(), reply)
+				_, _ = postRepository.Create(context.Background(), secondLevel)
+
+				thirdLevel := newReply(primitive.NewObjectID(), secondLevel)
+				_, _ = postRepository.Create(context.Background(), thirdLevel)
+
+				replies, err := postRepository.GetReplies(context.Background(), root.ID.Hex(), nil, 5, 2)
+
+				Expect(err).To(BeNil())
+				Expect(replies).To(HaveLen(1))
+				Expect(replies[0].ID).To(Equal(reply.ID))
+				Expect(replies[0].Descendants).To(HaveLen(1))
+				Expect(replies[0].Descendants[0].ID).To(Equal(secondLevel.ID))
+			})
+		})
+
+		Context("when a nested reply quotes a post", func() {
+			It("returns it with the quoted post", func() {
+				root := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				_, _ = postRepository.Create(context.Background(), root)
+
+				quoted := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a quoted post"}
+				_, _ = postRepository.Create(context.Background(), quoted)
+
+				reply := newReply(primitive.NewObjectID(), root)
+				_, _ = postRepository.Create(context.Background(), reply)
+
+				secondLevel := newReply(primitive.NewObjectID(), reply)
+				secondLevel.ParentID = quoted.ID
+				_, _ = postRepository.Create(context.Background(), secondLevel)
+
+				replies, err := postRepository.GetReplies(context.Background(), root.ID.Hex(), nil, 5, 2)
+
+				Expect(err).To(BeNil())
+				Expect(replies[0].Descendants).To(HaveLen(1))
+				Expect(replies[0].Descendants[0].QuotedPost).NotTo(BeNil())
+				Expect(replies[0].Descendants[0].QuotedPost.Content).To(Equal("this is a quoted post"))
+			})
+		})
+
+		Context("when its given a cursor", func() {
+			It("returns only the newer replies", func() {
+				root := &entity.Post{UserID: primitive.NewObjectID(), Content: "this is a post"}
+				_, _ = postRepository.Create(context.Background(), root)
+
+				for i := 0; i < 3; i++ {
+					_, _ = postRepository.Create(context.Background(), newReply(primitive.NewObjectID(), root))
+				}
+
+				firstPage, _ := postRepository.GetReplies(context.Background(), root.ID.Hex(), nil, 2, 1)
+
+				cursor, _ := entity.DecodeCursor(entity.NewCursor(&firstPage[len(firstPage)-1].Post))
+
+				replies, err := postRepository.GetReplies(context.Background(), root.ID.Hex(), cursor, 2, 1)
+
+				Expect(err).To(BeNil())
+				Expect(replies).To(HaveLen(1))
+			})
+		})
 	})
 })
 
+func newReply(user primitive.ObjectID, post *entity.Post) *entity.Post {
+	conversationId := post.ConversationID
+
+	if conversationId.IsZero() {
+		conversationId = post.ID
+	}
+
+	return &entity.Post{
+		UserID:          user,
+		InReplyToID:     post.ID,
+		InReplyToUserID: post.UserID,
+		ConversationID:  conversationId,
+		Content:         "this is a reply",
+	}
+}
+
 func createPosts(user primitive.ObjectID, numberOfPosts int) []string {
 	var posts []string
 	for i := 0; i < numberOfPosts; i++ {
-		post, _ := postRepository.Create(user.Hex(), "", "this is a post")
+		post, _ := postRepository.Create(context.Background(), &entity.Post{UserID: user, Content: "this is a post"})
 
 		posts = append(posts, post)
 	}
@@ -0,0 +1,717 @@
+package post_repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"time"
+)
+
+const postColumns = `p.id, p.user_id, p.parent_id, p.in_reply_to_id, p.in_reply_to_user_id, p.conversation_id,
+	p.content, p.hashtags, p.mentions, p.likes_count, p.replies_count, p.created_at, p.edited_at, p.deleted_at`
+
+// quotedPostColumns are the columns of the quoted post joined as q, as the $lookup of generateLookUpStage
+const quotedPostColumns = `q.id, q.user_id, q.content, q.created_at, q.edited_at, q.deleted_at`
+
+// PostgresPostRepository keeps the posts on the posts table, with the same soft deletes,
+// keyset pagination and tombstones of quoted posts as the PostRepository
+type PostgresPostRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPostRepository(db *sql.DB) *PostgresPostRepository {
+	return &PostgresPostRepository{
+		db: db,
+	}
+}
+
+// storedMention is how a mention is kept on the mentions column
+type storedMention struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+func (repo *PostgresPostRepository) Create(ctx context.Context, post *entity.Post) (string, error) {
+	post.CreatedAt = postgres.Now()
+
+	if post.ID.IsZero() {
+		post.ID = primitive.NewObjectID()
+	}
+
+	mentionsJSON, err := encodeMentions(post.Mentions)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO posts (id, user_id, parent_id, in_reply_to_id, in_reply_to_user_id, conversation_id,
+				content, hashtags, mentions, likes_count, replies_count, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			post.ID.Hex(),
+			post.UserID.Hex(),
+			postgres.ID(post.ParentID),
+			postgres.ID(post.InReplyToID),
+			postgres.ID(post.InReplyToUserID),
+			postgres.ID(post.ConversationID),
+			post.Content,
+			postgres.Strings(post.Hashtags),
+			mentionsJSON,
+			post.LikesCount,
+			post.RepliesCount,
+			post.CreatedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if err := incrementPosts(ctx, tx, post.UserID.Hex(), 1); err != nil {
+			return err
+		}
+
+		if !post.IsReply() {
+			return nil
+		}
+
+		return incrementField(ctx, tx, post.InReplyToID.Hex(), "replies_count", 1)
+	})
+
+	if err != nil {
+		if postgres.IsDup(err) {
+			return "", mongodb.ErrDuplicateKey
+		}
+		return "", err
+	}
+
+	return post.ID.Hex(), nil
+}
+
+// Delete returns whether this call deleted the post, it decrements the posts of its user,
+// and a reply the replies of the replied post, in the same transaction as the PostRepository does
+func (repo *PostgresPostRepository) Delete(ctx context.Context, id string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
+	}
+
+	deleted := false
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		var userId string
+		var inReplyToId sql.NullString
+
+		err := tx.QueryRowContext(
+			ctx,
+			`UPDATE posts SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL RETURNING user_id, in_reply_to_id`,
+			objectId.Hex(),
+			postgres.Now(),
+		).Scan(&userId, &inReplyToId)
+
+		if err == sql.ErrNoRows {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		deleted = true
+
+		if err := incrementPosts(ctx, tx, userId, -1); err != nil {
+			return err
+		}
+
+		if !inReplyToId.Valid {
+			return nil
+		}
+
+		return incrementField(ctx, tx, inReplyToId.String, "replies_count", -1)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// Edit keeps the previous version of the post on post_versions and replaces it on the same
+// statement, the row lock makes a concurrent edit wait and read the version it left
+func (repo *PostgresPostRepository) Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error) {
+	mentionsJSON, err := encodeMentions(post.Mentions)
+
+	if err != nil {
+		return false, err
+	}
+
+	editedAt := postgres.Now()
+
+	result, err := repo.db.ExecContext(
+		ctx,
+		`WITH previous AS (
+			SELECT id, content, hashtags, mentions, COALESCE(edited_at, created_at) AS created_at
+			FROM posts
+			WHERE id = $1 AND deleted_at IS NULL AND created_at >= $6
+			FOR UPDATE
+		), version AS (
+			INSERT INTO post_versions (post_id, content, hashtags, mentions, created_at)
+			SELECT id, content, hashtags, mentions, created_at FROM previous
+		)
+		UPDATE posts p SET content = $2, hashtags = $3, mentions = $4, edited_at = $5
+		FROM previous
+		WHERE p.id = previous.id`,
+		post.ID.Hex(),
+		post.Content,
+		postgres.Strings(post.Hashtags),
+		mentionsJSON,
+		editedAt,
+		postgres.Time(since),
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	edited, err := result.RowsAffected()
+
+	if err != nil || edited == 0 {
+		return false, err
+	}
+
+	post.EditedAt = &editedAt
+
+	return true, nil
+}
+
+func (repo *PostgresPostRepository) GetHistory(ctx context.Context, id string) ([]*entity.PostVersion, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT content, hashtags, mentions, created_at FROM post_versions WHERE post_id = $1 ORDER BY id`,
+		objectId.Hex(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var versions []*entity.PostVersion
+
+	for rows.Next() {
+		var version entity.PostVersion
+		var mentions []byte
+
+		if err := rows.Scan(&version.Content, pq.Array(&version.Hashtags), &mentions, &version.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		version.CreatedAt = version.CreatedAt.UTC()
+
+		if len(version.Hashtags) == 0 {
+			version.Hashtags = nil
+		}
+
+		version.Mentions, err = decodeMentions(mentions)
+
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, &version)
+	}
+
+	return versions, rows.Err()
+}
+
+// incrementField never takes a counter below zero, which an unsigned one of the entity could not hold
+func incrementField(ctx context.Context, tx *sql.Tx, id, field string, value int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE posts SET `+field+` = `+field+` + $2 WHERE id = $1 AND `+field+` + $2 >= 0`,
+		id,
+		value,
+	)
+
+	return err
+}
+
+// incrementPosts changes the posts of the user in the transaction of its post, never below zero
+func incrementPosts(ctx context.Context, tx *sql.Tx, userId string, value int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE users SET posts_count = posts_count + $2 WHERE id = $1 AND posts_count + $2 >= 0`,
+		userId,
+		value,
+	)
+
+	return err
+}
+
+func (repo *PostgresPostRepository) Find(ctx context.Context, id string) (*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := repo.last(ctx, "p.id = $1", []interface{}{objectId.Hex()}, nil, -1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	return posts[0], nil
+}
+
+func (repo *PostgresPostRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	postsObjectId, err := toObjectIDs(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(ctx, "p.id = ANY($1)", []interface{}{postgres.IDs(postsObjectId)}, nil, -1)
+}
+
+// GetReplies walks the replies of each direct reply with a recursive query, as the $graphLookup
+// of the PostRepository, up to depth levels and never through a deleted reply
+func (repo *PostgresPostRepository) GetReplies(ctx context.Context, postId string, cursor *entity.Cursor, limit, depth int) ([]*entity.Reply, error) {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + postColumns + `, ` + quotedPostColumns + `
+		FROM posts p
+		LEFT JOIN posts q ON q.id = p.parent_id
+		WHERE p.in_reply_to_id = $1 AND p.deleted_at IS NULL`
+	args := []interface{}{objectId.Hex()}
+
+	if cursor != nil {
+		query += ` AND (p.created_at, p.id) > ($2, $3)`
+		args = append(args, postgres.Time(cursor.CreatedAt), cursor.ID.Hex())
+	}
+
+	query += ` ORDER BY p.created_at, p.id LIMIT ` + strconv.Itoa(limit)
+
+	direct, err := repo.query(ctx, true, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*entity.Reply
+
+	replies := map[primitive.ObjectID]*entity.Reply{}
+
+	var directIds []primitive.ObjectID
+
+	for _, post := range direct {
+		reply := &entity.Reply{Post: *post}
+
+		if depth > 1 {
+			reply.Descendants = []*entity.Post{}
+		}
+
+		replies[post.ID] = reply
+		directIds = append(directIds, post.ID)
+		result = append(result, reply)
+	}
+
+	if depth <= 1 || len(directIds) == 0 {
+		return result, nil
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`WITH RECURSIVE descendants AS (
+			SELECT p.*, p.in_reply_to_id AS root_id, 1 AS level
+			FROM posts p
+			WHERE p.in_reply_to_id = ANY($1) AND p.deleted_at IS NULL
+			UNION ALL
+			SELECT p.*, d.root_id, d.level + 1
+			FROM posts p
+			JOIN descendants d ON p.in_reply_to_id = d.id
+			WHERE p.deleted_at IS NULL AND d.level < $2
+		)
+		SELECT p.root_id, `+postColumns+`, `+quotedPostColumns+`
+		FROM descendants p
+		LEFT JOIN posts q ON q.id = p.parent_id`,
+		postgres.IDs(directIds),
+		depth-1,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootId primitive.ObjectID
+
+		post, err := scanPost(rows, true, postgres.ScanID(&rootId))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if reply, ok := replies[rootId]; ok {
+			reply.Descendants = append(reply.Descendants, post)
+		}
+	}
+
+	return result, rows.Err()
+}
+
+func (repo *PostgresPostRepository) GetLastByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(ctx, "p.user_id = $1", []interface{}{objectId.Hex()}, cursor, limit)
+}
+
+func (repo *PostgresPostRepository) GetLastByUsers(ctx context.Context, users, following []string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if following == nil {
+		return repo.last(ctx, "p.user_id = ANY($1)", []interface{}{postgres.IDs(usersObjectId)}, cursor, limit)
+	}
+
+	followingObjectId, err := toObjectIDs(following)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// posts that are not replies have no in_reply_to_user_id
+	return repo.last(
+		ctx,
+		"p.user_id = ANY($1) AND (p.in_reply_to_user_id IS NULL OR p.in_reply_to_user_id = ANY($2))",
+		[]interface{}{postgres.IDs(usersObjectId), postgres.IDs(followingObjectId)},
+		cursor,
+		limit,
+	)
+}
+
+func (repo *PostgresPostRepository) GetLastByHashtag(ctx context.Context, hashtag string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	return repo.last(ctx, "p.hashtags @> ARRAY[$1::text]", []interface{}{hashtag}, cursor, limit)
+}
+
+func (repo *PostgresPostRepository) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) ([]*entity.TrendingHashtag, error) {
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT hashtag, count(*) AS count
+		FROM posts p, unnest(p.hashtags) AS hashtag
+		WHERE p.created_at >= $1 AND p.deleted_at IS NULL
+		GROUP BY hashtag
+		ORDER BY count DESC, hashtag COLLATE "C"
+		LIMIT $2`,
+		postgres.Time(since),
+		limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.TrendingHashtag
+
+	for rows.Next() {
+		var hashtag entity.TrendingHashtag
+		if err := rows.Scan(&hashtag.Tag, &hashtag.Count); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &hashtag)
+	}
+
+	return result, rows.Err()
+}
+
+func (repo *PostgresPostRepository) GetLastByMention(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mention, err := json.Marshal([]map[string]string{{"user_id": objectId.Hex()}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.last(ctx, "p.mentions @> $1::jsonb", []interface{}{string(mention)}, cursor, limit)
+}
+
+func (repo *PostgresPostRepository) CountByUserSince(ctx context.Context, id string, since time.Time) (int, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	err = repo.db.QueryRowContext(
+		ctx,
+		`SELECT count(*) FROM posts WHERE user_id = $1 AND created_at >= $2`,
+		objectId.Hex(),
+		postgres.Time(since),
+	).Scan(&count)
+
+	return count, err
+}
+
+func (repo *PostgresPostRepository) FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest sql.NullTime
+
+	err = repo.db.QueryRowContext(
+		ctx,
+		`SELECT min(created_at) FROM posts WHERE user_id = $1 AND created_at >= $2`,
+		objectId.Hex(),
+		postgres.Time(since),
+	).Scan(&oldest)
+
+	if err != nil || !oldest.Valid {
+		return nil, err
+	}
+
+	createdAt := oldest.Time.UTC()
+
+	return &createdAt, nil
+}
+
+func (repo *PostgresPostRepository) CountByUsers(ctx context.Context, users []string) (map[string]int64, error) {
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryCounts(
+		ctx,
+		repo.db,
+		`SELECT user_id, count(*) FROM posts WHERE user_id = ANY($1) AND deleted_at IS NULL GROUP BY user_id`,
+		postgres.IDs(usersObjectId),
+	)
+}
+
+// last returns the posts that were not deleted and match condition, from the newest and after the cursor,
+// with their quoted posts. A negative limit returns all of them
+func (repo *PostgresPostRepository) last(ctx context.Context, condition string, args []interface{}, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	query := `SELECT ` + postColumns + `, ` + quotedPostColumns + `
+		FROM posts p
+		LEFT JOIN posts q ON q.id = p.parent_id
+		WHERE p.deleted_at IS NULL AND ` + condition
+
+	if cursor != nil {
+		query += fmt.Sprintf(` AND (p.created_at, p.id) < ($%d, $%d)`, len(args)+1, len(args)+2)
+		args = append(args, postgres.Time(cursor.CreatedAt), cursor.ID.Hex())
+	}
+
+	query += ` ORDER BY p.created_at DESC, p.id DESC`
+
+	if limit >= 0 {
+		query += ` LIMIT ` + strconv.Itoa(limit)
+	}
+
+	return repo.query(ctx, true, query, args...)
+}
+
+func (repo *PostgresPostRepository) query(ctx context.Context, quoted bool, query string, args ...interface{}) ([]*entity.Post, error) {
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.Post
+
+	for rows.Next() {
+		post, err := scanPost(rows, quoted)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, post)
+	}
+
+	return result, rows.Err()
+}
+
+// scanPost scans the postColumns after the leading columns, followed by the quotedPostColumns when quoted is set
+func scanPost(rows *sql.Rows, quoted bool, leading ...interface{}) (*entity.Post, error) {
+	var post entity.Post
+	var mentions []byte
+	var editedAt, deletedAt sql.NullTime
+
+	dest := append(leading,
+		postgres.ScanID(&post.ID),
+		postgres.ScanID(&post.UserID),
+		postgres.ScanID(&post.ParentID),
+		postgres.ScanID(&post.InReplyToID),
+		postgres.ScanID(&post.InReplyToUserID),
+		postgres.ScanID(&post.ConversationID),
+		&post.Content,
+		pq.Array(&post.Hashtags),
+		&mentions,
+		&post.LikesCount,
+		&post.RepliesCount,
+		&post.CreatedAt,
+		&editedAt,
+		&deletedAt,
+	)
+
+	var quotedPost entity.QuotedPost
+	var quotedContent sql.NullString
+	var quotedCreatedAt, quotedEditedAt, quotedDeletedAt sql.NullTime
+
+	if quoted {
+		dest = append(dest,
+			postgres.ScanID(&quotedPost.ID),
+			postgres.ScanID(&quotedPost.UserID),
+			&quotedContent,
+			&quotedCreatedAt,
+			&quotedEditedAt,
+			&quotedDeletedAt,
+		)
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	post.CreatedAt = post.CreatedAt.UTC()
+	post.EditedAt = nullTime(editedAt)
+	post.DeletedAt = nullTime(deletedAt)
+
+	if len(post.Hashtags) == 0 {
+		post.Hashtags = nil
+	}
+
+	var err error
+
+	post.Mentions, err = decodeMentions(mentions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case quotedPost.ID.IsZero():
+	case quotedDeletedAt.Valid:
+		post.QuotedPost = &entity.QuotedPost{ID: quotedPost.ID, Deleted: true}
+	default:
+		quotedPost.Content = quotedContent.String
+		quotedPost.CreatedAt = quotedCreatedAt.Time.UTC()
+		quotedPost.EditedAt = nullTime(quotedEditedAt)
+		post.QuotedPost = &quotedPost
+	}
+
+	return &post, nil
+}
+
+// encodeMentions is the value of mentions on the mentions column
+func encodeMentions(mentions []*entity.Mention) (string, error) {
+	stored := []*storedMention{}
+
+	for _, mention := range mentions {
+		stored = append(stored, &storedMention{
+			UserID:   mention.UserID.Hex(),
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		})
+	}
+
+	mentionsJSON, err := json.Marshal(stored)
+
+	return string(mentionsJSON), err
+}
+
+func decodeMentions(mentionsJSON []byte) ([]*entity.Mention, error) {
+	var stored []*storedMention
+
+	if err := json.Unmarshal(mentionsJSON, &stored); err != nil {
+		return nil, err
+	}
+
+	var mentions []*entity.Mention
+
+	for _, mention := range stored {
+		userId, err := primitive.ObjectIDFromHex(mention.UserID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, &entity.Mention{
+			UserID:   userId,
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		})
+	}
+
+	return mentions, nil
+}
+
+// nullTime is the time of a nullable column in UTC, nil when it is NULL
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	t := value.Time.UTC()
+
+	return &t
+}
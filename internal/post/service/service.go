@@ -1,67 +1,289 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/metrics"
+	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/post/entity"
 	"github.com/regiszanandrea/posty/internal/post/repository"
-	follower_repository "github.com/regiszanandrea/posty/internal/user/repository/follower"
+	"github.com/regiszanandrea/posty/internal/post/repository/like"
+	quota_entity "github.com/regiszanandrea/posty/internal/quota/entity"
+	quota_service "github.com/regiszanandrea/posty/internal/quota/service"
+	timeline_service "github.com/regiszanandrea/posty/internal/timeline/service"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	user_repository "github.com/regiszanandrea/posty/internal/user/repository/user"
 	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+	"log"
 	"time"
 )
 
-var ErrLimitPostsByDay = errors.New("limit of posts by day reached")
+var (
+	ErrPostsQuotaReached = apperror.RateLimited("posts_quota_reached", "quota of posts reached, wait for it to reset")
+	ErrPostNotFound      = apperror.NotFound("post_not_found", "post not found")
+	ErrTooManyMentions   = apperror.Validation("too_many_mentions", "a post can not mention that many users")
+	ErrEditWindowExpired = apperror.Forbidden("edit_window_expired", "posts can only be edited for a while after they are created")
+	ErrRepostNotEditable = apperror.Validation("repost_not_editable", "reposts have no content to edit")
+)
 
 type Service interface {
-	CreatePost(createPostRequest *entity.CreatePostRequest) (*string, []error)
-	ListLastPostByUser(listPostRequest *entity.ListPostRequest) ([]*entity.Post, []error)
-	ListFeed(listFeedRequest *entity.ListFeedRequest) ([]*entity.Post, []error)
+	CreatePost(ctx context.Context, createPostRequest *entity.CreatePostRequest) (*string, *quota_entity.Quota, []error)
+	DeletePost(ctx context.Context, deletePostRequest *entity.DeletePostRequest) []error
+	EditPost(ctx context.Context, editPostRequest *entity.EditPostRequest) (*entity.Post, []error)
+	GetPostHistory(ctx context.Context, postHistoryRequest *entity.PostHistoryRequest) (*entity.PostHistory, []error)
+	ListLastPostByUser(ctx context.Context, listPostRequest *entity.ListPostRequest) (*entity.PostList, []error)
+	ListFeed(ctx context.Context, listFeedRequest *entity.ListFeedRequest) (*entity.PostList, []error)
+	LikePost(ctx context.Context, likeRequest *entity.LikeRequest) []error
+	UnlikePost(ctx context.Context, likeRequest *entity.LikeRequest) []error
+	ListLikes(ctx context.Context, listLikesRequest *entity.ListLikesRequest) (*entity.LikeList, []error)
+	ListLikedPosts(ctx context.Context, listLikedPostsRequest *entity.ListLikedPostsRequest) (*entity.PostList, []error)
+	GetConversation(ctx context.Context, conversationRequest *entity.ConversationRequest) (*entity.Conversation, []error)
+	ListPostsByHashtag(ctx context.Context, listHashtagPostsRequest *entity.ListHashtagPostsRequest) (*entity.PostList, []error)
+	ListTrendingHashtags(ctx context.Context, listTrendingHashtagsRequest *entity.ListTrendingHashtagsRequest) (*entity.TrendingHashtagList, []error)
+	ListMentions(ctx context.Context, listMentionsRequest *entity.ListMentionsRequest) (*entity.PostList, []error)
 }
 
 type PostService struct {
-	repository         post_repository.Repository
-	followerRepository follower_repository.Repository
-	configs            *viper.Viper
+	repository      post_repository.Repository
+	likeRepository  like_repository.Repository
+	userRepository  user_repository.Repository
+	timelineService timeline_service.Service
+	quotaService    quota_service.Service
+	configs         *viper.Viper
 }
 
 func NewPostService(
 	repository post_repository.Repository,
-	followerRepository follower_repository.Repository,
+	likeRepository like_repository.Repository,
+	userRepository user_repository.Repository,
+	timelineService timeline_service.Service,
+	quotaService quota_service.Service,
 	configs *viper.Viper,
 ) *PostService {
 	return &PostService{
-		repository:         repository,
-		followerRepository: followerRepository,
-		configs:            configs,
+		repository:      repository,
+		likeRepository:  likeRepository,
+		userRepository:  userRepository,
+		timelineService: timelineService,
+		quotaService:    quotaService,
+		configs:         configs,
 	}
 }
 
-func (service *PostService) CreatePost(createPostRequest *entity.CreatePostRequest) (*string, []error) {
+// CreatePost returns the quota of posts of the user along with the post, the created one already
+// taken from it, or the exhausted quota along with ErrPostsQuotaReached
+func (service *PostService) CreatePost(ctx context.Context, createPostRequest *entity.CreatePostRequest) (*string, *quota_entity.Quota, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.CreatePost")
+	defer span.End()
+
 	errs := entity.Validate(createPostRequest)
 
+	if errs != nil {
+		return nil, nil, errs
+	}
+
+	// the quota is read before the post is created and nothing is locked between them, so it is
+	// best-effort: concurrent posts of a user can go over it by the ones that are in flight
+	quota, err := service.quotaService.GetPostsQuota(ctx, createPostRequest.UserID)
+
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	if quota.Exhausted() {
+		metrics.PostsRejected.WithLabelValues(metrics.ReasonQuotaReached).Inc()
+		return nil, quota, []error{ErrPostsQuotaReached}
+	}
+
+	post, err := entity.NewPost(createPostRequest)
+
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	post.Mentions, err = service.resolveMentions(ctx, entity.ExtractMentions(post.Content))
+
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	if post.IsReply() {
+		repliedPost, err := service.repository.Find(ctx, createPostRequest.InReplyToID)
+
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+
+		if repliedPost == nil {
+			return nil, nil, []error{ErrPostNotFound}
+		}
+
+		post.InReplyToUserID = repliedPost.UserID
+		post.ConversationID = repliedPost.ConversationID
+
+		if post.ConversationID.IsZero() {
+			post.ConversationID = repliedPost.ID
+		}
+	}
+
+	id, err := service.repository.Create(ctx, post)
+
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	metrics.PostsCreated.Inc()
+
+	service.fanOut(ctx, id)
+
+	quota.Take()
+
+	return &id, quota, nil
+}
+
+func (service *PostService) DeletePost(ctx context.Context, deletePostRequest *entity.DeletePostRequest) []error {
+	ctx, span := tracing.Start(ctx, "PostService.DeletePost")
+	defer span.End()
+
+	errs := entity.ValidateStruct(deletePostRequest)
+
+	if errs != nil {
+		return errs
+	}
+
+	post, err := service.repository.Find(ctx, deletePostRequest.PostID)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	if post == nil || post.UserID.Hex() != deletePostRequest.UserID {
+		return []error{ErrPostNotFound}
+	}
+
+	deleted, err := service.repository.Delete(ctx, deletePostRequest.PostID)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	// a concurrent delete tombstoned it first and already ran the side effects
+	if !deleted {
+		return []error{ErrPostNotFound}
+	}
+
+	if err = service.timelineService.Remove(ctx, post); err != nil {
+		log.Printf("could not remove post %s from timelines: %v", post.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+// EditPost replaces the content of a post along with its hashtags and mentions, which is allowed
+// on the first app.posts.edit-window after it was created. The previous content is kept on its
+// history, and quotes read the post, so they show the edited content too
+func (service *PostService) EditPost(ctx context.Context, editPostRequest *entity.EditPostRequest) (*entity.Post, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.EditPost")
+	defer span.End()
+
+	errs := entity.ValidateStruct(editPostRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	post, err := service.repository.Find(ctx, editPostRequest.PostID)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	if post == nil || post.UserID.Hex() != editPostRequest.UserID {
+		return nil, []error{ErrPostNotFound}
+	}
+
+	if post.IsRepost() {
+		return nil, []error{ErrRepostNotEditable}
+	}
+
+	since := time.Now().Add(-service.configs.GetDuration("app.posts.edit-window"))
+
+	if post.CreatedAt.Before(since) {
+		return nil, []error{ErrEditWindowExpired}
+	}
+
+	if post.Content == editPostRequest.Content {
+		return post, nil
+	}
+
+	post.Content = editPostRequest.Content
+	post.Hashtags = entity.ExtractHashtags(post.Content)
+	post.Mentions, err = service.resolveMentions(ctx, entity.ExtractMentions(post.Content))
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	edited, err := service.repository.Edit(ctx, post, since)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// the post was deleted or its edit window closed since it was found, a deleted post is not found again
+	if !edited {
+		post, err := service.repository.Find(ctx, editPostRequest.PostID)
+
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		if post == nil {
+			return nil, []error{ErrPostNotFound}
+		}
+
+		return nil, []error{ErrEditWindowExpired}
+	}
+
+	return post, nil
+}
+
+// GetPostHistory returns a post of the user with the versions it had before its edits
+func (service *PostService) GetPostHistory(ctx context.Context, postHistoryRequest *entity.PostHistoryRequest) (*entity.PostHistory, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostHistory")
+	defer span.End()
+
+	errs := entity.ValidateStruct(postHistoryRequest)
+
 	if errs != nil {
 		return nil, errs
 	}
 
-	postsNumber, err := service.repository.GetNumberOfUsersPostsByDay(createPostRequest.UserID, time.Now())
+	post, err := service.repository.Find(ctx, postHistoryRequest.PostID)
 
 	if err != nil {
 		return nil, []error{err}
 	}
 
-	if postsNumber >= service.configs.GetInt("app.posts.maximum-per-day") {
-		return nil, []error{ErrLimitPostsByDay}
+	if post == nil || post.UserID.Hex() != postHistoryRequest.UserID {
+		return nil, []error{ErrPostNotFound}
 	}
 
-	id, err := service.repository.Create(createPostRequest.UserID, createPostRequest.ParentID, createPostRequest.Content)
+	versions, err := service.repository.GetHistory(ctx, postHistoryRequest.PostID)
 
 	if err != nil {
 		return nil, []error{err}
 	}
 
-	return &id, nil
+	return entity.NewPostHistory(post, versions), nil
 }
 
-func (service *PostService) ListLastPostByUser(listPostRequest *entity.ListPostRequest) ([]*entity.Post, []error) {
+func (service *PostService) ListLastPostByUser(ctx context.Context, listPostRequest *entity.ListPostRequest) (*entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListLastPostByUser")
+	defer span.End()
+
 	errs := entity.ValidateStruct(listPostRequest)
 
 	if errs != nil {
@@ -72,16 +294,25 @@ func (service *PostService) ListLastPostByUser(listPostRequest *entity.ListPostR
 		listPostRequest.Limit = service.configs.GetInt("app.posts.list-user-posts-limit")
 	}
 
-	posts, err := service.repository.GetLastByUser(listPostRequest.UserID, listPostRequest.Page, listPostRequest.Limit)
+	cursor, err := decodeCursor(listPostRequest.Cursor)
 
 	if err != nil {
 		return nil, []error{err}
 	}
 
-	return posts, nil
+	posts, err := service.repository.GetLastByUser(ctx, listPostRequest.UserID, cursor, listPostRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewPostList(posts, listPostRequest.Limit), nil
 }
 
-func (service *PostService) ListFeed(listFeedRequest *entity.ListFeedRequest) ([]*entity.Post, []error) {
+func (service *PostService) ListFeed(ctx context.Context, listFeedRequest *entity.ListFeedRequest) (*entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListFeed")
+	defer span.End()
+
 	errs := entity.ValidateStruct(listFeedRequest)
 
 	if errs != nil {
@@ -92,17 +323,370 @@ func (service *PostService) ListFeed(listFeedRequest *entity.ListFeedRequest) ([
 		listFeedRequest.Limit = service.configs.GetInt("app.posts.feed-posts-limit")
 	}
 
-	users, err := service.followerRepository.GetFollowingUsers(listFeedRequest.UserID)
+	cursor, err := decodeCursor(listFeedRequest.Cursor)
 
-	if len(users) == 0 {
-		return nil, nil
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	posts, err := service.timelineService.GetFeed(ctx, listFeedRequest.UserID, cursor, listFeedRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewPostList(posts, listFeedRequest.Limit), nil
+}
+
+func (service *PostService) ListPostsByHashtag(ctx context.Context, listHashtagPostsRequest *entity.ListHashtagPostsRequest) (*entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListPostsByHashtag")
+	defer span.End()
+
+	listHashtagPostsRequest.Tag = entity.NormalizeHashtag(listHashtagPostsRequest.Tag)
+
+	errs := entity.ValidateStruct(listHashtagPostsRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listHashtagPostsRequest.Limit == 0 {
+		listHashtagPostsRequest.Limit = service.configs.GetInt("app.posts.hashtag-posts-limit")
+	}
+
+	cursor, err := decodeCursor(listHashtagPostsRequest.Cursor)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	posts, err := service.repository.GetLastByHashtag(ctx, listHashtagPostsRequest.Tag, cursor, listHashtagPostsRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewPostList(posts, listHashtagPostsRequest.Limit), nil
+}
+
+// ListTrendingHashtags ranks the hashtags by how many posts used them on the last app.posts.trending-hashtags-window
+func (service *PostService) ListTrendingHashtags(ctx context.Context, listTrendingHashtagsRequest *entity.ListTrendingHashtagsRequest) (*entity.TrendingHashtagList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListTrendingHashtags")
+	defer span.End()
+
+	errs := entity.ValidateStruct(listTrendingHashtagsRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listTrendingHashtagsRequest.Limit == 0 {
+		listTrendingHashtagsRequest.Limit = service.configs.GetInt("app.posts.trending-hashtags-limit")
+	}
+
+	since := time.Now().Add(-service.configs.GetDuration("app.posts.trending-hashtags-window"))
+
+	hashtags, err := service.repository.GetTrendingHashtags(ctx, since, listTrendingHashtagsRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewTrendingHashtagList(hashtags), nil
+}
+
+func (service *PostService) ListMentions(ctx context.Context, listMentionsRequest *entity.ListMentionsRequest) (*entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListMentions")
+	defer span.End()
+
+	errs := entity.ValidateStruct(listMentionsRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listMentionsRequest.Limit == 0 {
+		listMentionsRequest.Limit = service.configs.GetInt("app.posts.list-mentions-limit")
 	}
 
-	posts, err := service.repository.GetLastByUsers(users, listFeedRequest.Page, listFeedRequest.Limit)
+	cursor, err := decodeCursor(listMentionsRequest.Cursor)
 
 	if err != nil {
 		return nil, []error{err}
 	}
 
-	return posts, nil
+	posts, err := service.repository.GetLastByMention(ctx, listMentionsRequest.UserID, cursor, listMentionsRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewPostList(posts, listMentionsRequest.Limit), nil
+}
+
+// LikePost is idempotent, liking a post twice keeps a single like and does not change the counter
+func (service *PostService) LikePost(ctx context.Context, likeRequest *entity.LikeRequest) []error {
+	ctx, span := tracing.Start(ctx, "PostService.LikePost")
+	defer span.End()
+
+	errs := entity.ValidateStruct(likeRequest)
+
+	if errs != nil {
+		return errs
+	}
+
+	post, err := service.repository.Find(ctx, likeRequest.PostID)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	if post == nil {
+		return []error{ErrPostNotFound}
+	}
+
+	_, err = service.likeRepository.Like(ctx, likeRequest.UserID, likeRequest.PostID)
+
+	if err == mongodb.ErrDuplicateKey {
+		return nil
+	}
+
+	if err != nil {
+		return []error{err}
+	}
+
+	return nil
+}
+
+func (service *PostService) UnlikePost(ctx context.Context, likeRequest *entity.LikeRequest) []error {
+	ctx, span := tracing.Start(ctx, "PostService.UnlikePost")
+	defer span.End()
+
+	errs := entity.ValidateStruct(likeRequest)
+
+	if errs != nil {
+		return errs
+	}
+
+	_, err := service.likeRepository.Unlike(ctx, likeRequest.UserID, likeRequest.PostID)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	return nil
+}
+
+func (service *PostService) ListLikes(ctx context.Context, listLikesRequest *entity.ListLikesRequest) (*entity.LikeList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListLikes")
+	defer span.End()
+
+	errs := entity.ValidateStruct(listLikesRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listLikesRequest.Limit == 0 {
+		listLikesRequest.Limit = service.configs.GetInt("app.posts.list-likes-limit")
+	}
+
+	cursor, err := decodeCursor(listLikesRequest.Cursor)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	likes, err := service.likeRepository.GetByPost(ctx, listLikesRequest.PostID, cursor, listLikesRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewLikeList(likes, listLikesRequest.Limit), nil
+}
+
+// ListLikedPosts returns the posts in the order they were liked, so the cursor points to the last like
+func (service *PostService) ListLikedPosts(ctx context.Context, listLikedPostsRequest *entity.ListLikedPostsRequest) (*entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListLikedPosts")
+	defer span.End()
+
+	errs := entity.ValidateStruct(listLikedPostsRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listLikedPostsRequest.Limit == 0 {
+		listLikedPostsRequest.Limit = service.configs.GetInt("app.posts.list-likes-limit")
+	}
+
+	cursor, err := decodeCursor(listLikedPostsRequest.Cursor)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	likes, err := service.likeRepository.GetByUser(ctx, listLikedPostsRequest.UserID, cursor, listLikedPostsRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var ids []string
+
+	for _, like := range likes {
+		ids = append(ids, like.PostID.Hex())
+	}
+
+	posts, err := service.repository.GetByIDs(ctx, ids)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	postsById := make(map[string]*entity.Post)
+
+	for _, post := range posts {
+		postsById[post.ID.Hex()] = post
+	}
+
+	list := entity.NewPostList(nil, listLikedPostsRequest.Limit)
+
+	for _, like := range likes {
+		if post, ok := postsById[like.PostID.Hex()]; ok {
+			list.Data = append(list.Data, post)
+		}
+	}
+
+	if len(likes) == listLikedPostsRequest.Limit {
+		last := likes[len(likes)-1]
+		list.NextCursor = entity.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return list, nil
+}
+
+// GetConversation returns the tree of replies from the first post of the conversation,
+// or from the requested post when the first one was deleted
+func (service *PostService) GetConversation(ctx context.Context, conversationRequest *entity.ConversationRequest) (*entity.Conversation, []error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetConversation")
+	defer span.End()
+
+	errs := entity.ValidateStruct(conversationRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	maximumDepth := service.configs.GetInt("app.posts.conversation-maximum-depth")
+
+	if conversationRequest.Depth == 0 || conversationRequest.Depth > maximumDepth {
+		conversationRequest.Depth = maximumDepth
+	}
+
+	if conversationRequest.Limit == 0 {
+		conversationRequest.Limit = service.configs.GetInt("app.posts.conversation-replies-limit")
+	}
+
+	cursor, err := decodeCursor(conversationRequest.Cursor)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	root, err := service.repository.Find(ctx, conversationRequest.PostID)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	if root == nil {
+		return nil, []error{ErrPostNotFound}
+	}
+
+	if !root.ConversationID.IsZero() {
+		first, err := service.repository.Find(ctx, root.ConversationID.Hex())
+
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		if first != nil {
+			root = first
+		}
+	}
+
+	replies, err := service.repository.GetReplies(
+		ctx,
+		root.ID.Hex(),
+		cursor,
+		conversationRequest.Limit,
+		conversationRequest.Depth,
+	)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewConversation(root, replies, conversationRequest.Limit), nil
+}
+
+// fanOut is best-effort, the post is already persisted and a failure here must not make
+// the client retry and create it twice. The post is only queued, the timelines are written
+// by the workers of the timeline service, so it does not wait for them
+func (service *PostService) fanOut(ctx context.Context, id string) {
+	post, err := service.repository.Find(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), id)
+
+	if err == nil && post != nil {
+		err = service.timelineService.Enqueue(ctx, post)
+	}
+
+	if err != nil {
+		log.Printf("could not fan out post %s: %v", id, err)
+	}
+}
+
+// resolveMentions keeps the mentions of users that exist, the others are left as plain text
+func (service *PostService) resolveMentions(ctx context.Context, mentions []*entity.Mention) ([]*entity.Mention, error) {
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	usernames := entity.Usernames(mentions)
+
+	if len(usernames) > service.configs.GetInt("app.posts.maximum-mentions") {
+		return nil, ErrTooManyMentions
+	}
+
+	users, err := service.userRepository.FindByUsernames(ctx, usernames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]primitive.ObjectID, len(users))
+
+	for _, user := range users {
+		ids[user.Username] = user.ID
+	}
+
+	var resolved []*entity.Mention
+
+	for _, mention := range mentions {
+		if id, ok := ids[mention.Username]; ok {
+			mention.UserID = id
+			resolved = append(resolved, mention)
+		}
+	}
+
+	return resolved, nil
+}
+
+func decodeCursor(cursor string) (*entity.Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	return entity.DecodeCursor(cursor)
 }
@@ -1,15 +1,25 @@
 package service
 
 import (
+	"context"
+	"errors"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/post/entity"
+	quota_service "github.com/regiszanandrea/posty/internal/quota/service"
+	timeline_service "github.com/regiszanandrea/posty/internal/timeline/service"
 	follower_mock "github.com/regiszanandrea/posty/test/mocks/follower"
+	"github.com/regiszanandrea/posty/test/mocks/like"
 	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/timeline"
+	"github.com/regiszanandrea/posty/test/mocks/user"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestFollowerRepository(t *testing.T) {
@@ -32,22 +42,116 @@ var _ = Describe("PostRepository suite test", func() {
 		BeforeEach(func() {
 			service = NewPostService(
 				&post_mock.SuccessPostRepositoryMock{},
-				&follower_mock.SuccessFollowerRepositoryMock{},
+				&like_mock.SuccessLikeRepositoryMock{},
+				&user_mock.SuccessUserRepositoryMock{},
+				newTimelineService(),
+				newQuotaService(),
 				configs,
 			)
 		})
 
 		Context("when its given a valid post", func() {
-			It("creates it without error", func() {
+			It("creates it and returns the quota with it taken", func() {
 				request := entity.CreatePostRequest{
 					UserID:  primitive.NewObjectID().Hex(),
 					Content: "this is a post",
 				}
 
-				id, err := service.CreatePost(&request)
+				id, quota, err := service.CreatePost(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(*id)).To(BeTrue())
+				Expect(quota.Remaining).To(Equal(quota.Limit - 1))
+			})
+		})
+
+		Context("when its given a malformed parent or replied post id", func() {
+			It("returns invalid id", func() {
+				for _, request := range []entity.CreatePostRequest{
+					{UserID: primitive.NewObjectID().Hex(), ParentID: "not-an-id", Content: "this is a quote"},
+					{UserID: primitive.NewObjectID().Hex(), InReplyToID: "not-an-id", Content: "this is a reply"},
+				} {
+					_, _, errs := service.CreatePost(context.Background(), &request)
+
+					Expect(errors.Is(errs[0], mongodb.ErrInvalidID)).To(BeTrue())
+				}
+			})
+		})
+
+		Context("when its given a post with hashtags", func() {
+			It("stores them normalized and without duplicates", func() {
+				repository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewPostService(
+					repository,
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				request := entity.CreatePostRequest{
+					UserID:  primitive.NewObjectID().Hex(),
+					Content: "#Go is #go, not c# nor #1 (#MongoDB_4 and #café) http://posty.io/#anchor",
+				}
+
+				_, _, err := service.CreatePost(context.Background(), &request)
+
+				Expect(err).To(BeNil())
+				Expect(repository.Created.Hashtags).To(Equal([]string{"go", "mongodb_4", "café"}))
+			})
+		})
+
+		Context("when its given a post with mentions", func() {
+			It("stores the ones of existing users with their offsets", func() {
+				repository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewPostService(
+					repository,
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				request := entity.CreatePostRequest{
+					UserID:  primitive.NewObjectID().Hex(),
+					Content: "olá @maria, @ghost and me@host.com, cc @maria",
+				}
+
+				_, _, err := service.CreatePost(context.Background(), &request)
+
+				Expect(err).To(BeNil())
+
+				mentions := repository.Created.Mentions
+
+				Expect(mentions).To(HaveLen(2))
+				Expect(mentions[0].Username).To(Equal("maria"))
+				Expect(mentions[0].UserID.IsZero()).To(BeFalse())
+				Expect(mentions[0].Start).To(Equal(4))
+				Expect(mentions[0].End).To(Equal(10))
+				Expect([]rune(request.Content)[mentions[1].Start:mentions[1].End]).To(Equal([]rune("@maria")))
+			})
+		})
+
+		Context("when its given a post mentioning too many users", func() {
+			It("returns error", func() {
+				content := ""
+
+				for i := 0; i <= configs.GetInt("app.posts.maximum-mentions"); i++ {
+					content += "@user" + strconv.Itoa(i) + " "
+				}
+
+				request := entity.CreatePostRequest{
+					UserID:  primitive.NewObjectID().Hex(),
+					Content: content,
+				}
+
+				_, _, errors := service.CreatePost(context.Background(), &request)
+
+				Expect(errors[0]).To(Equal(ErrTooManyMentions))
 			})
 		})
 
@@ -59,7 +163,7 @@ var _ = Describe("PostRepository suite test", func() {
 					ParentID: primitive.NewObjectID().Hex(),
 				}
 
-				id, err := service.CreatePost(&request)
+				id, _, err := service.CreatePost(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(*id)).To(BeTrue())
@@ -73,18 +177,76 @@ var _ = Describe("PostRepository suite test", func() {
 					ParentID: primitive.NewObjectID().Hex(),
 				}
 
-				id, err := service.CreatePost(&request)
+				id, _, err := service.CreatePost(context.Background(), &request)
+
+				Expect(err).To(BeNil())
+				Expect(primitive.IsValidObjectID(*id)).To(BeTrue())
+			})
+		})
+
+		Context("when its given a reply", func() {
+			It("creates it on the conversation of the replied post", func() {
+				postRepository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewPostService(
+					postRepository,
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				request := entity.CreatePostRequest{
+					UserID:      primitive.NewObjectID().Hex(),
+					Content:     "this is a reply",
+					InReplyToID: primitive.NewObjectID().Hex(),
+				}
+
+				id, _, err := service.CreatePost(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(*id)).To(BeTrue())
+				Expect(postRepository.Created.InReplyToID.Hex()).To(Equal(request.InReplyToID))
+				Expect(postRepository.Created.ConversationID.Hex()).To(Equal(request.InReplyToID))
+			})
+		})
+
+		Context("when its given a reply to a post that does not exist", func() {
+			It("returns post not found", func() {
+				service = NewPostService(
+					&post_mock.NotFoundPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				request := entity.CreatePostRequest{
+					UserID:      primitive.NewObjectID().Hex(),
+					Content:     "this is a reply",
+					InReplyToID: primitive.NewObjectID().Hex(),
+				}
+
+				_, _, errors := service.CreatePost(context.Background(), &request)
+
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
 			})
 		})
 
-		Context("when its reach maximum number of posts per day", func() {
+		Context("when its reach the quota of posts", func() {
 			It("returns error and not creates a new post", func() {
 				service = NewPostService(
-					&post_mock.MaximumPostsCreatedOnDayRepositoryMock{Configs: configs},
-					&follower_mock.SuccessFollowerRepositoryMock{},
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					quota_service.NewQuotaService(
+						&user_mock.SuccessUserRepositoryMock{},
+						&post_mock.MaximumPostsCreatedRepositoryMock{Configs: configs},
+						configs,
+					),
 					configs,
 				)
 
@@ -93,9 +255,409 @@ var _ = Describe("PostRepository suite test", func() {
 					Content: "this is a post",
 				}
 
-				_, errors := service.CreatePost(&request)
+				_, quota, errors := service.CreatePost(context.Background(), &request)
+
+				Expect(errors[0]).To(Equal(ErrPostsQuotaReached))
+				Expect(quota.Exhausted()).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Deleting a post", func() {
+		Context("when its given a post from the user", func() {
+			It("deletes it", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: userId},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.DeletePost(context.Background(), &entity.DeletePostRequest{
+					UserID: userId,
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors).To(BeNil())
+			})
+		})
+
+		Context("when a concurrent delete deleted it first", func() {
+			It("returns post not found", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: userId, DeletedConcurrently: true},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.DeletePost(context.Background(), &entity.DeletePostRequest{
+					UserID: userId,
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
+			})
+		})
+
+		Context("when its given a post from another user", func() {
+			It("returns post not found", func() {
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: primitive.NewObjectID().Hex()},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.DeletePost(context.Background(), &entity.DeletePostRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
+			})
+		})
+	})
+
+	Describe("Editing a post", func() {
+		Context("when its given a post from the user on the edit window", func() {
+			It("replaces its content, hashtags and mentions", func() {
+				userId := primitive.NewObjectID().Hex()
+				repository := &post_mock.OwnedPostRepositoryMock{UserID: userId}
+
+				service = NewPostService(
+					repository,
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				post, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  userId,
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "this is a post about #Go, cc @maria and @ghost",
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(post.Content).To(Equal("this is a post about #Go, cc @maria and @ghost"))
+				Expect(post.EditedAt).ToNot(BeNil())
+				Expect(repository.Edited.Hashtags).To(Equal([]string{"go"}))
+				Expect(repository.Edited.Mentions).To(HaveLen(1))
+				Expect(repository.Edited.Mentions[0].Username).To(Equal("maria"))
+				Expect(repository.EditedSince).To(BeTemporally("~", time.Now().Add(-configs.GetDuration("app.posts.edit-window")), time.Second))
+			})
+		})
+
+		Context("when its given the same content", func() {
+			It("does not edit it", func() {
+				userId := primitive.NewObjectID().Hex()
+				repository := &post_mock.OwnedPostRepositoryMock{UserID: userId}
+
+				service = NewPostService(
+					repository,
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				post, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  userId,
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "this is a post",
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(post.EditedAt).To(BeNil())
+				Expect(repository.Edited).To(BeNil())
+			})
+		})
+
+		Context("when the edit window of the post is over", func() {
+			It("returns edit window expired", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{
+						UserID:    userId,
+						CreatedAt: time.Now().Add(-configs.GetDuration("app.posts.edit-window") - time.Minute),
+					},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  userId,
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "fixing a typo",
+				})
+
+				Expect(errors[0]).To(Equal(ErrEditWindowExpired))
+			})
+		})
+
+		Context("when the edit window of the post closes while it is edited", func() {
+			It("returns edit window expired", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: userId, EditWindowClosed: true},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  userId,
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "fixing a typo",
+				})
+
+				Expect(errors[0]).To(Equal(ErrEditWindowExpired))
+			})
+		})
+
+		Context("when its given a repost", func() {
+			It("returns repost not editable", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: userId, Repost: true},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  userId,
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "adding content",
+				})
+
+				Expect(errors[0]).To(Equal(ErrRepostNotEditable))
+			})
+		})
+
+		Context("when its given a post from another user", func() {
+			It("returns post not found", func() {
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: primitive.NewObjectID().Hex()},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.EditPost(context.Background(), &entity.EditPostRequest{
+					UserID:  primitive.NewObjectID().Hex(),
+					PostID:  primitive.NewObjectID().Hex(),
+					Content: "not mine",
+				})
+
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
+			})
+		})
+	})
+
+	Describe("Getting the history of a post", func() {
+		Context("when its given a post from the user", func() {
+			It("returns the post with its previous versions", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: userId},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				history, errors := service.GetPostHistory(context.Background(), &entity.PostHistoryRequest{
+					UserID: userId,
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(history.Post.Content).To(Equal("this is a post"))
+				Expect(history.Versions).To(HaveLen(1))
+				Expect(history.Versions[0].Content).To(Equal("this is a pots"))
+			})
+		})
+
+		Context("when its given a post from another user", func() {
+			It("returns post not found", func() {
+				service = NewPostService(
+					&post_mock.OwnedPostRepositoryMock{UserID: primitive.NewObjectID().Hex()},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.GetPostHistory(context.Background(), &entity.PostHistoryRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
+			})
+		})
+	})
+
+	Describe("Liking a post", func() {
+		Context("when the post was not liked by the user", func() {
+			It("likes it", func() {
+				likeRepository := &like_mock.SuccessLikeRepositoryMock{}
+
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					likeRepository,
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.LikePost(context.Background(), &entity.LikeRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(likeRepository.Likes).To(Equal(1))
+			})
+		})
+
+		Context("when the post was already liked by the user", func() {
+			It("returns no error", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.AlreadyLikedRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.LikePost(context.Background(), &entity.LikeRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Unliking a post", func() {
+		Context("when the post was not liked by the user", func() {
+			It("returns no error", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.AlreadyLikedRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				errors := service.UnlikePost(context.Background(), &entity.LikeRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					PostID: primitive.NewObjectID().Hex(),
+				})
+
+				Expect(errors).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Getting liked posts by user", func() {
+		Context("when its given a user", func() {
+			It("returns the liked posts with a cursor", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				posts, errors := service.ListLikedPosts(context.Background(), &entity.ListLikedPostsRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					Limit:  3,
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(posts.Data).To(HaveLen(3))
+				Expect(posts.NextCursor).NotTo(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Getting a conversation", func() {
+		Context("when its given a post", func() {
+			It("returns its replies as a tree", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				conversation, errors := service.GetConversation(context.Background(), &entity.ConversationRequest{
+					PostID: primitive.NewObjectID().Hex(),
+					Limit:  2,
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(conversation.Replies).To(HaveLen(2))
+				Expect(conversation.Replies[0].Replies).To(HaveLen(1))
+				Expect(conversation.NextCursor).NotTo(BeEmpty())
+			})
+		})
+
+		Context("when the post does not exist", func() {
+			It("returns post not found", func() {
+				service = NewPostService(
+					&post_mock.NotFoundPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				_, errors := service.GetConversation(context.Background(), &entity.ConversationRequest{
+					PostID: primitive.NewObjectID().Hex(),
+				})
 
-				Expect(errors[0]).To(Equal(ErrLimitPostsByDay))
+				Expect(errors[0]).To(Equal(ErrPostNotFound))
 			})
 		})
 	})
@@ -104,7 +666,10 @@ var _ = Describe("PostRepository suite test", func() {
 		BeforeEach(func() {
 			service = NewPostService(
 				&post_mock.SuccessPostRepositoryMock{},
-				&follower_mock.SuccessFollowerRepositoryMock{},
+				&like_mock.SuccessLikeRepositoryMock{},
+				&user_mock.SuccessUserRepositoryMock{},
+				newTimelineService(),
+				newQuotaService(),
 				configs,
 			)
 		})
@@ -114,26 +679,42 @@ var _ = Describe("PostRepository suite test", func() {
 				userId := primitive.NewObjectID().Hex()
 				request := entity.ListPostRequest{
 					UserID: userId,
-					Page:   1,
 					Limit:  5,
 				}
 
-				posts, err := service.ListLastPostByUser(&request)
+				posts, err := service.ListLastPostByUser(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 
-				for _, post := range posts {
+				for _, post := range posts.Data {
 					Expect(post.UserID.Hex()).To(Equal(userId))
 				}
 			})
 		})
+
+		Context("when its given an invalid cursor", func() {
+			It("returns error", func() {
+				request := entity.ListPostRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					Cursor: "not a cursor",
+					Limit:  5,
+				}
+
+				_, errors := service.ListLastPostByUser(context.Background(), &request)
+
+				Expect(errors[0]).To(Equal(entity.ErrInvalidCursor))
+			})
+		})
 	})
 
 	Describe("Getting user feed", func() {
 		BeforeEach(func() {
 			service = NewPostService(
 				&post_mock.SuccessPostRepositoryMock{},
-				&follower_mock.SuccessFollowerRepositoryMock{},
+				&like_mock.SuccessLikeRepositoryMock{},
+				&user_mock.SuccessUserRepositoryMock{},
+				newTimelineService(),
+				newQuotaService(),
 				configs,
 			)
 		})
@@ -143,18 +724,108 @@ var _ = Describe("PostRepository suite test", func() {
 				userId := primitive.NewObjectID().Hex()
 				request := entity.ListFeedRequest{
 					UserID: userId,
-					Page:   1,
 					Limit:  5,
 				}
 
-				posts, err := service.ListFeed(&request)
+				posts, err := service.ListFeed(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 
-				for _, post := range posts {
+				for _, post := range posts.Data {
 					Expect(post.UserID.Hex()).NotTo(Equal(userId))
 				}
 			})
 		})
 	})
+
+	Describe("Listing posts by hashtag", func() {
+		BeforeEach(func() {
+			service = NewPostService(
+				&post_mock.SuccessPostRepositoryMock{},
+				&like_mock.SuccessLikeRepositoryMock{},
+				&user_mock.SuccessUserRepositoryMock{},
+				newTimelineService(),
+				newQuotaService(),
+				configs,
+			)
+		})
+
+		Context("when its given a hashtag with # and upper case letters", func() {
+			It("returns the posts of the normalized hashtag", func() {
+				posts, errors := service.ListPostsByHashtag(context.Background(), &entity.ListHashtagPostsRequest{Tag: "#GoLang"})
+
+				Expect(errors).To(BeNil())
+				Expect(posts.Data).To(HaveLen(1))
+				Expect(posts.Data[0].Hashtags).To(ConsistOf("golang"))
+			})
+		})
+
+		Context("when its given an empty hashtag", func() {
+			It("returns error", func() {
+				_, errors := service.ListPostsByHashtag(context.Background(), &entity.ListHashtagPostsRequest{Tag: "#"})
+
+				Expect(errors).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Listing the mentions of a user", func() {
+		Context("when its given a user", func() {
+			It("returns the posts that mention it", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				userId := primitive.NewObjectID().Hex()
+
+				posts, errors := service.ListMentions(context.Background(), &entity.ListMentionsRequest{UserID: userId})
+
+				Expect(errors).To(BeNil())
+				Expect(posts.Data[0].Mentions[0].UserID.Hex()).To(Equal(userId))
+			})
+		})
+	})
+
+	Describe("Listing trending hashtags", func() {
+		Context("when there are hashtags on the window", func() {
+			It("returns them ranked", func() {
+				service = NewPostService(
+					&post_mock.SuccessPostRepositoryMock{},
+					&like_mock.SuccessLikeRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					newTimelineService(),
+					newQuotaService(),
+					configs,
+				)
+
+				hashtags, errors := service.ListTrendingHashtags(context.Background(), &entity.ListTrendingHashtagsRequest{})
+
+				Expect(errors).To(BeNil())
+				Expect(hashtags.Data[0].Tag).To(Equal("golang"))
+			})
+		})
+	})
 })
+
+func newTimelineService() *timeline_service.TimelineService {
+	return timeline_service.NewTimelineService(
+		&timeline_mock.SuccessTimelineRepositoryMock{},
+		&post_mock.SuccessPostRepositoryMock{},
+		&follower_mock.SuccessFollowerRepositoryMock{},
+		&user_mock.SuccessUserRepositoryMock{},
+		configs,
+	)
+}
+
+func newQuotaService() *quota_service.QuotaService {
+	return quota_service.NewQuotaService(
+		&user_mock.SuccessUserRepositoryMock{},
+		&post_mock.SuccessPostRepositoryMock{},
+		configs,
+	)
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ID is the value stored for id, ids are kept as the hex of ObjectIDs and the zero one as NULL
+func ID(id primitive.ObjectID) interface{} {
+	if id.IsZero() {
+		return nil
+	}
+
+	return id.Hex()
+}
+
+// IDs is the value of ids as a text[] parameter, as for "= ANY($1)"
+func IDs(ids []primitive.ObjectID) interface{} {
+	hexes := []string{}
+
+	for _, id := range ids {
+		hexes = append(hexes, id.Hex())
+	}
+
+	return pq.Array(hexes)
+}
+
+// ScanID scans an id column into dest, NULL as the zero id
+func ScanID(dest *primitive.ObjectID) *IDScanner {
+	return &IDScanner{dest: dest}
+}
+
+type IDScanner struct {
+	dest *primitive.ObjectID
+}
+
+func (scanner *IDScanner) Scan(value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		*scanner.dest = primitive.NilObjectID
+	case string:
+		return scanner.scanHex(value)
+	case []byte:
+		return scanner.scanHex(string(value))
+	default:
+		return fmt.Errorf("postgres: can not scan %T into an id", value)
+	}
+
+	return nil
+}
+
+func (scanner *IDScanner) scanHex(hex string) error {
+	id, err := primitive.ObjectIDFromHex(hex)
+
+	if err != nil {
+		return err
+	}
+
+	*scanner.dest = id
+
+	return nil
+}
+
+// Strings is the value of values as a text[] parameter
+func Strings(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+
+	return pq.Array(values)
+}
+
+// QueryIDs returns the ids of the first column of the rows of query
+func QueryIDs(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var id primitive.ObjectID
+		if err := rows.Scan(ScanID(&id)); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id.Hex())
+	}
+
+	return ids, rows.Err()
+}
+
+// QueryCounts returns the counts of the rows of query, which selects an id and its count
+func QueryCounts(ctx context.Context, db *sql.DB, query string, args ...interface{}) (map[string]int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := make(map[string]int64)
+
+	for rows.Next() {
+		var id primitive.ObjectID
+		var count int64
+
+		if err := rows.Scan(ScanID(&id), &count); err != nil {
+			return nil, err
+		}
+
+		result[id.Hex()] = count
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// the key of the advisory lock held while migrating, so instances starting together migrate once
+const migrationsLock = 7867386
+
+// Migrate applies the migrations that were not applied yet, in the order of their names,
+// each one in a transaction along with its row on schema_migrations
+func Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    text PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+		script, err := migrations.ReadFile(name)
+
+		if err != nil {
+			return err
+		}
+
+		err = Transaction(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLock); err != nil {
+				return err
+			}
+
+			var applied bool
+
+			err := tx.QueryRowContext(
+				ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version,
+			).Scan(&applied)
+
+			if err != nil || applied {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version)
+
+			return err
+		})
+
+		if err != nil {
+			return fmt.Errorf("postgres: migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+-- ids are the hex of ObjectIDs, which are generated by the application as on MongoDB,
+-- the "C" collation orders them by their bytes as MongoDB does
+CREATE TABLE users (
+    id              text COLLATE "C" PRIMARY KEY,
+    username        text COLLATE "C" NOT NULL UNIQUE,
+    password_hash   text NOT NULL DEFAULT '',
+    timezone        text NOT NULL DEFAULT '',
+    created_at      timestamptz NOT NULL,
+    followers_count bigint NOT NULL DEFAULT 0,
+    following_count bigint NOT NULL DEFAULT 0,
+    posts_count     bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX users_followers_count_idx ON users (followers_count DESC, username);
+
+CREATE TABLE followers (
+    id          text COLLATE "C" PRIMARY KEY,
+    follower_id text COLLATE "C" NOT NULL,
+    user_id     text COLLATE "C" NOT NULL,
+    created_at  timestamptz NOT NULL,
+    UNIQUE (follower_id, user_id)
+);
+
+CREATE INDEX followers_user_id_idx ON followers (user_id, id DESC);
+CREATE INDEX followers_follower_id_idx ON followers (follower_id, id DESC);
@@ -0,0 +1,35 @@
+CREATE TABLE posts (
+    id                  text COLLATE "C" PRIMARY KEY,
+    user_id             text COLLATE "C" NOT NULL,
+    parent_id           text COLLATE "C",
+    in_reply_to_id      text COLLATE "C",
+    in_reply_to_user_id text COLLATE "C",
+    conversation_id     text COLLATE "C",
+    content             text NOT NULL DEFAULT '',
+    hashtags            text[] NOT NULL DEFAULT '{}',
+    mentions            jsonb NOT NULL DEFAULT '[]',
+    likes_count         bigint NOT NULL DEFAULT 0,
+    replies_count       bigint NOT NULL DEFAULT 0,
+    created_at          timestamptz NOT NULL,
+    deleted_at          timestamptz
+);
+
+-- the keyset pagination of the listings, from the newest
+CREATE INDEX posts_user_id_created_at_idx ON posts (user_id, created_at DESC, id DESC);
+CREATE INDEX posts_created_at_idx ON posts (created_at DESC, id DESC);
+-- the replies, from the oldest
+CREATE INDEX posts_in_reply_to_id_idx ON posts (in_reply_to_id, created_at, id) WHERE in_reply_to_id IS NOT NULL;
+CREATE INDEX posts_hashtags_idx ON posts USING gin (hashtags);
+CREATE INDEX posts_mentions_idx ON posts USING gin (mentions jsonb_path_ops);
+CREATE INDEX posts_content_idx ON posts USING gin (to_tsvector('simple', content));
+
+CREATE TABLE likes (
+    id         text COLLATE "C" PRIMARY KEY,
+    user_id    text COLLATE "C" NOT NULL,
+    post_id    text COLLATE "C" NOT NULL,
+    created_at timestamptz NOT NULL,
+    UNIQUE (user_id, post_id)
+);
+
+CREATE INDEX likes_post_id_idx ON likes (post_id, created_at DESC, id DESC);
+CREATE INDEX likes_user_id_idx ON likes (user_id, created_at DESC, id DESC);
@@ -0,0 +1,15 @@
+-- a row on timelines marks a materialized timeline, even when it has no entries
+CREATE TABLE timelines (
+    user_id text COLLATE "C" PRIMARY KEY
+);
+
+CREATE TABLE timeline_entries (
+    user_id    text COLLATE "C" NOT NULL REFERENCES timelines ON DELETE CASCADE,
+    post_id    text COLLATE "C" NOT NULL,
+    author_id  text COLLATE "C" NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX timeline_entries_created_at_idx ON timeline_entries (user_id, created_at DESC, post_id DESC);
+CREATE INDEX timeline_entries_post_id_idx ON timeline_entries (post_id);
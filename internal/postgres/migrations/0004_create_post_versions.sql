@@ -0,0 +1,13 @@
+ALTER TABLE posts ADD COLUMN edited_at timestamptz;
+
+-- the versions of the posts before their edits, in the order they were replaced
+CREATE TABLE post_versions (
+    id         bigserial PRIMARY KEY,
+    post_id    text COLLATE "C" NOT NULL,
+    content    text NOT NULL,
+    hashtags   text[] NOT NULL DEFAULT '{}',
+    mentions   jsonb NOT NULL DEFAULT '[]',
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX post_versions_post_id_idx ON post_versions (post_id, id);
@@ -0,0 +1,6 @@
+-- users whose posts are pulled into the feeds instead of pushed to the timelines
+ALTER TABLE users ADD COLUMN pulled boolean NOT NULL DEFAULT false;
+
+-- the pulled users each timeline reads along with its entries, NULL on the timelines
+-- materialized before they were kept, which are rebuilt when they are read
+ALTER TABLE timelines ADD COLUMN pulled text[] COLLATE "C";
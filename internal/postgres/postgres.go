@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/spf13/viper"
+	. "go.uber.org/fx"
+	"net/url"
+	"time"
+)
+
+// the code of unique_violation, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const uniqueViolation = "23505"
+
+// NewPostgresDB only prepares the pool, connections are opened when the application starts
+func NewPostgresDB(configs *viper.Viper) (*sql.DB, error) {
+	dataSource := url.URL{
+		Scheme: "postgres",
+		User: url.UserPassword(
+			configs.GetString("app.postgres.user"),
+			configs.GetString("app.postgres.password"),
+		),
+		Host:     configs.GetString("app.postgres.host") + ":" + configs.GetString("app.postgres.port"),
+		Path:     configs.GetString("app.postgres.database"),
+		RawQuery: url.Values{"sslmode": {configs.GetString("app.postgres.ssl-mode")}}.Encode(),
+	}
+
+	db, err := sql.Open("postgres", dataSource.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(configs.GetInt("app.postgres.max-open-connections"))
+
+	return db, nil
+}
+
+// IsStorage is whether the repositories are set to PostgreSQL on app.storage.driver
+func IsStorage(configs *viper.Viper) bool {
+	return configs.GetString("app.storage.driver") == "postgres"
+}
+
+// IsUsed is whether the repositories or the search are set to PostgreSQL, the database is only reached when one of them is
+func IsUsed(configs *viper.Viper) bool {
+	return IsStorage(configs) || configs.GetString("app.search.backend") == "postgres"
+}
+
+// RegisterPostgres checks the connection and migrates the schema on the start, only when PostgreSQL is used
+func RegisterPostgres(lifecycle Lifecycle, db *sql.DB, configs *viper.Viper) {
+	if !IsUsed(configs) {
+		return
+	}
+
+	lifecycle.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := db.PingContext(ctx); err != nil {
+				return err
+			}
+
+			return Migrate(ctx, db)
+		},
+		OnStop: func(ctx context.Context) error {
+			return db.Close()
+		},
+	})
+}
+
+func IsDup(err error) bool {
+	var e *pq.Error
+	return errors.As(err, &e) && e.Code == uniqueViolation
+}
+
+// Time is t as it is stored, with millisecond precision as the cursors have
+func Time(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func Now() time.Time {
+	return Time(time.Now())
+}
+
+// Transaction runs fn in a transaction, which is committed when fn returns no error
+func Transaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w, rollback failed: %v", err, rollbackErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
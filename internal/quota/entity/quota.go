@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	// the timezones of the users are loaded even where the system has no zoneinfo
+	_ "time/tzdata"
+)
+
+type WindowKind string
+
+const (
+	// WindowCalendarDay starts at midnight in the timezone of the user
+	WindowCalendarDay WindowKind = "calendar-day"
+	// WindowHourly starts at the beginning of the current hour, allowing bursts every hour
+	WindowHourly WindowKind = "hourly"
+	// WindowRolling covers the last duration, so it has no fixed reset time
+	WindowRolling WindowKind = "rolling"
+)
+
+type Window struct {
+	Kind  WindowKind
+	Start time.Time
+	End   time.Time
+}
+
+// NewWindow returns the window that contains now, the duration is only used by rolling windows
+func NewWindow(kind WindowKind, duration time.Duration, now time.Time) (*Window, error) {
+	switch kind {
+	case WindowCalendarDay:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+		return &Window{Kind: kind, Start: start, End: start.AddDate(0, 0, 1)}, nil
+	case WindowHourly:
+		start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+
+		return &Window{Kind: kind, Start: start, End: start.Add(time.Hour)}, nil
+	case WindowRolling:
+		if duration <= 0 {
+			return nil, fmt.Errorf("quota: rolling window needs a positive duration, got %s", duration)
+		}
+
+		return &Window{Kind: kind, Start: now.Add(-duration), End: now}, nil
+	default:
+		return nil, fmt.Errorf("quota: unknown window %q", kind)
+	}
+}
+
+// Quota is how many posts a user can still create and when the window frees them
+type Quota struct {
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+func (quota *Quota) Exhausted() bool {
+	return quota.Remaining <= 0
+}
+
+// Take counts a post created on the quota
+func (quota *Quota) Take() {
+	if quota.Remaining > 0 {
+		quota.Remaining--
+	}
+}
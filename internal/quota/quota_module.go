@@ -0,0 +1,17 @@
+package quota
+
+import (
+	"github.com/regiszanandrea/posty/internal/quota/service"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			Annotate(
+				service.NewQuotaService,
+				As(new(service.Service)),
+			),
+		),
+	)
+)
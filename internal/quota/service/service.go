@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	post_repository "github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/quota/entity"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+	"time"
+)
+
+type Service interface {
+	GetPostsQuota(ctx context.Context, userId string) (*entity.Quota, error)
+}
+
+type QuotaService struct {
+	userRepository user_repository.Repository
+	postRepository post_repository.Repository
+	configs        *viper.Viper
+}
+
+func NewQuotaService(
+	userRepository user_repository.Repository,
+	postRepository post_repository.Repository,
+	configs *viper.Viper,
+) *QuotaService {
+	return &QuotaService{
+		userRepository: userRepository,
+		postRepository: postRepository,
+		configs:        configs,
+	}
+}
+
+// GetPostsQuota counts the posts of the user on the current window, deleted posts included,
+// so deleting a post does not give the quota back
+func (service *QuotaService) GetPostsQuota(ctx context.Context, userId string) (*entity.Quota, error) {
+	ctx, span := tracing.Start(ctx, "QuotaService.GetPostsQuota")
+	defer span.End()
+
+	location, err := service.location(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := entity.NewWindow(
+		entity.WindowKind(service.configs.GetString("app.quota.posts.window")),
+		service.configs.GetDuration("app.quota.posts.rolling-duration"),
+		time.Now().In(location),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := service.postRepository.CountByUserSince(ctx, userId, window.Start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	limit := service.configs.GetInt("app.quota.posts.limit")
+
+	quota := &entity.Quota{
+		Limit:     limit,
+		Remaining: limit - count,
+		ResetAt:   window.End,
+	}
+
+	if quota.Remaining < 0 {
+		quota.Remaining = 0
+	}
+
+	// a rolling window frees a post when the oldest one on it gets out of the window, with
+	// no post on it the one about to be created is the oldest
+	if window.Kind == entity.WindowRolling {
+		quota.ResetAt = window.End.Add(window.End.Sub(window.Start))
+	}
+
+	if window.Kind == entity.WindowRolling && count > 0 {
+		oldest, err := service.postRepository.FindOldestCreatedAtSince(ctx, userId, window.Start)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if oldest != nil {
+			quota.ResetAt = oldest.Add(window.End.Sub(window.Start))
+		}
+	}
+
+	return quota, nil
+}
+
+// location is the timezone of the user, or the default one when the user has not set it
+func (service *QuotaService) location(ctx context.Context, userId string) (*time.Location, error) {
+	timezone := service.configs.GetString("app.quota.default-timezone")
+
+	user, err := service.userRepository.Find(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil && user.Timezone != "" {
+		timezone = user.Timezone
+	}
+
+	return time.LoadLocation(timezone)
+}
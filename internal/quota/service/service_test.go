@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestQuotaService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quota Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *QuotaService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("QuotaService suite test", func() {
+	AfterEach(func() {
+		configs.Set("app.quota.posts.window", "calendar-day")
+	})
+
+	Describe("Getting the quota of posts of a user", func() {
+		Context("when the user has set a timezone", func() {
+			It("counts the posts since midnight on that timezone", func() {
+				postRepository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewQuotaService(
+					&user_mock.TimezoneUserRepositoryMock{Timezone: "America/Sao_Paulo"},
+					postRepository,
+					configs,
+				)
+
+				quota, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+
+				since := postRepository.CountedSince
+
+				Expect(since.Location().String()).To(Equal("America/Sao_Paulo"))
+				Expect(since.Hour()).To(Equal(0))
+				Expect(since.Minute()).To(Equal(0))
+				Expect(quota.ResetAt).To(Equal(since.AddDate(0, 0, 1)))
+				Expect(quota.Limit).To(Equal(configs.GetInt("app.quota.posts.limit")))
+				Expect(quota.Remaining).To(Equal(quota.Limit))
+				Expect(quota.Exhausted()).To(BeFalse())
+			})
+		})
+
+		Context("when the user has not set a timezone", func() {
+			It("uses the default one", func() {
+				postRepository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewQuotaService(&user_mock.SuccessUserRepositoryMock{}, postRepository, configs)
+
+				_, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(postRepository.CountedSince.Location().String()).To(Equal(configs.GetString("app.quota.default-timezone")))
+			})
+		})
+
+		Context("when the window is hourly", func() {
+			It("counts the posts since the beginning of the hour", func() {
+				configs.Set("app.quota.posts.window", "hourly")
+
+				postRepository := &post_mock.SuccessPostRepositoryMock{}
+
+				service = NewQuotaService(&user_mock.SuccessUserRepositoryMock{}, postRepository, configs)
+
+				quota, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(postRepository.CountedSince.Minute()).To(Equal(0))
+				Expect(quota.ResetAt).To(Equal(postRepository.CountedSince.Add(time.Hour)))
+			})
+		})
+
+		Context("when the window is rolling and has no posts", func() {
+			It("resets a window after now", func() {
+				configs.Set("app.quota.posts.window", "rolling")
+
+				service = NewQuotaService(&user_mock.SuccessUserRepositoryMock{}, &post_mock.SuccessPostRepositoryMock{}, configs)
+
+				quota, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(quota.Remaining).To(Equal(quota.Limit))
+				Expect(quota.ResetAt).To(BeTemporally("~", time.Now().Add(configs.GetDuration("app.quota.posts.rolling-duration")), time.Second))
+			})
+		})
+
+		Context("when the window is rolling and the quota was used", func() {
+			It("resets when the oldest post leaves the window", func() {
+				configs.Set("app.quota.posts.window", "rolling")
+
+				service = NewQuotaService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&post_mock.MaximumPostsCreatedRepositoryMock{Configs: configs},
+					configs,
+				)
+
+				quota, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(quota.Exhausted()).To(BeTrue())
+				Expect(quota.Remaining).To(Equal(0))
+				Expect(quota.ResetAt).To(BeTemporally("~", time.Now().Add(23*time.Hour), time.Second))
+			})
+		})
+
+		Context("when the window is unknown", func() {
+			It("returns error", func() {
+				configs.Set("app.quota.posts.window", "weekly")
+
+				service = NewQuotaService(&user_mock.SuccessUserRepositoryMock{}, &post_mock.SuccessPostRepositoryMock{}, configs)
+
+				_, err := service.GetPostsQuota(context.Background(), primitive.NewObjectID().Hex())
+
+				Expect(err).NotTo(BeNil())
+			})
+		})
+	})
+})
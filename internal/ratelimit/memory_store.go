@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket is full again, the same as not having one
+	fullAt time.Time
+}
+
+// MemoryStore keeps the buckets on the instance, so every instance limits on its own
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (store *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sweep(now)
+
+	b, ok := store.buckets[key]
+
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		store.buckets[key] = b
+	}
+
+	b.tokens = limit.refill(b.tokens, b.updatedAt, now)
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+
+	if allowed {
+		b.tokens--
+	}
+
+	b.fullAt = now.Add(limit.until(b.tokens, float64(limit.Burst)))
+
+	return limit.result(b.tokens, allowed), nil
+}
+
+// sweep removes the buckets that are full again, so idle clients do not hold memory
+func (store *MemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range store.buckets {
+		if !now.Before(b.fullAt) {
+			delete(store.buckets, key)
+		}
+	}
+
+	store.lastSweep = now
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/metrics"
+	"github.com/spf13/viper"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+var ErrRateLimited = apperror.RateLimited("rate_limited", "too many requests, slow down")
+
+type Middleware struct {
+	store   Store
+	configs *viper.Viper
+}
+
+func NewMiddleware(store Store, configs *viper.Viper) *Middleware {
+	return &Middleware{
+		store:   store,
+		configs: configs,
+	}
+}
+
+// Handle limits the requests of the group on app.rate-limits.groups by the authenticated user,
+// or by the IP when there is none, so it goes after the auth middleware on the routes that have it.
+// Groups without a limit are not limited, and requests are let through when the store fails
+func (m *Middleware) Handle(group string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		limit, ok := m.limit(group)
+
+		if !ok {
+			return ctx.Next()
+		}
+
+		key := group + ":ip:" + ctx.IP()
+
+		if userId := middleware.AuthenticatedUserID(ctx); userId != "" {
+			key = group + ":user:" + userId
+		}
+
+		result, err := m.store.Take(ctx.UserContext(), key, limit, time.Now())
+
+		if err != nil {
+			log.Printf("could not take a token of %s: %v", key, err)
+			return ctx.Next()
+		}
+
+		ctx.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+		ctx.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		ctx.Set(HeaderRateLimitReset, seconds(result.ResetAfter))
+
+		if !result.Allowed {
+			metrics.RateLimitedRequests.WithLabelValues(group).Inc()
+			ctx.Set(fiber.HeaderRetryAfter, seconds(result.RetryAfter))
+
+			return ErrRateLimited
+		}
+
+		return ctx.Next()
+	}
+}
+
+func (m *Middleware) limit(group string) (Limit, bool) {
+	key := "app.rate-limits.groups." + group
+
+	if !m.configs.GetBool("app.rate-limits.enabled") || !m.configs.IsSet(key) {
+		return Limit{}, false
+	}
+
+	limit := Limit{
+		Burst:  m.configs.GetInt(key + ".burst"),
+		Rate:   m.configs.GetInt(key + ".rate"),
+		Period: m.configs.GetDuration(key + ".period"),
+	}
+
+	return limit, limit.Burst > 0 && limit.Rate > 0 && limit.Period > 0
+}
+
+// seconds rounds up, so clients waiting for it do not come back too early
+func seconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
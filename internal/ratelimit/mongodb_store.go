@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type bucketDocument struct {
+	Key     string  `bson:"_id"`
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+// MongoDBStore shares the buckets between the instances, every bucket is refilled and taken
+// on a single update so concurrent requests can not take the same token
+type MongoDBStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBStore(client *mongo.Client, configs *viper.Viper) *MongoDBStore {
+	collection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.rate-limit-collection"),
+	)
+
+	return &MongoDBStore{
+		collection: collection,
+	}
+}
+
+func (store *MongoDBStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	result, err := store.take(ctx, key, limit, now)
+
+	// two requests creating the same bucket race on the upsert, the one that lost finds it created
+	if mongo.IsDuplicateKeyError(err) {
+		return store.take(ctx, key, limit, now)
+	}
+
+	return result, err
+}
+
+func (store *MongoDBStore) take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	nowDate := primitive.NewDateTimeFromTime(now)
+	burst := float64(limit.Burst)
+	// subtracting dates gives milliseconds
+	tokensPerMillisecond := limit.perSecond() / 1000
+
+	var document bucketDocument
+
+	err := store.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		mongo.Pipeline{
+			{{"$set", bson.D{
+				{"tokens", bson.D{{"$min", bson.A{
+					burst,
+					bson.D{{"$add", bson.A{
+						bson.D{{"$ifNull", bson.A{"$tokens", burst}}},
+						bson.D{{"$multiply", bson.A{
+							bson.D{{"$max", bson.A{0, bson.D{{"$subtract", bson.A{nowDate, bson.D{{"$ifNull", bson.A{"$updated_at", nowDate}}}}}}}}},
+							tokensPerMillisecond,
+						}}},
+					}}},
+				}}}},
+				{"updated_at", nowDate},
+			}}},
+			{{"$set", bson.D{
+				{"allowed", bson.D{{"$gte", bson.A{"$tokens", 1}}}},
+			}}},
+			{{"$set", bson.D{
+				{"tokens", bson.D{{"$cond", bson.A{"$allowed", bson.D{{"$subtract", bson.A{"$tokens", 1}}}, "$tokens"}}}},
+			}}},
+			// the bucket is removed by the TTL index once it is full again
+			{{"$set", bson.D{
+				{"expires_at", bson.D{{"$add", bson.A{
+					nowDate,
+					bson.D{{"$divide", bson.A{bson.D{{"$subtract", bson.A{burst, "$tokens"}}}, tokensPerMillisecond}}},
+				}}}},
+			}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&document)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return limit.result(document.Tokens, document.Allowed), nil
+}
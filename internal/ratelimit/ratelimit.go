@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket that holds up to Burst tokens and refills Rate tokens every Period,
+// every request takes a token
+type Limit struct {
+	Burst  int
+	Rate   int
+	Period time.Duration
+}
+
+func (limit Limit) perSecond() float64 {
+	return float64(limit.Rate) / limit.Period.Seconds()
+}
+
+// refill returns the tokens of a bucket that had tokens at updatedAt
+func (limit Limit) refill(tokens float64, updatedAt, now time.Time) float64 {
+	if elapsed := now.Sub(updatedAt); elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.perSecond()
+	}
+
+	return math.Min(tokens, float64(limit.Burst))
+}
+
+// until returns how long the bucket takes to refill from tokens to target
+func (limit Limit) until(tokens, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+
+	return time.Duration((target - tokens) / limit.perSecond() * float64(time.Second))
+}
+
+// result describes the bucket left with tokens after a request was allowed or not
+func (limit Limit) result(tokens float64, allowed bool) *Result {
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: limit.until(tokens, float64(limit.Burst)),
+	}
+
+	if !allowed {
+		result.RetryAfter = limit.until(tokens, 1)
+	}
+
+	return result
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long the bucket takes to be full again
+	ResetAfter time.Duration
+	// RetryAfter is how long a rejected request has to wait for a token
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take takes a token from the bucket of key, creating a full one when it does not exist
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error)
+}
+
+// NewStore returns the store set on app.rate-limits.store, memory limits every instance
+// on its own and mongodb shares the limits between them
+func NewStore(client *mongo.Client, configs *viper.Viper) (Store, error) {
+	switch store := configs.GetString("app.rate-limits.store"); store {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "mongodb":
+		return NewMongoDBStore(client, configs), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown store %q", store)
+	}
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	posty_fiber "github.com/regiszanandrea/posty/internal/fiber"
+	"github.com/spf13/viper"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rate Limit Suite")
+}
+
+var _ = Describe("Rate limit suite test", func() {
+	limit := Limit{Burst: 2, Rate: 1, Period: time.Second}
+
+	Describe("Taking tokens from the memory store", func() {
+		var (
+			store *MemoryStore
+			now   time.Time
+		)
+
+		BeforeEach(func() {
+			store = NewMemoryStore()
+			now = time.Now()
+		})
+
+		Context("when the bucket has tokens", func() {
+			It("allows the requests until it is empty", func() {
+				first, _ := store.Take(context.Background(), "key", limit, now)
+				second, _ := store.Take(context.Background(), "key", limit, now)
+				third, _ := store.Take(context.Background(), "key", limit, now)
+
+				Expect(first.Allowed).To(BeTrue())
+				Expect(first.Remaining).To(Equal(1))
+				Expect(second.Allowed).To(BeTrue())
+				Expect(second.Remaining).To(Equal(0))
+				Expect(second.ResetAfter).To(Equal(2 * time.Second))
+				Expect(third.Allowed).To(BeFalse())
+				Expect(third.RetryAfter).To(Equal(time.Second))
+			})
+		})
+
+		Context("when time has passed since the bucket was emptied", func() {
+			It("refills it up to the burst", func() {
+				_, _ = store.Take(context.Background(), "key", limit, now)
+				_, _ = store.Take(context.Background(), "key", limit, now)
+
+				result, _ := store.Take(context.Background(), "key", limit, now.Add(time.Hour))
+
+				Expect(result.Allowed).To(BeTrue())
+				Expect(result.Remaining).To(Equal(1))
+			})
+		})
+
+		Context("when the keys are different", func() {
+			It("keeps a bucket for each one", func() {
+				_, _ = store.Take(context.Background(), "key", limit, now)
+				_, _ = store.Take(context.Background(), "key", limit, now)
+
+				result, _ := store.Take(context.Background(), "other", limit, now)
+
+				Expect(result.Allowed).To(BeTrue())
+			})
+		})
+
+		Context("when the buckets are full again", func() {
+			It("sweeps them", func() {
+				_, _ = store.Take(context.Background(), "key", limit, now)
+				_, _ = store.Take(context.Background(), "other", limit, now.Add(2*sweepInterval))
+
+				Expect(store.buckets).To(HaveLen(1))
+				Expect(store.buckets).To(HaveKey("other"))
+			})
+		})
+	})
+
+	Describe("Limiting the requests of a group", func() {
+		var app *fiber.App
+
+		BeforeEach(func() {
+			configs := viper.New()
+			configs.Set("app.rate-limits.enabled", true)
+			configs.Set("app.rate-limits.groups.feed", map[string]interface{}{"burst": 1, "rate": 1, "period": "1m"})
+
+			m := NewMiddleware(NewMemoryStore(), configs)
+
+			app = fiber.New(fiber.Config{
+				ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+					return ctx.SendStatus(apperror.From(err).Status())
+				},
+			})
+
+			app.Get("/feed", m.Handle("feed"), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+			app.Get("/posts", m.Handle("posts"), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+		})
+
+		Context("when the client is within the limit", func() {
+			It("answers the limit on the headers", func() {
+				resp, err := app.Test(httptest.NewRequest("GET", "/feed", nil))
+
+				Expect(err).To(BeNil())
+				Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+				Expect(resp.Header.Get(HeaderRateLimitLimit)).To(Equal("1"))
+				Expect(resp.Header.Get(HeaderRateLimitRemaining)).To(Equal("0"))
+				Expect(resp.Header.Get(HeaderRateLimitReset)).To(Equal("60"))
+			})
+		})
+
+		Context("when the client goes over the limit", func() {
+			It("answers too many requests with when to retry", func() {
+				_, _ = app.Test(httptest.NewRequest("GET", "/feed", nil))
+
+				resp, err := app.Test(httptest.NewRequest("GET", "/feed", nil))
+
+				Expect(err).To(BeNil())
+				Expect(resp.StatusCode).To(Equal(fiber.StatusTooManyRequests))
+				Expect(resp.Header.Get(fiber.HeaderRetryAfter)).To(Equal("60"))
+			})
+		})
+
+		Context("when the clients are behind a load balancer", func() {
+			It("keeps a bucket for the IP of each client on the proxy header", func() {
+				configs := viper.New()
+				configs.Set("app.rate-limits.enabled", true)
+				configs.Set("app.rate-limits.groups.feed", map[string]interface{}{"burst": 1, "rate": 1, "period": "1m"})
+				configs.Set("app.fiber.proxy-header", "X-Real-IP")
+
+				m := NewMiddleware(NewMemoryStore(), configs)
+
+				app = posty_fiber.NewFiber(configs)
+				app.Get("/feed", m.Handle("feed"), func(ctx *fiber.Ctx) error {
+					return ctx.SendStatus(fiber.StatusOK)
+				})
+
+				for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+					request := httptest.NewRequest("GET", "/feed", nil)
+					request.Header.Set("X-Real-IP", ip)
+
+					resp, err := app.Test(request)
+
+					Expect(err).To(BeNil())
+					Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+				}
+			})
+		})
+
+		Context("when the group has no limit", func() {
+			It("does not limit it", func() {
+				resp, err := app.Test(httptest.NewRequest("GET", "/posts", nil))
+
+				Expect(err).To(BeNil())
+				Expect(resp.StatusCode).To(Equal(fiber.StatusOK))
+				Expect(resp.Header.Get(HeaderRateLimitLimit)).To(BeEmpty())
+			})
+		})
+	})
+})
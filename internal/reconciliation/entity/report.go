@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"fmt"
+	user_entity "github.com/regiszanandrea/posty/internal/user/entity"
+)
+
+// Discrepancy is a counter of a user whose stored value differs from the one computed from the collections
+type Discrepancy struct {
+	UserID string
+	Field  string
+	Stored int64
+	Actual int64
+}
+
+type Report struct {
+	DryRun        bool
+	Checked       int
+	Fixed         int
+	Discrepancies []*Discrepancy
+}
+
+// Compare returns the discrepancies between the stored and the actual counters of a user
+func Compare(stored, actual *user_entity.Counters) []*Discrepancy {
+	var discrepancies []*Discrepancy
+
+	fields := []struct {
+		name   string
+		stored int64
+		actual int64
+	}{
+		{"followers_count", stored.FollowersCount, actual.FollowersCount},
+		{"following_count", stored.FollowingCount, actual.FollowingCount},
+		{"posts_count", stored.PostsCount, actual.PostsCount},
+	}
+
+	for _, field := range fields {
+		if field.stored != field.actual {
+			discrepancies = append(discrepancies, &Discrepancy{
+				UserID: stored.UserID.Hex(),
+				Field:  field.name,
+				Stored: field.stored,
+				Actual: field.actual,
+			})
+		}
+	}
+
+	return discrepancies
+}
+
+func (report *Report) Summary() string {
+	return fmt.Sprintf(
+		"reconciliation checked %d users, found %d discrepancies and fixed %d users (dry run: %t)",
+		report.Checked,
+		len(report.Discrepancies),
+		report.Fixed,
+		report.DryRun,
+	)
+}
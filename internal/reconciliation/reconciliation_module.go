@@ -0,0 +1,21 @@
+package reconciliation
+
+import (
+	"github.com/regiszanandrea/posty/internal/reconciliation/service"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			Annotate(
+				service.NewReconciliationService,
+				As(new(service.Service)),
+			),
+		),
+	)
+
+	Invokables = Options(
+		Invoke(RegisterWorker),
+	)
+)
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/reconciliation/entity"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	user_entity "github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/regiszanandrea/posty/internal/user/repository/follower"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+)
+
+type Service interface {
+	Reconcile(ctx context.Context, dryRun bool) (*entity.Report, error)
+}
+
+type ReconciliationService struct {
+	userRepository     user_repository.Repository
+	followerRepository follower_repository.Repository
+	postRepository     post_repository.Repository
+	configs            *viper.Viper
+}
+
+func NewReconciliationService(
+	userRepository user_repository.Repository,
+	followerRepository follower_repository.Repository,
+	postRepository post_repository.Repository,
+	configs *viper.Viper,
+) *ReconciliationService {
+	return &ReconciliationService{
+		userRepository:     userRepository,
+		followerRepository: followerRepository,
+		postRepository:     postRepository,
+		configs:            configs,
+	}
+}
+
+// Reconcile recomputes the counters of every user from the followers and posts collections,
+// batch by batch, fixing the ones that drifted unless it is a dry run
+func (service *ReconciliationService) Reconcile(ctx context.Context, dryRun bool) (*entity.Report, error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.Reconcile")
+	defer span.End()
+
+	batchSize := service.configs.GetInt("app.reconciliation.batch-size")
+	report := &entity.Report{DryRun: dryRun}
+	after := ""
+
+	for {
+		stored, err := service.userRepository.GetCounters(ctx, after, batchSize)
+
+		if err != nil {
+			return report, err
+		}
+
+		if len(stored) == 0 {
+			return report, nil
+		}
+
+		actual, err := service.count(ctx, stored)
+
+		if err != nil {
+			return report, err
+		}
+
+		var drifted, fixes []*user_entity.Counters
+
+		for i := range stored {
+			discrepancies := entity.Compare(stored[i], actual[i])
+
+			if len(discrepancies) > 0 {
+				report.Discrepancies = append(report.Discrepancies, discrepancies...)
+				drifted = append(drifted, stored[i])
+				fixes = append(fixes, actual[i])
+			}
+		}
+
+		report.Checked += len(stored)
+
+		if !dryRun {
+			fixed, err := service.userRepository.ReplaceCounters(ctx, drifted, fixes)
+
+			if err != nil {
+				return report, err
+			}
+
+			report.Fixed += fixed
+		}
+
+		if len(stored) < batchSize {
+			return report, nil
+		}
+
+		after = stored[len(stored)-1].UserID.Hex()
+	}
+}
+
+// count returns the actual counters in the same order as the stored ones
+func (service *ReconciliationService) count(ctx context.Context, stored []*user_entity.Counters) ([]*user_entity.Counters, error) {
+	var ids []string
+
+	for _, counters := range stored {
+		ids = append(ids, counters.UserID.Hex())
+	}
+
+	followers, err := service.followerRepository.CountFollowers(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	following, err := service.followerRepository.CountFollowing(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := service.postRepository.CountByUsers(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var actual []*user_entity.Counters
+
+	for _, counters := range stored {
+		id := counters.UserID.Hex()
+
+		actual = append(actual, &user_entity.Counters{
+			UserID:         counters.UserID,
+			FollowersCount: followers[id],
+			FollowingCount: following[id],
+			PostsCount:     posts[id],
+		})
+	}
+
+	return actual, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/test/mocks/follower"
+	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
+	"testing"
+)
+
+func TestReconciliationService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Reconciliation Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *ReconciliationService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("ReconciliationService suite test", func() {
+	Describe("Reconciling counters", func() {
+		Context("when a user counter drifted", func() {
+			It("reports and fixes only this user", func() {
+				userRepository := &user_mock.DriftedCountersUserRepositoryMock{}
+
+				service = NewReconciliationService(
+					userRepository,
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&post_mock.SuccessPostRepositoryMock{},
+					configs,
+				)
+
+				report, err := service.Reconcile(context.Background(), false)
+
+				Expect(err).To(BeNil())
+				Expect(report.Checked).To(Equal(2))
+				Expect(report.Fixed).To(Equal(1))
+				Expect(report.Discrepancies).To(HaveLen(1))
+				Expect(report.Discrepancies[0].Field).To(Equal("followers_count"))
+				Expect(userRepository.Replaced).To(HaveLen(1))
+				Expect(userRepository.Replaced[0].FollowersCount).To(BeEquivalentTo(2))
+			})
+		})
+
+		Context("when it is a dry run", func() {
+			It("reports without fixing", func() {
+				userRepository := &user_mock.DriftedCountersUserRepositoryMock{}
+
+				service = NewReconciliationService(
+					userRepository,
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&post_mock.SuccessPostRepositoryMock{},
+					configs,
+				)
+
+				report, err := service.Reconcile(context.Background(), true)
+
+				Expect(err).To(BeNil())
+				Expect(report.Discrepancies).To(HaveLen(1))
+				Expect(report.Fixed).To(Equal(0))
+				Expect(userRepository.Replaced).To(BeEmpty())
+			})
+		})
+	})
+})
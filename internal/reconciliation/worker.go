@@ -0,0 +1,64 @@
+package reconciliation
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/reconciliation/service"
+	"github.com/spf13/viper"
+	. "go.uber.org/fx"
+	"log"
+	"time"
+)
+
+// RegisterWorker reconciles the users counters every app.reconciliation.interval,
+// it only runs along with the application when app.reconciliation.enabled is set, and
+// stopping the application cancels the run in progress and waits for it
+func RegisterWorker(lifecycle Lifecycle, service service.Service, configs *viper.Viper) {
+	if !configs.GetBool("app.reconciliation.enabled") {
+		return
+	}
+
+	interval := configs.GetDuration("app.reconciliation.interval")
+	dryRun := configs.GetBool("app.reconciliation.dry-run")
+
+	// the run in progress is canceled when the application stops, before the databases are disconnected
+	runCtx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	lifecycle.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				defer close(stopped)
+
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-runCtx.Done():
+						return
+					case <-ticker.C:
+						report, err := service.Reconcile(runCtx, dryRun)
+
+						if err != nil {
+							log.Printf("could not reconcile counters: %v", err)
+						}
+
+						log.Print(report.Summary())
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
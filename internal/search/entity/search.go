@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"encoding/base64"
+	"github.com/go-playground/validator/v10"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Sort string
+
+const (
+	SortRelevance Sort = "relevance"
+	SortRecent    Sort = "recent"
+)
+
+var ErrInvalidDate = apperror.Validation("invalid_date", "dates must be like 2006-01-02 or 2006-01-02T15:04:05Z07:00")
+
+type SearchPostsRequest struct {
+	Query  string `json:"q" query:"q" validate:"required,max=256"`
+	Since  string `json:"since"`
+	Until  string `json:"until"`
+	Sort   string `json:"sort" validate:"omitempty,oneof=relevance recent"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit" validate:"min=0,max=100"`
+}
+
+// SearchUsersRequest completes a username, q is the start of it with or without the "@"
+type SearchUsersRequest struct {
+	Query string `json:"q" query:"q" validate:"required,max=14,alphanum"`
+	Limit int    `json:"limit" validate:"min=0,max=50"`
+}
+
+// Query is the q of a search, words match posts with any of them, "quoted phrases" only
+// posts with all of them and from:username only the posts of that user
+type Query struct {
+	Terms   []string
+	Phrases []string
+	From    string
+}
+
+func ParseQuery(q string) *Query {
+	query := &Query{}
+
+	for i, part := range strings.Split(q, `"`) {
+		// the parts between quotes are phrases, an unclosed quote is a phrase up to the end
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				query.Phrases = append(query.Phrases, phrase)
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(strings.ToLower(word), "from:") {
+				query.From = strings.TrimPrefix(word[len("from:"):], "@")
+				continue
+			}
+
+			query.Terms = append(query.Terms, word)
+		}
+	}
+
+	return query
+}
+
+// Text is the query without its filters, as searched by a text index
+func (query *Query) Text() string {
+	text := strings.Join(query.Terms, " ")
+
+	for _, phrase := range query.Phrases {
+		text += ` "` + strings.ReplaceAll(phrase, `"`, "") + `"`
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// PostSearch is what a search backend looks for, zero values are not filtered
+type PostSearch struct {
+	Text   string
+	UserID primitive.ObjectID
+	Since  time.Time
+	Until  time.Time
+	Sort   Sort
+	// Cursor resumes searches sorted by recency and Offset the ones sorted by relevance
+	Cursor *post_entity.Cursor
+	Offset int
+	Limit  int
+}
+
+// ParseDate accepts a day, which starts at midnight UTC, or a time in RFC 3339
+func ParseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	if day, err := time.Parse("2006-01-02", date); err == nil {
+		return day, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return t, nil
+}
+
+// EncodeOffsetCursor is the cursor of searches by relevance, which have no stable key to resume from
+func EncodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func DecodeOffsetCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, post_entity.ErrInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+
+	if err != nil || offset < 0 {
+		return 0, post_entity.ErrInvalidCursor
+	}
+
+	return offset, nil
+}
+
+var validate = validator.New()
+
+func ValidateStruct(st interface{}) []error {
+	var errs []error
+
+	err := validate.Struct(st)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
+		}
+	}
+
+	return errs
+}
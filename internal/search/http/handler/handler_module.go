@@ -0,0 +1,12 @@
+package handler
+
+import (
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Provide(
+		NewPostsSearcherHandler,
+		NewUsersSearcherHandler,
+	)
+)
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/regiszanandrea/posty/internal/search/service"
+)
+
+type PostsSearcherHandler struct {
+	service service.Service
+}
+
+func NewPostsSearcherHandler(s service.Service) *PostsSearcherHandler {
+	return &PostsSearcherHandler{
+		service: s,
+	}
+}
+
+func (h *PostsSearcherHandler) SearchPosts(ctx *fiber.Ctx) error {
+	search := new(entity.SearchPostsRequest)
+
+	if err := ctx.QueryParser(search); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	posts, errors := h.service.SearchPosts(ctx.UserContext(), search)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(posts)
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/regiszanandrea/posty/internal/search/service"
+)
+
+type UsersSearcherHandler struct {
+	service service.Service
+}
+
+func NewUsersSearcherHandler(s service.Service) *UsersSearcherHandler {
+	return &UsersSearcherHandler{
+		service: s,
+	}
+}
+
+func (h *UsersSearcherHandler) SearchUsers(ctx *fiber.Ctx) error {
+	search := new(entity.SearchUsersRequest)
+
+	if err := ctx.QueryParser(search); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	users, errors := h.service.SearchUsers(ctx.UserContext(), search)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(users)
+}
@@ -0,0 +1,11 @@
+package http
+
+import (
+	. "go.uber.org/fx"
+)
+
+var (
+	Invokables = Invoke(
+		RegisterSearchRoutes,
+	)
+)
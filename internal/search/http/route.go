@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/ratelimit"
+	"github.com/regiszanandrea/posty/internal/search/http/handler"
+	"github.com/regiszanandrea/posty/internal/timeout"
+)
+
+func RegisterSearchRoutes(
+	app *fiber.App,
+	postsSearcherHandler *handler.PostsSearcherHandler,
+	usersSearcherHandler *handler.UsersSearcherHandler,
+	timeoutMiddleware *timeout.Middleware,
+	rateLimitMiddleware *ratelimit.Middleware,
+) {
+	group := app.Group("/search")
+
+	group.Get("/posts", timeoutMiddleware.Handle("search-posts"), rateLimitMiddleware.Handle("search"), postsSearcherHandler.SearchPosts)
+	group.Get("/users", timeoutMiddleware.Handle("search-users"), rateLimitMiddleware.Handle("search"), usersSearcherHandler.SearchUsers)
+}
@@ -0,0 +1,151 @@
+package search_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MemoryRepository searches the posts of a memory.Database as the text index of the
+// MongoDBRepository does: words match posts with any of them, "-words" exclude posts,
+// and when there are phrases only the posts with all of them match
+type MemoryRepository struct {
+	database *memory.Database
+}
+
+func NewMemoryRepository(database *memory.Database) *MemoryRepository {
+	return &MemoryRepository{
+		database: database,
+	}
+}
+
+type scoredPost struct {
+	post  *post_entity.Post
+	score int
+}
+
+func (repo *MemoryRepository) SearchPosts(ctx context.Context, search *entity.PostSearch) ([]string, error) {
+	terms, excluded, phrases := parseText(search.Text)
+
+	repo.database.RLock()
+
+	var found []*scoredPost
+
+	for _, post := range repo.database.Posts {
+		if post.DeletedAt != nil ||
+			!search.UserID.IsZero() && post.UserID != search.UserID ||
+			!search.Since.IsZero() && post.CreatedAt.Before(search.Since) ||
+			!search.Until.IsZero() && !post.CreatedAt.Before(search.Until) {
+			continue
+		}
+
+		if search.Text == "" {
+			found = append(found, &scoredPost{post: post})
+			continue
+		}
+
+		if score, ok := match(post.Content, terms, excluded, phrases); ok {
+			found = append(found, &scoredPost{post: post, score: score})
+		}
+	}
+
+	repo.database.RUnlock()
+
+	relevance := search.Sort == entity.SortRelevance && search.Text != ""
+
+	sort.Slice(found, func(i, j int) bool {
+		if relevance && found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+
+		if !found[i].post.CreatedAt.Equal(found[j].post.CreatedAt) {
+			return found[i].post.CreatedAt.After(found[j].post.CreatedAt)
+		}
+
+		return memory.CompareIDs(found[i].post.ID, found[j].post.ID) > 0
+	})
+
+	var ids []string
+
+	for i, scored := range found {
+		if relevance && i < search.Offset {
+			continue
+		}
+
+		if !relevance && search.Cursor != nil && !(scored.post.CreatedAt.Before(search.Cursor.CreatedAt) ||
+			scored.post.CreatedAt.Equal(search.Cursor.CreatedAt) && memory.CompareIDs(scored.post.ID, search.Cursor.ID) < 0) {
+			continue
+		}
+
+		if len(ids) == search.Limit {
+			break
+		}
+
+		ids = append(ids, scored.post.ID.Hex())
+	}
+
+	return ids, nil
+}
+
+// parseText splits the $search string of a text index into its words, the words excluded and the phrases
+func parseText(text string) (terms, excluded, phrases []string) {
+	for i, part := range strings.Split(strings.ToLower(text), `"`) {
+		if i%2 == 1 {
+			if phrase := strings.Join(words(part), " "); phrase != "" {
+				phrases = append(phrases, phrase)
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			if strings.HasPrefix(field, "-") {
+				excluded = append(excluded, words(field)...)
+				continue
+			}
+
+			terms = append(terms, words(field)...)
+		}
+	}
+
+	return terms, excluded, phrases
+}
+
+// match scores content by how many times the words and phrases appear on it
+func match(content string, terms, excluded, phrases []string) (int, bool) {
+	contentWords := words(strings.ToLower(content))
+	text := " " + strings.Join(contentWords, " ") + " "
+
+	for _, word := range excluded {
+		if strings.Contains(text, " "+word+" ") {
+			return 0, false
+		}
+	}
+
+	score := 0
+
+	for _, phrase := range phrases {
+		count := strings.Count(text, " "+phrase+" ")
+
+		if count == 0 {
+			return 0, false
+		}
+
+		score += count
+	}
+
+	for _, term := range terms {
+		score += strings.Count(text, " "+term+" ")
+	}
+
+	return score, score > 0
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
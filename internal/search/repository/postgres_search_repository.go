@@ -0,0 +1,106 @@
+package search_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"strings"
+)
+
+// PostgresRepository searches the posts table with the full text index on its content, matching
+// as the text index of the MongoDBRepository does: words match posts with any of them, "-words"
+// exclude posts, and when there are phrases only the posts with all of them match
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{
+		db: db,
+	}
+}
+
+func (repo *PostgresRepository) SearchPosts(ctx context.Context, search *entity.PostSearch) ([]string, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	relevance := search.Sort == entity.SortRelevance && search.Text != ""
+	order := "created_at DESC, id DESC"
+
+	if search.Text != "" {
+		match, rank := tsQueries(search.Text)
+
+		if match == "" {
+			return nil, nil
+		}
+
+		conditions = append(conditions, "to_tsvector('simple', content) @@ to_tsquery('simple', "+arg(match)+")")
+
+		if relevance {
+			order = "ts_rank(to_tsvector('simple', content), to_tsquery('simple', " + arg(rank) + ")) DESC, " + order
+		}
+	}
+
+	if !search.UserID.IsZero() {
+		conditions = append(conditions, "user_id = "+arg(search.UserID.Hex()))
+	}
+
+	if !search.Since.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(postgres.Time(search.Since)))
+	}
+
+	if !search.Until.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(postgres.Time(search.Until)))
+	}
+
+	if !relevance && search.Cursor != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(created_at, id) < (%s, %s)", arg(postgres.Time(search.Cursor.CreatedAt)), arg(search.Cursor.ID.Hex()),
+		))
+	}
+
+	query := "SELECT id FROM posts WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + order +
+		" LIMIT " + arg(search.Limit)
+
+	if relevance {
+		query += " OFFSET " + arg(search.Offset)
+	}
+
+	return postgres.QueryIDs(ctx, repo.db, query, args...)
+}
+
+// tsQueries returns the tsquery that matches the $search string of a text index and the one
+// that ranks the matches by all of its words and phrases, match is empty when nothing can match
+func tsQueries(text string) (match, rank string) {
+	terms, excluded, phrases := parseText(text)
+
+	var phraseQueries []string
+
+	for _, phrase := range phrases {
+		phraseQueries = append(phraseQueries, "("+strings.Join(strings.Fields(phrase), " <-> ")+")")
+	}
+
+	rank = strings.Join(append(append([]string{}, terms...), phraseQueries...), " | ")
+
+	if len(phraseQueries) > 0 {
+		match = strings.Join(phraseQueries, " & ")
+	} else if len(terms) > 0 {
+		match = "(" + strings.Join(terms, " | ") + ")"
+	} else {
+		return "", ""
+	}
+
+	for _, word := range excluded {
+		match += " & !" + word
+	}
+
+	return match, rank
+}
@@ -0,0 +1,136 @@
+package search_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository is a search backend, it returns the ids of the posts found in order
+// and the posts themselves are read from the posts repository
+type Repository interface {
+	SearchPosts(ctx context.Context, search *entity.PostSearch) ([]string, error)
+}
+
+// NewRepository returns the backend set on app.search.backend, storage searches
+// the posts where app.storage.driver keeps them
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	backend := configs.GetString("app.search.backend")
+
+	if backend == "storage" {
+		backend = configs.GetString("app.storage.driver")
+	}
+
+	switch backend {
+	case "mongodb":
+		return NewMongoDBRepository(client, configs), nil
+	case "memory":
+		return NewMemoryRepository(database), nil
+	case "postgres":
+		return NewPostgresRepository(db), nil
+	default:
+		return nil, fmt.Errorf("search: unknown backend %q", backend)
+	}
+}
+
+// MongoDBRepository searches the text index on the content of the posts collection
+type MongoDBRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDBRepository(client *mongo.Client, configs *viper.Viper) *MongoDBRepository {
+	postsCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.post-collection"),
+	)
+
+	return &MongoDBRepository{
+		collection: postsCollection,
+	}
+}
+
+func (repo *MongoDBRepository) SearchPosts(ctx context.Context, search *entity.PostSearch) ([]string, error) {
+	filter := bson.D{{"deleted_at", bson.D{{"$exists", false}}}}
+
+	if search.Text != "" {
+		filter = append(filter, bson.E{"$text", bson.D{{"$search", search.Text}}})
+	}
+
+	if !search.UserID.IsZero() {
+		filter = append(filter, bson.E{"user_id", search.UserID})
+	}
+
+	createdAt := bson.D{}
+
+	if !search.Since.IsZero() {
+		createdAt = append(createdAt, bson.E{"$gte", search.Since})
+	}
+
+	if !search.Until.IsZero() {
+		createdAt = append(createdAt, bson.E{"$lt", search.Until})
+	}
+
+	if len(createdAt) > 0 {
+		filter = append(filter, bson.E{"created_at", createdAt})
+	}
+
+	opts := options.Find().SetLimit(int64(search.Limit))
+
+	if search.Sort == entity.SortRelevance && search.Text != "" {
+		score := bson.D{{"$meta", "textScore"}}
+
+		opts.
+			SetProjection(bson.D{{"_id", 1}, {"score", score}}).
+			SetSort(bson.D{{"score", score}, {"created_at", -1}, {"_id", -1}}).
+			SetSkip(int64(search.Offset))
+	} else {
+		opts.
+			SetProjection(bson.D{{"_id", 1}}).
+			SetSort(bson.D{{"created_at", -1}, {"_id", -1}})
+
+		if search.Cursor != nil {
+			filter = append(filter, bson.E{
+				"$or",
+				bson.A{
+					bson.D{{"created_at", bson.D{{"$lt", search.Cursor.CreatedAt}}}},
+					bson.D{
+						{"created_at", search.Cursor.CreatedAt},
+						{"_id", bson.D{{"$lt", search.Cursor.ID}}},
+					},
+				},
+			})
+		}
+	}
+
+	curr, err := repo.collection.Find(ctx, filter, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var post struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+
+		if err := curr.Decode(&post); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, post.ID.Hex())
+	}
+
+	return ids, curr.Err()
+}
@@ -0,0 +1,202 @@
+package search_repository
+
+import (
+	"context"
+	"database/sql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+	"time"
+)
+
+func TestSearchRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SearchRepository Suite")
+}
+
+var (
+	searchRepository Repository
+	db               *sql.DB
+	client           *mongo.Client
+	database         *memory.Database
+	configs          *viper.Viper
+	postsCollection  *mongo.Collection
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+
+	client = mongodb.NewMongoDBClient(configs)
+
+	// the suite runs against the posts of the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		err = mongodb.CreateIndexes(client, configs, context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	postsCollection = client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.post-collection"),
+	)
+
+	database = memory.NewDatabase()
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	searchRepository, err = NewRepository(client, db, database, configs)
+
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = AfterSuite(func() {
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE posts")
+
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
+	_, err := postsCollection.DeleteMany(context.Background(), bson.M{})
+
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = Describe("SearchRepository suite test", func() {
+	Describe("Searching posts", func() {
+		Context("when its given words", func() {
+			It("finds the posts with any of them, the most relevant first", func() {
+				word := "word" + primitive.NewObjectID().Hex()
+
+				once := createPost(primitive.NewObjectID(), word+" once", time.Now())
+				twice := createPost(primitive.NewObjectID(), word+" and "+word+" twice", time.Now())
+				_ = createPost(primitive.NewObjectID(), "nothing to see", time.Now())
+
+				ids, err := searchRepository.SearchPosts(context.Background(), &entity.PostSearch{
+					Text:  word,
+					Sort:  entity.SortRelevance,
+					Limit: 10,
+				})
+
+				Expect(err).To(BeNil())
+				Expect(ids).To(Equal([]string{twice, once}))
+			})
+		})
+
+		Context("when its given a phrase", func() {
+			It("finds only the posts with the whole phrase", func() {
+				word := "word" + primitive.NewObjectID().Hex()
+
+				phrase := createPost(primitive.NewObjectID(), word+" text index", time.Now())
+				_ = createPost(primitive.NewObjectID(), word+" index of text", time.Now())
+
+				ids, err := searchRepository.SearchPosts(context.Background(), &entity.PostSearch{
+					Text:  `"` + word + ` text index"`,
+					Sort:  entity.SortRelevance,
+					Limit: 10,
+				})
+
+				Expect(err).To(BeNil())
+				Expect(ids).To(Equal([]string{phrase}))
+			})
+		})
+
+		Context("when its given an author and a date range", func() {
+			It("finds the posts of the author on the range from the newest", func() {
+				user := primitive.NewObjectID()
+				now := time.Now()
+
+				_ = createPost(user, "too old", now.Add(-48*time.Hour))
+				older := createPost(user, "older", now.Add(-2*time.Hour))
+				newer := createPost(user, "newer", now.Add(-time.Hour))
+				_ = createPost(primitive.NewObjectID(), "from someone else", now.Add(-time.Hour))
+
+				ids, err := searchRepository.SearchPosts(context.Background(), &entity.PostSearch{
+					UserID: user,
+					Since:  now.Add(-24 * time.Hour),
+					Until:  now,
+					Sort:   entity.SortRecent,
+					Limit:  10,
+				})
+
+				Expect(err).To(BeNil())
+				Expect(ids).To(Equal([]string{newer, older}))
+			})
+		})
+	})
+})
+
+// createPost stores the post with the given creation time, which the repositories of the posts set to now
+func createPost(user primitive.ObjectID, content string, createdAt time.Time) string {
+	post := &post_entity.Post{
+		ID:        primitive.NewObjectID(),
+		UserID:    user,
+		Content:   content,
+		CreatedAt: memory.Time(createdAt),
+	}
+
+	if postgres.IsStorage(configs) {
+		_, _ = db.Exec(
+			"INSERT INTO posts (id, user_id, content, created_at) VALUES ($1, $2, $3, $4)",
+			post.ID.Hex(), post.UserID.Hex(), post.Content, post.CreatedAt,
+		)
+
+		return post.ID.Hex()
+	}
+
+	if !mongodb.IsStorage(configs) {
+		database.Lock()
+		database.Posts[post.ID] = post
+		database.Unlock()
+
+		return post.ID.Hex()
+	}
+
+	_, _ = postsCollection.InsertOne(context.Background(), post)
+
+	return post.ID.Hex()
+}
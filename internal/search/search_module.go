@@ -0,0 +1,27 @@
+package search
+
+import (
+	"github.com/regiszanandrea/posty/internal/search/http"
+	"github.com/regiszanandrea/posty/internal/search/http/handler"
+	"github.com/regiszanandrea/posty/internal/search/repository"
+	"github.com/regiszanandrea/posty/internal/search/service"
+
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			search_repository.NewRepository,
+			Annotate(
+				service.NewSearchService,
+				As(new(service.Service)),
+			),
+		),
+		handler.Module,
+	)
+
+	Invokables = Options(
+		http.Invokables,
+	)
+)
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	post_repository "github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	search_repository "github.com/regiszanandrea/posty/internal/search/repository"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	user_entity "github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+type Service interface {
+	SearchPosts(ctx context.Context, searchPostsRequest *entity.SearchPostsRequest) (*post_entity.PostList, []error)
+	SearchUsers(ctx context.Context, searchUsersRequest *entity.SearchUsersRequest) (*user_entity.UserList, []error)
+}
+
+type SearchService struct {
+	searchRepository search_repository.Repository
+	postRepository   post_repository.Repository
+	userRepository   user_repository.Repository
+	configs          *viper.Viper
+}
+
+func NewSearchService(
+	searchRepository search_repository.Repository,
+	postRepository post_repository.Repository,
+	userRepository user_repository.Repository,
+	configs *viper.Viper,
+) *SearchService {
+	return &SearchService{
+		searchRepository: searchRepository,
+		postRepository:   postRepository,
+		userRepository:   userRepository,
+		configs:          configs,
+	}
+}
+
+// SearchPosts sorts by relevance when the query has words to search and by recency otherwise,
+// a search from a user that does not exist finds nothing
+func (service *SearchService) SearchPosts(ctx context.Context, searchPostsRequest *entity.SearchPostsRequest) (*post_entity.PostList, []error) {
+	ctx, span := tracing.Start(ctx, "SearchService.SearchPosts")
+	defer span.End()
+
+	errs := entity.ValidateStruct(searchPostsRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	query := entity.ParseQuery(searchPostsRequest.Query)
+
+	search, err := service.newPostSearch(searchPostsRequest, query)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	if query.From != "" {
+		user, err := service.userRepository.FindByUsername(ctx, query.From)
+
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		if user == nil {
+			return post_entity.NewPostList(nil, search.Limit), nil
+		}
+
+		search.UserID = user.ID
+	}
+
+	ids, err := service.searchRepository.SearchPosts(ctx, search)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	posts, err := service.postRepository.GetByIDs(ctx, ids)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	posts = inOrder(posts, ids)
+
+	list := post_entity.NewPostList(posts, search.Limit)
+
+	// a page is full when the backend found limit posts, even if some were deleted since
+	list.NextCursor = ""
+
+	if len(ids) == search.Limit && len(posts) > 0 {
+		if search.Sort == entity.SortRelevance {
+			list.NextCursor = entity.EncodeOffsetCursor(search.Offset + search.Limit)
+		} else {
+			list.NextCursor = post_entity.NewCursor(posts[len(posts)-1])
+		}
+	}
+
+	return list, nil
+}
+
+// SearchUsers ranks the user with exactly the username searched first, then the
+// users whose username starts with it by their number of followers
+func (service *SearchService) SearchUsers(ctx context.Context, searchUsersRequest *entity.SearchUsersRequest) (*user_entity.UserList, []error) {
+	ctx, span := tracing.Start(ctx, "SearchService.SearchUsers")
+	defer span.End()
+
+	searchUsersRequest.Query = strings.TrimPrefix(searchUsersRequest.Query, "@")
+
+	errs := entity.ValidateStruct(searchUsersRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	limit := searchUsersRequest.Limit
+
+	if limit == 0 {
+		limit = service.configs.GetInt("app.search.users-limit")
+	}
+
+	users, err := service.userRepository.FindByUsernamePrefix(ctx, searchUsersRequest.Query, limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// the exact match may have too few followers to be among the first ones
+	exact, err := service.userRepository.FindByUsername(ctx, searchUsersRequest.Query)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	if exact == nil {
+		return user_entity.NewUserSummaryList(users), nil
+	}
+
+	ranked := []*user_entity.UserSummary{{
+		ID:             exact.ID,
+		Username:       exact.Username,
+		FollowersCount: exact.FollowersCount,
+		FollowingCount: exact.FollowingCount,
+	}}
+
+	for _, user := range users {
+		if user.Username != exact.Username && len(ranked) < limit {
+			ranked = append(ranked, user)
+		}
+	}
+
+	return user_entity.NewUserSummaryList(ranked), nil
+}
+
+func (service *SearchService) newPostSearch(searchPostsRequest *entity.SearchPostsRequest, query *entity.Query) (*entity.PostSearch, error) {
+	var err error
+
+	search := &entity.PostSearch{
+		Text:  query.Text(),
+		Sort:  entity.Sort(searchPostsRequest.Sort),
+		Limit: searchPostsRequest.Limit,
+	}
+
+	if search.Limit == 0 {
+		search.Limit = service.configs.GetInt("app.search.posts-limit")
+	}
+
+	if search.Text == "" {
+		search.Sort = entity.SortRecent
+	} else if search.Sort == "" {
+		search.Sort = entity.SortRelevance
+	}
+
+	if search.Since, err = entity.ParseDate(searchPostsRequest.Since); err != nil {
+		return nil, err
+	}
+
+	if search.Until, err = entity.ParseDate(searchPostsRequest.Until); err != nil {
+		return nil, err
+	}
+
+	if searchPostsRequest.Cursor != "" {
+		if search.Sort == entity.SortRelevance {
+			search.Offset, err = entity.DecodeOffsetCursor(searchPostsRequest.Cursor)
+		} else {
+			search.Cursor, err = post_entity.DecodeCursor(searchPostsRequest.Cursor)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return search, nil
+}
+
+// inOrder sorts posts as ids, the posts repository returns them by recency
+func inOrder(posts []*post_entity.Post, ids []string) []*post_entity.Post {
+	byID := make(map[string]*post_entity.Post, len(posts))
+
+	for _, post := range posts {
+		byID[post.ID.Hex()] = post
+	}
+
+	var ordered []*post_entity.Post
+
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/search"
+	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
+	"testing"
+	"time"
+)
+
+func TestSearchService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Search Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *SearchService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("SearchService suite test", func() {
+	var searchRepository *search_mock.SuccessSearchRepositoryMock
+
+	BeforeEach(func() {
+		searchRepository = &search_mock.SuccessSearchRepositoryMock{Found: 2}
+
+		service = NewSearchService(
+			searchRepository,
+			&post_mock.SuccessPostRepositoryMock{},
+			&user_mock.SuccessUserRepositoryMock{},
+			configs,
+		)
+	})
+
+	Describe("Searching posts", func() {
+		Context("when its given words, a phrase and an author", func() {
+			It("searches the text of the posts of the author by relevance", func() {
+				posts, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{
+					Query: `golang "text index" from:@test`,
+					Limit: 2,
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(posts.Data).To(HaveLen(2))
+				Expect(searchRepository.Search.Text).To(Equal(`golang "text index"`))
+				Expect(searchRepository.Search.UserID.IsZero()).To(BeFalse())
+				Expect(searchRepository.Search.Sort).To(Equal(entity.SortRelevance))
+				Expect(posts.NextCursor).To(Equal(entity.EncodeOffsetCursor(2)))
+			})
+		})
+
+		Context("when its given only an author", func() {
+			It("searches the posts of the author by recency", func() {
+				posts, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{
+					Query: "from:test",
+					Sort:  "relevance",
+					Limit: 2,
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(searchRepository.Search.Text).To(BeEmpty())
+				Expect(searchRepository.Search.Sort).To(Equal(entity.SortRecent))
+				Expect(posts.NextCursor).To(Equal(post_entity.NewCursor(posts.Data[1])))
+			})
+		})
+
+		Context("when its given a date range", func() {
+			It("searches the posts created on it", func() {
+				_, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{
+					Query: "golang",
+					Since: "2022-03-01",
+					Until: "2022-03-08T12:00:00Z",
+				})
+
+				Expect(errors).To(BeNil())
+				Expect(searchRepository.Search.Since).To(Equal(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)))
+				Expect(searchRepository.Search.Until).To(Equal(time.Date(2022, 3, 8, 12, 0, 0, 0, time.UTC)))
+			})
+		})
+
+		Context("when its given an invalid date", func() {
+			It("returns error", func() {
+				_, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{
+					Query: "golang",
+					Since: "yesterday",
+				})
+
+				Expect(errors[0]).To(Equal(entity.ErrInvalidDate))
+			})
+		})
+
+		Context("when its given an author that does not exist", func() {
+			It("finds nothing", func() {
+				service = NewSearchService(
+					searchRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&user_mock.NotFoundUserRepositoryMock{},
+					configs,
+				)
+
+				posts, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{Query: "golang from:nobody"})
+
+				Expect(errors).To(BeNil())
+				Expect(posts.Data).To(BeEmpty())
+				Expect(searchRepository.Search).To(BeNil())
+			})
+		})
+
+		Context("when its given an empty query", func() {
+			It("returns error", func() {
+				_, errors := service.SearchPosts(context.Background(), &entity.SearchPostsRequest{})
+
+				Expect(errors).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Searching users", func() {
+		Context("when its given the start of a username", func() {
+			It("ranks the exact username first and then the most followed", func() {
+				users, errors := service.SearchUsers(context.Background(), &entity.SearchUsersRequest{Query: "@alice", Limit: 2})
+
+				Expect(errors).To(BeNil())
+				Expect(users.Data).To(HaveLen(2))
+				Expect(users.Data[0].Username).To(Equal("alice"))
+				Expect(users.Data[1].Username).To(Equal("alicepopular"))
+			})
+		})
+
+		Context("when its given a query that can not be a username", func() {
+			It("returns error", func() {
+				_, errors := service.SearchUsers(context.Background(), &entity.SearchUsersRequest{Query: "alice smith"})
+
+				Expect(errors).To(HaveLen(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,303 @@
+package seed
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/bxcodec/faker/v3"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// hashtagRatio and mentionRatio are the share of the posts with content that have a hashtag and a mention
+	hashtagRatio = 0.2
+	mentionRatio = 0.1
+	// recentPosts is how many of the last posts with content can be reposted or quoted
+	recentPosts = 1000
+)
+
+var hashtags = []string{
+	"golang", "mongodb", "postgres", "news", "music", "sports", "travel", "food", "movies", "books",
+	"tech", "science", "art", "photography", "gaming", "weekend", "coffee", "fitness", "nature", "posty",
+}
+
+type generatedUser struct {
+	id             primitive.ObjectID
+	createdAt      time.Time
+	followersCount uint
+	followingCount uint
+}
+
+// Generator generates the users, their followers and their posts from a random source seeded
+// with Options.Seed, which also seeds faker, so two generators must not run at the same time.
+// Every user has the same password, its hash is the only value that differs between runs
+type Generator struct {
+	options      *Options
+	random       *rand.Rand
+	start        time.Time
+	passwordHash string
+	users        []generatedUser
+}
+
+func NewGenerator(options *Options) (*Generator, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	generator := &Generator{
+		options: options,
+		random:  rand.New(rand.NewSource(options.Seed)),
+		start:   options.Until.Add(-options.Spread),
+	}
+
+	faker.SetRandomSource(rand.NewSource(options.Seed))
+
+	if options.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(options.Password), bcrypt.DefaultCost)
+
+		if err != nil {
+			return nil, err
+		}
+
+		generator.passwordHash = string(hash)
+	}
+
+	generator.users = make([]generatedUser, options.Users)
+
+	for i := range generator.users {
+		createdAt := at(generator.start.Add(-time.Duration(generator.random.Int63n(int64(options.Spread)))))
+
+		generator.users[i] = generatedUser{
+			id:        generator.objectID(createdAt),
+			createdAt: createdAt,
+		}
+	}
+
+	return generator, nil
+}
+
+// Followers generates the follows of every user and counts them on the users, so it
+// must be called before Users
+func (generator *Generator) Followers(write func(followers []*entity.Follower) error) error {
+	var zipf *rand.Zipf
+	var ranks []int
+
+	if generator.options.Distribution == DistributionPowerLaw && len(generator.users) > 1 {
+		zipf = rand.NewZipf(generator.random, generator.options.Exponent, 1, uint64(len(generator.users)-1))
+		// the most followed users are not the first ones created
+		ranks = generator.random.Perm(len(generator.users))
+	}
+
+	batch := make([]*entity.Follower, 0, generator.options.BatchSize)
+
+	for i := range generator.users {
+		follower := &generator.users[i]
+
+		for _, j := range generator.following(i, zipf, ranks) {
+			following := &generator.users[j]
+
+			// a user follows another after both exist and before the first post
+			since := follower.createdAt
+
+			if following.createdAt.After(since) {
+				since = following.createdAt
+			}
+
+			createdAt := at(since.Add(time.Duration(generator.random.Int63n(int64(generator.start.Sub(since)) + 1))))
+
+			batch = append(batch, &entity.Follower{
+				ID:          generator.objectID(createdAt),
+				FollowerID:  follower.id,
+				FollowingID: following.id,
+				CreatedAt:   createdAt,
+			})
+
+			follower.followingCount++
+			following.followersCount++
+
+			if len(batch) == generator.options.BatchSize {
+				if err := write(batch); err != nil {
+					return err
+				}
+
+				batch = make([]*entity.Follower, 0, generator.options.BatchSize)
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		return write(batch)
+	}
+
+	return nil
+}
+
+// following picks the users the i-th user follows, on average Options.Follows of them. Popular
+// users may be picked again on the power-law distribution, so the user may follow fewer
+func (generator *Generator) following(i int, zipf *rand.Zipf, ranks []int) []int {
+	count := generator.random.Intn(2*generator.options.Follows + 1)
+
+	if count > len(generator.users)-1 {
+		count = len(generator.users) - 1
+	}
+
+	var picked []int
+
+	seen := map[int]bool{i: true}
+
+	for attempts := 0; len(picked) < count && attempts < 10*count; attempts++ {
+		var j int
+
+		if zipf != nil {
+			j = ranks[zipf.Uint64()]
+		} else {
+			j = generator.random.Intn(len(generator.users))
+		}
+
+		if !seen[j] {
+			seen[j] = true
+			picked = append(picked, j)
+		}
+	}
+
+	return picked
+}
+
+// Users generates the users with the counters of the followers generated, the ones with
+// Options.PulledFollowers or more are pulled as if their followers had been followed one by one
+func (generator *Generator) Users(write func(users []*entity.User) error) error {
+	batch := make([]*entity.User, 0, generator.options.BatchSize)
+
+	for i, user := range generator.users {
+		batch = append(batch, &entity.User{
+			ID:             user.id,
+			Username:       username(i),
+			PasswordHash:   generator.passwordHash,
+			CreatedAt:      user.createdAt,
+			FollowersCount: user.followersCount,
+			FollowingCount: user.followingCount,
+			PostsCount:     uint(generator.options.PostsPerUser),
+			Pulled:         user.followersCount >= generator.options.PulledFollowers,
+		})
+
+		if len(batch) == generator.options.BatchSize {
+			if err := write(batch); err != nil {
+				return err
+			}
+
+			batch = make([]*entity.User, 0, generator.options.BatchSize)
+		}
+	}
+
+	if len(batch) > 0 {
+		return write(batch)
+	}
+
+	return nil
+}
+
+// Posts generates Options.PostsPerUser posts for every user from the oldest, spread evenly
+// along Options.Spread. Reposts and quotes reference one of the last posts with content
+func (generator *Generator) Posts(write func(posts []*post_entity.Post) error) error {
+	total := float64(len(generator.users) * generator.options.PostsPerUser)
+	recent := make([]primitive.ObjectID, 0, recentPosts)
+	created := 0
+
+	batch := make([]*post_entity.Post, 0, generator.options.BatchSize)
+
+	for round := 0; round < generator.options.PostsPerUser; round++ {
+		for _, i := range generator.random.Perm(len(generator.users)) {
+			offset := (float64(created) + generator.random.Float64()) / total * float64(generator.options.Spread)
+			createdAt := at(generator.start.Add(time.Duration(offset)))
+
+			post := &post_entity.Post{
+				ID:        generator.objectID(createdAt),
+				UserID:    generator.users[i].id,
+				CreatedAt: createdAt,
+			}
+
+			kind := generator.random.Float64()
+
+			switch {
+			case len(recent) > 0 && kind < generator.options.RepostRatio:
+				post.ParentID = recent[generator.random.Intn(len(recent))]
+			case len(recent) > 0 && kind < generator.options.RepostRatio+generator.options.QuoteRatio:
+				post.ParentID = recent[generator.random.Intn(len(recent))]
+				generator.content(post)
+			default:
+				generator.content(post)
+			}
+
+			if post.Content != "" {
+				if len(recent) < recentPosts {
+					recent = append(recent, post.ID)
+				} else {
+					recent[created%recentPosts] = post.ID
+				}
+			}
+
+			created++
+
+			batch = append(batch, post)
+
+			if len(batch) == generator.options.BatchSize {
+				if err := write(batch); err != nil {
+					return err
+				}
+
+				batch = make([]*post_entity.Post, 0, generator.options.BatchSize)
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		return write(batch)
+	}
+
+	return nil
+}
+
+// content writes a sentence on post, sometimes with a hashtag and a mention, which are set as the service sets them
+func (generator *Generator) content(post *post_entity.Post) {
+	post.Content = faker.Sentence()
+
+	if generator.random.Float64() < hashtagRatio {
+		post.Content += " #" + hashtags[generator.random.Intn(len(hashtags))]
+	}
+
+	if generator.random.Float64() < mentionRatio {
+		i := generator.random.Intn(len(generator.users))
+		post.Content += " @" + username(i)
+
+		post.Mentions = post_entity.ExtractMentions(post.Content)
+
+		for _, mention := range post.Mentions {
+			mention.UserID = generator.users[i].id
+		}
+	}
+
+	post.Hashtags = post_entity.ExtractHashtags(post.Content)
+}
+
+// objectID is an ObjectID of the given time with its other bytes taken from the random source
+func (generator *Generator) objectID(t time.Time) primitive.ObjectID {
+	var id primitive.ObjectID
+
+	binary.BigEndian.PutUint32(id[0:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint64(id[4:12], generator.random.Uint64())
+
+	return id
+}
+
+// at truncates t to milliseconds, the precision both databases keep
+func at(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func username(i int) string {
+	return fmt.Sprintf("user%d", i+1)
+}
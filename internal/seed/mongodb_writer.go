@@ -0,0 +1,63 @@
+package seed
+
+import (
+	"context"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDBWriter inserts every batch with a single unordered insertMany
+type MongoDBWriter struct {
+	usersCollection     *mongo.Collection
+	followersCollection *mongo.Collection
+	postsCollection     *mongo.Collection
+}
+
+func NewMongoDBWriter(client *mongo.Client, configs *viper.Viper) *MongoDBWriter {
+	database := client.Database(configs.GetString("app.mongodb.database"))
+
+	return &MongoDBWriter{
+		usersCollection:     database.Collection(configs.GetString("app.mongodb.user-collection")),
+		followersCollection: database.Collection(configs.GetString("app.mongodb.follower-collection")),
+		postsCollection:     database.Collection(configs.GetString("app.mongodb.post-collection")),
+	}
+}
+
+func (writer *MongoDBWriter) WriteUsers(ctx context.Context, users []*entity.User) error {
+	documents := make([]interface{}, len(users))
+
+	for i, user := range users {
+		documents[i] = user
+	}
+
+	return writer.insert(ctx, writer.usersCollection, documents)
+}
+
+func (writer *MongoDBWriter) WriteFollowers(ctx context.Context, followers []*entity.Follower) error {
+	documents := make([]interface{}, len(followers))
+
+	for i, follower := range followers {
+		documents[i] = follower
+	}
+
+	return writer.insert(ctx, writer.followersCollection, documents)
+}
+
+func (writer *MongoDBWriter) WritePosts(ctx context.Context, posts []*post_entity.Post) error {
+	documents := make([]interface{}, len(posts))
+
+	for i, post := range posts {
+		documents[i] = post
+	}
+
+	return writer.insert(ctx, writer.postsCollection, documents)
+}
+
+func (writer *MongoDBWriter) insert(ctx context.Context, collection *mongo.Collection, documents []interface{}) error {
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+
+	return err
+}
@@ -0,0 +1,130 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+)
+
+// PostgresWriter copies every batch with COPY FROM STDIN in its own transaction
+type PostgresWriter struct {
+	db *sql.DB
+}
+
+func NewPostgresWriter(db *sql.DB) *PostgresWriter {
+	return &PostgresWriter{
+		db: db,
+	}
+}
+
+// storedMention is a mention as the PostgresPostRepository keeps it on the mentions column
+type storedMention struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+func (writer *PostgresWriter) WriteUsers(ctx context.Context, users []*entity.User) error {
+	rows := make([][]interface{}, len(users))
+
+	for i, user := range users {
+		rows[i] = []interface{}{
+			user.ID.Hex(),
+			user.Username,
+			user.PasswordHash,
+			user.CreatedAt,
+			user.FollowersCount,
+			user.FollowingCount,
+			user.PostsCount,
+			user.Pulled,
+		}
+	}
+
+	return writer.copy(
+		ctx,
+		pq.CopyIn("users", "id", "username", "password_hash", "created_at", "followers_count", "following_count", "posts_count", "pulled"),
+		rows,
+	)
+}
+
+func (writer *PostgresWriter) WriteFollowers(ctx context.Context, followers []*entity.Follower) error {
+	rows := make([][]interface{}, len(followers))
+
+	for i, follower := range followers {
+		rows[i] = []interface{}{
+			follower.ID.Hex(),
+			follower.FollowerID.Hex(),
+			follower.FollowingID.Hex(),
+			follower.CreatedAt,
+		}
+	}
+
+	return writer.copy(ctx, pq.CopyIn("followers", "id", "follower_id", "user_id", "created_at"), rows)
+}
+
+func (writer *PostgresWriter) WritePosts(ctx context.Context, posts []*post_entity.Post) error {
+	rows := make([][]interface{}, len(posts))
+
+	for i, post := range posts {
+		mentions := []*storedMention{}
+
+		for _, mention := range post.Mentions {
+			mentions = append(mentions, &storedMention{
+				UserID:   mention.UserID.Hex(),
+				Username: mention.Username,
+				Start:    mention.Start,
+				End:      mention.End,
+			})
+		}
+
+		mentionsJSON, err := json.Marshal(mentions)
+
+		if err != nil {
+			return err
+		}
+
+		rows[i] = []interface{}{
+			post.ID.Hex(),
+			post.UserID.Hex(),
+			postgres.ID(post.ParentID),
+			post.Content,
+			postgres.Strings(post.Hashtags),
+			string(mentionsJSON),
+			post.CreatedAt,
+		}
+	}
+
+	return writer.copy(
+		ctx,
+		pq.CopyIn("posts", "id", "user_id", "parent_id", "content", "hashtags", "mentions", "created_at"),
+		rows,
+	)
+}
+
+func (writer *PostgresWriter) copy(ctx context.Context, query string, rows [][]interface{}) error {
+	return postgres.Transaction(ctx, writer.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+
+		if err != nil {
+			return err
+		}
+
+		defer stmt.Close()
+
+		for _, row := range rows {
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				return err
+			}
+		}
+
+		// an Exec without values flushes the rows copied
+		_, err = stmt.ExecContext(ctx)
+
+		return err
+	})
+}
@@ -0,0 +1,165 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	// DistributionUniform makes every user as likely to be followed as any other
+	DistributionUniform = "uniform"
+	// DistributionPowerLaw makes a few users followed by most of the others, as on real networks
+	DistributionPowerLaw = "power-law"
+)
+
+var ErrMemoryDriver = errors.New("seed: the memory driver keeps nothing once the seed ends")
+
+// Options are the parameters of the data generated, the same options generate the same data
+type Options struct {
+	Users int
+	// Follows is the average number of users each user follows
+	Follows      int
+	Distribution string
+	// Exponent is how skewed the power-law distribution is, it must be greater than 1
+	Exponent     float64
+	PostsPerUser int
+	// the posts are created along Spread until Until, and the users and follows before them
+	Spread      time.Duration
+	Until       time.Time
+	RepostRatio float64
+	QuoteRatio  float64
+	Seed        int64
+	Password    string
+	BatchSize   int
+	// PulledFollowers are the followers from which the posts of a user are pulled into the feeds,
+	// app.timeline.fan-out-maximum-followers, so the users followed by many are seeded as pulled
+	PulledFollowers uint
+}
+
+func (options *Options) Validate() error {
+	switch {
+	case options.Users < 1:
+		return fmt.Errorf("seed: users must be at least 1, got %d", options.Users)
+	case options.Follows < 0:
+		return fmt.Errorf("seed: follows can not be negative, got %d", options.Follows)
+	case options.Distribution != DistributionUniform && options.Distribution != DistributionPowerLaw:
+		return fmt.Errorf("seed: unknown distribution %q", options.Distribution)
+	case options.Distribution == DistributionPowerLaw && options.Exponent <= 1:
+		return fmt.Errorf("seed: the exponent must be greater than 1, got %g", options.Exponent)
+	case options.PostsPerUser < 0:
+		return fmt.Errorf("seed: posts per user can not be negative, got %d", options.PostsPerUser)
+	case options.Spread <= 0:
+		return fmt.Errorf("seed: spread must be positive, got %s", options.Spread)
+	case options.RepostRatio < 0 || options.QuoteRatio < 0 || options.RepostRatio+options.QuoteRatio > 1:
+		return fmt.Errorf("seed: the repost and quote ratios must be positive and add up to at most 1")
+	case options.BatchSize < 1:
+		return fmt.Errorf("seed: batch size must be at least 1, got %d", options.BatchSize)
+	case options.PulledFollowers < 1:
+		return fmt.Errorf("seed: the followers of pulled users must be at least 1, got %d", options.PulledFollowers)
+	}
+
+	return nil
+}
+
+// Writer inserts the generated documents batch by batch
+type Writer interface {
+	WriteUsers(ctx context.Context, users []*entity.User) error
+	WriteFollowers(ctx context.Context, followers []*entity.Follower) error
+	WritePosts(ctx context.Context, posts []*post_entity.Post) error
+}
+
+// NewWriter returns the writer of the driver set on app.storage.driver
+func NewWriter(client *mongo.Client, db *sql.DB, configs *viper.Viper) (Writer, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewMongoDBWriter(client, configs), nil
+	case "postgres":
+		return NewPostgresWriter(db), nil
+	case "memory":
+		return nil, ErrMemoryDriver
+	default:
+		return nil, fmt.Errorf("seed: unknown storage driver %q", driver)
+	}
+}
+
+type Report struct {
+	Users     int
+	Followers int
+	Posts     int
+	Reposts   int
+	Quotes    int
+	Duration  time.Duration
+}
+
+func (report *Report) Summary() string {
+	return fmt.Sprintf(
+		"seed created %d users, %d followers and %d posts, %d reposts and %d quotes among them, in %s",
+		report.Users,
+		report.Followers,
+		report.Posts,
+		report.Reposts,
+		report.Quotes,
+		report.Duration.Round(time.Millisecond),
+	)
+}
+
+// Seed generates the followers, the users with their counters and then the posts,
+// writing them as each batch is full so the data never has to fit in memory
+func Seed(ctx context.Context, options *Options, writer Writer) (*Report, error) {
+	started := time.Now()
+
+	generator, err := NewGenerator(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+
+	err = generator.Followers(func(followers []*entity.Follower) error {
+		report.Followers += len(followers)
+
+		return writer.WriteFollowers(ctx, followers)
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	err = generator.Users(func(users []*entity.User) error {
+		report.Users += len(users)
+
+		return writer.WriteUsers(ctx, users)
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	err = generator.Posts(func(posts []*post_entity.Post) error {
+		for _, post := range posts {
+			switch {
+			case post.ParentID.IsZero():
+			case post.Content == "":
+				report.Reposts++
+			default:
+				report.Quotes++
+			}
+		}
+
+		report.Posts += len(posts)
+
+		return writer.WritePosts(ctx, posts)
+	})
+
+	report.Duration = time.Since(started)
+
+	return report, err
+}
@@ -0,0 +1,285 @@
+package seed
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestSeed(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Seed Suite")
+}
+
+// recordingWriter keeps the batches written
+type recordingWriter struct {
+	users     []*entity.User
+	followers []*entity.Follower
+	posts     []*post_entity.Post
+	batches   []int
+}
+
+func (writer *recordingWriter) WriteUsers(_ context.Context, users []*entity.User) error {
+	writer.users = append(writer.users, users...)
+	writer.batches = append(writer.batches, len(users))
+
+	return nil
+}
+
+func (writer *recordingWriter) WriteFollowers(_ context.Context, followers []*entity.Follower) error {
+	writer.followers = append(writer.followers, followers...)
+	writer.batches = append(writer.batches, len(followers))
+
+	return nil
+}
+
+func (writer *recordingWriter) WritePosts(_ context.Context, posts []*post_entity.Post) error {
+	writer.posts = append(writer.posts, posts...)
+	writer.batches = append(writer.batches, len(posts))
+
+	return nil
+}
+
+var _ = Describe("Seed suite test", func() {
+	until := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	newOptions := func() *Options {
+		return &Options{
+			Users:        200,
+			Follows:      10,
+			Distribution: DistributionUniform,
+			Exponent:     1.5,
+			PostsPerUser: 5,
+			Spread:       24 * time.Hour,
+			Until:        until,
+			RepostRatio:  0.2,
+			QuoteRatio:   0.1,
+			Seed:         42,
+			BatchSize:    64,
+			// users follow 10 others on average, so a few of them are pulled
+			PulledFollowers: 15,
+		}
+	}
+
+	seed := func(options *Options) (*recordingWriter, *Report) {
+		writer := &recordingWriter{}
+
+		report, err := Seed(context.Background(), options, writer)
+
+		Expect(err).To(BeNil())
+
+		return writer, report
+	}
+
+	Describe("Validating the options", func() {
+		It("rejects options that can not generate data", func() {
+			invalid := []func(options *Options){
+				func(options *Options) { options.Users = 0 },
+				func(options *Options) { options.Distribution = "normal" },
+				func(options *Options) { options.Distribution = DistributionPowerLaw; options.Exponent = 1 },
+				func(options *Options) { options.Spread = 0 },
+				func(options *Options) { options.RepostRatio = 0.8; options.QuoteRatio = 0.3 },
+				func(options *Options) { options.BatchSize = 0 },
+				func(options *Options) { options.PulledFollowers = 0 },
+			}
+
+			for _, change := range invalid {
+				options := newOptions()
+				change(options)
+
+				Expect(options.Validate()).ToNot(BeNil())
+			}
+
+			Expect(newOptions().Validate()).To(BeNil())
+		})
+	})
+
+	Describe("Seeding", func() {
+		Context("when it is given the same options", func() {
+			It("generates the same data", func() {
+				first, _ := seed(newOptions())
+				second, _ := seed(newOptions())
+
+				Expect(second.users).To(Equal(first.users))
+				Expect(second.followers).To(Equal(first.followers))
+				Expect(second.posts).To(Equal(first.posts))
+
+				other := newOptions()
+				other.Seed = 7
+
+				third, _ := seed(other)
+
+				Expect(third.posts).ToNot(Equal(first.posts))
+			})
+		})
+
+		It("writes batches of up to the batch size", func() {
+			writer, report := seed(newOptions())
+
+			for _, batch := range writer.batches {
+				Expect(batch).To(BeNumerically("<=", 64))
+			}
+
+			Expect(report.Users).To(Equal(200))
+			Expect(report.Posts).To(Equal(1000))
+			Expect(report.Followers).To(Equal(len(writer.followers)))
+		})
+
+		It("counts the followers and posts on the users", func() {
+			writer, _ := seed(newOptions())
+
+			followers := map[primitive.ObjectID]uint{}
+			following := map[primitive.ObjectID]uint{}
+			pairs := map[[2]primitive.ObjectID]bool{}
+
+			for _, follower := range writer.followers {
+				Expect(follower.FollowerID).ToNot(Equal(follower.FollowingID))
+				Expect(pairs[[2]primitive.ObjectID{follower.FollowerID, follower.FollowingID}]).To(BeFalse())
+
+				pairs[[2]primitive.ObjectID{follower.FollowerID, follower.FollowingID}] = true
+				followers[follower.FollowingID]++
+				following[follower.FollowerID]++
+			}
+
+			posts := map[primitive.ObjectID]uint{}
+
+			for _, post := range writer.posts {
+				posts[post.UserID]++
+			}
+
+			for _, user := range writer.users {
+				Expect(user.FollowersCount).To(Equal(followers[user.ID]))
+				Expect(user.FollowingCount).To(Equal(following[user.ID]))
+				Expect(user.PostsCount).To(Equal(posts[user.ID]))
+				Expect(user.PostsCount).To(Equal(uint(5)))
+				Expect(user.Pulled).To(Equal(user.FollowersCount >= 15))
+			}
+
+			// users follow 10 others on average
+			Expect(len(writer.followers)).To(BeNumerically("~", 2000, 300))
+		})
+
+		It("creates the posts along the spread, after the users and follows, reposting older posts", func() {
+			writer, report := seed(newOptions())
+
+			start := until.Add(-24 * time.Hour)
+			created := map[primitive.ObjectID]*post_entity.Post{}
+
+			for _, user := range writer.users {
+				Expect(user.CreatedAt.After(start)).To(BeFalse())
+			}
+
+			for _, follower := range writer.followers {
+				Expect(follower.CreatedAt.After(start)).To(BeFalse())
+			}
+
+			for i, post := range writer.posts {
+				Expect(post.CreatedAt.Before(start)).To(BeFalse())
+				Expect(post.CreatedAt.After(until)).To(BeFalse())
+				Expect(post.ID.Timestamp().Unix()).To(Equal(post.CreatedAt.Unix()))
+
+				if i > 0 {
+					Expect(post.CreatedAt.Before(writer.posts[i-1].CreatedAt)).To(BeFalse())
+				}
+
+				if !post.ParentID.IsZero() {
+					parent := created[post.ParentID]
+
+					Expect(parent).ToNot(BeNil())
+					Expect(parent.Content).ToNot(BeEmpty())
+				}
+
+				created[post.ID] = post
+			}
+
+			Expect(report.Reposts).To(BeNumerically("~", 200, 60))
+			Expect(report.Quotes).To(BeNumerically("~", 100, 40))
+		})
+
+		It("sets the hashtags and the mentions of the content", func() {
+			writer, _ := seed(newOptions())
+
+			users := map[primitive.ObjectID]string{}
+
+			for _, user := range writer.users {
+				users[user.ID] = user.Username
+			}
+
+			hashtags, mentions := 0, 0
+
+			for _, post := range writer.posts {
+				Expect(post.Hashtags).To(Equal(post_entity.ExtractHashtags(post.Content)))
+
+				for _, mention := range post.Mentions {
+					Expect(users[mention.UserID]).To(Equal(mention.Username))
+					mentions++
+				}
+
+				if len(post.Hashtags) > 0 {
+					hashtags++
+				}
+			}
+
+			Expect(hashtags).To(BeNumerically(">", 0))
+			Expect(mentions).To(BeNumerically(">", 0))
+		})
+
+		Context("when the followers have a power-law distribution", func() {
+			It("makes a few users followed by many more than the others", func() {
+				maximumFollowers := func(options *Options) uint {
+					writer, _ := seed(options)
+
+					var maximum uint
+
+					for _, user := range writer.users {
+						if user.FollowersCount > maximum {
+							maximum = user.FollowersCount
+						}
+					}
+
+					return maximum
+				}
+
+				powerLaw := newOptions()
+				powerLaw.Distribution = DistributionPowerLaw
+
+				Expect(maximumFollowers(powerLaw)).To(BeNumerically(">", 3*maximumFollowers(newOptions())))
+			})
+
+			It("seeds the users followed by the most as pulled", func() {
+				powerLaw := newOptions()
+				powerLaw.Distribution = DistributionPowerLaw
+
+				writer, _ := seed(powerLaw)
+
+				pulled := 0
+
+				for _, user := range writer.users {
+					if user.Pulled {
+						pulled++
+					}
+				}
+
+				Expect(pulled).To(BeNumerically(">", 0))
+				Expect(pulled).To(BeNumerically("<", len(writer.users)/2))
+			})
+		})
+	})
+
+	Describe("Choosing the writer", func() {
+		It("refuses the memory driver", func() {
+			configs := viper.New()
+			configs.Set("app.storage.driver", "memory")
+
+			_, err := NewWriter(nil, nil, configs)
+
+			Expect(err).To(Equal(ErrMemoryDriver))
+		})
+	})
+})
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Timeline struct {
+	UserID  primitive.ObjectID `bson:"_id"`
+	Entries []*Entry           `bson:"entries"`
+	// Pulled are the followed users with too many followers to have their posts pushed, which
+	// are read along with the entries. Timelines materialized before it was kept have none
+	Pulled []primitive.ObjectID `bson:"pulled"`
+}
+
+type Entry struct {
+	PostID    primitive.ObjectID `bson:"post_id"`
+	AuthorID  primitive.ObjectID `bson:"author_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func NewEntries(posts []*post_entity.Post) []*Entry {
+	var entries []*Entry
+
+	for _, post := range posts {
+		entries = append(entries, &Entry{
+			PostID:    post.ID,
+			AuthorID:  post.UserID,
+			CreatedAt: post.CreatedAt,
+		})
+	}
+
+	return entries
+}
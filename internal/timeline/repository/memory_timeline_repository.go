@@ -0,0 +1,318 @@
+package timeline_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+)
+
+// MemoryTimelineRepository keeps the timelines on a memory.Database, capped at
+// app.timeline.size entries as on the TimelineRepository
+type MemoryTimelineRepository struct {
+	database *memory.Database
+	size     int
+}
+
+func NewMemoryTimelineRepository(database *memory.Database, configs *viper.Viper) *MemoryTimelineRepository {
+	return &MemoryTimelineRepository{
+		database: database,
+		size:     configs.GetInt("app.timeline.size"),
+	}
+}
+
+func (repo *MemoryTimelineRepository) Exists(ctx context.Context, userId string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	_, ok := repo.database.Timelines[objectId]
+
+	return ok, nil
+}
+
+// GetPulled returns false for timelines without pulled users, which are the
+// ones materialized before they were kept on the TimelineRepository
+func (repo *MemoryTimelineRepository) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	timeline, ok := repo.database.Timelines[objectId]
+
+	if !ok || timeline.Pulled == nil {
+		return nil, false, nil
+	}
+
+	var result []string
+
+	for _, objId := range timeline.Pulled {
+		result = append(result, objId.Hex())
+	}
+
+	return result, true, nil
+}
+
+func (repo *MemoryTimelineRepository) Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	pulledObjectId := []primitive.ObjectID{}
+
+	for _, user := range pulled {
+		objId, err := mongodb.ObjectIDFromHex(user)
+		if err != nil {
+			return err
+		}
+
+		pulledObjectId = append(pulledObjectId, objId)
+	}
+
+	if len(entries) > repo.size {
+		entries = entries[:repo.size]
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	repo.database.Timelines[objectId] = &entity.Timeline{UserID: objectId, Entries: copyEntries(entries), Pulled: pulledObjectId}
+
+	return nil
+}
+
+func (repo *MemoryTimelineRepository) Push(ctx context.Context, users []string, entries []*entity.Entry) error {
+	if len(users) == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, user := range users {
+		objId, err := mongodb.ObjectIDFromHex(user)
+		if err != nil {
+			return err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, objId := range usersObjectId {
+		timeline, ok := repo.database.Timelines[objId]
+
+		if !ok {
+			continue
+		}
+
+		pushed := append(copyEntries(timeline.Entries), copyEntries(entries)...)
+
+		sortEntries(pushed)
+
+		if len(pushed) > repo.size {
+			pushed = pushed[:repo.size]
+		}
+
+		repo.database.Timelines[objId] = &entity.Timeline{UserID: objId, Entries: pushed, Pulled: timeline.Pulled}
+	}
+
+	return nil
+}
+
+func (repo *MemoryTimelineRepository) AddPulled(ctx context.Context, users []string, authorId string) error {
+	return repo.updatePulled(users, authorId, func(pulled []primitive.ObjectID, authorObjectId primitive.ObjectID) []primitive.ObjectID {
+		for _, objId := range pulled {
+			if objId == authorObjectId {
+				return pulled
+			}
+		}
+
+		return append(pulled, authorObjectId)
+	})
+}
+
+func (repo *MemoryTimelineRepository) RemovePulled(ctx context.Context, users []string, authorId string) error {
+	return repo.updatePulled(users, authorId, func(pulled []primitive.ObjectID, authorObjectId primitive.ObjectID) []primitive.ObjectID {
+		result := []primitive.ObjectID{}
+
+		for _, objId := range pulled {
+			if objId != authorObjectId {
+				result = append(result, objId)
+			}
+		}
+
+		return result
+	})
+}
+
+// updatePulled replaces the pulled users of the timelines of users that keep them with the ones update returns
+func (repo *MemoryTimelineRepository) updatePulled(
+	users []string,
+	authorId string,
+	update func(pulled []primitive.ObjectID, authorObjectId primitive.ObjectID) []primitive.ObjectID,
+) error {
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, user := range users {
+		objId, err := mongodb.ObjectIDFromHex(user)
+		if err != nil {
+			return err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, objId := range usersObjectId {
+		timeline, ok := repo.database.Timelines[objId]
+
+		if !ok || timeline.Pulled == nil {
+			continue
+		}
+
+		pulled := update(append([]primitive.ObjectID{}, timeline.Pulled...), authorObjectId)
+
+		repo.database.Timelines[objId] = &entity.Timeline{UserID: objId, Entries: timeline.Entries, Pulled: pulled}
+	}
+
+	return nil
+}
+
+func (repo *MemoryTimelineRepository) RemoveByAuthor(ctx context.Context, userId, authorId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if timeline, ok := repo.database.Timelines[objectId]; ok {
+		repo.database.Timelines[objectId] = remove(timeline, func(entry *entity.Entry) bool {
+			return entry.AuthorID == authorObjectId
+		})
+	}
+
+	return nil
+}
+
+func (repo *MemoryTimelineRepository) RemoveByPost(ctx context.Context, postId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for userId, timeline := range repo.database.Timelines {
+		repo.database.Timelines[userId] = remove(timeline, func(entry *entity.Entry) bool {
+			return entry.PostID == objectId
+		})
+	}
+
+	return nil
+}
+
+func (repo *MemoryTimelineRepository) GetEntries(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*entity.Entry, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+
+	var result []*entity.Entry
+
+	if timeline, ok := repo.database.Timelines[objectId]; ok {
+		for _, entry := range timeline.Entries {
+			if cursor == nil ||
+				entry.CreatedAt.Before(cursor.CreatedAt) ||
+				entry.CreatedAt.Equal(cursor.CreatedAt) && memory.CompareIDs(entry.PostID, cursor.ID) < 0 {
+				found := *entry
+				result = append(result, &found)
+			}
+		}
+	}
+
+	repo.database.RUnlock()
+
+	sortEntries(result)
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// remove returns the timeline without the entries that match
+func remove(timeline *entity.Timeline, match func(entry *entity.Entry) bool) *entity.Timeline {
+	entries := []*entity.Entry{}
+
+	for _, entry := range timeline.Entries {
+		if !match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return &entity.Timeline{UserID: timeline.UserID, Entries: entries, Pulled: timeline.Pulled}
+}
+
+// sortEntries orders entries from the newest, as the $push of the TimelineRepository
+func sortEntries(entries []*entity.Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+
+		return memory.CompareIDs(entries[i].PostID, entries[j].PostID) > 0
+	})
+}
+
+func copyEntries(entries []*entity.Entry) []*entity.Entry {
+	copied := []*entity.Entry{}
+
+	for _, entry := range entries {
+		stored := *entry
+		stored.CreatedAt = memory.Time(entry.CreatedAt)
+		copied = append(copied, &stored)
+	}
+
+	return copied
+}
@@ -0,0 +1,311 @@
+package timeline_repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// PostgresTimelineRepository keeps the entries of the timelines on the timeline_entries table,
+// capped at app.timeline.size entries for each user as on the TimelineRepository
+type PostgresTimelineRepository struct {
+	db   *sql.DB
+	size int
+}
+
+func NewPostgresTimelineRepository(db *sql.DB, configs *viper.Viper) *PostgresTimelineRepository {
+	return &PostgresTimelineRepository{
+		db:   db,
+		size: configs.GetInt("app.timeline.size"),
+	}
+}
+
+func (repo *PostgresTimelineRepository) Exists(ctx context.Context, userId string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+
+	err = repo.db.QueryRowContext(
+		ctx, `SELECT EXISTS (SELECT 1 FROM timelines WHERE user_id = $1)`, objectId.Hex(),
+	).Scan(&exists)
+
+	return exists, err
+}
+
+// GetPulled returns false for timelines whose pulled users are NULL, as on the TimelineRepository
+func (repo *PostgresTimelineRepository) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var pulled []string
+
+	err = repo.db.QueryRowContext(
+		ctx, `SELECT pulled FROM timelines WHERE user_id = $1 AND pulled IS NOT NULL`, objectId.Hex(),
+	).Scan(pq.Array(&pulled))
+
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(pulled) == 0 {
+		pulled = nil
+	}
+
+	return pulled, true, nil
+}
+
+func (repo *PostgresTimelineRepository) Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	pulledObjectId, err := toObjectIDs(pulled)
+
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > repo.size {
+		entries = entries[:repo.size]
+	}
+
+	return postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO timelines (user_id, pulled) VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET pulled = EXCLUDED.pulled`,
+			objectId.Hex(),
+			postgres.IDs(pulledObjectId),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM timeline_entries WHERE user_id = $1`, objectId.Hex()); err != nil {
+			return err
+		}
+
+		return insertEntries(ctx, tx, []primitive.ObjectID{objectId}, entries)
+	})
+}
+
+// Push only reaches timelines that were already materialized, as on the TimelineRepository
+func (repo *PostgresTimelineRepository) Push(ctx context.Context, users []string, entries []*entity.Entry) error {
+	if len(users) == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, user := range users {
+		objId, err := mongodb.ObjectIDFromHex(user)
+		if err != nil {
+			return err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	return postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		if err := insertEntries(ctx, tx, usersObjectId, entries); err != nil {
+			return err
+		}
+
+		// keeps the newest entries of each timeline, as the $slice of the TimelineRepository
+		_, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM timeline_entries e
+			USING (
+				SELECT user_id, post_id, row_number() OVER (
+					PARTITION BY user_id ORDER BY created_at DESC, post_id DESC
+				) AS position
+				FROM timeline_entries
+				WHERE user_id = ANY($1)
+			) ranked
+			WHERE e.user_id = ranked.user_id AND e.post_id = ranked.post_id AND ranked.position > $2`,
+			postgres.IDs(usersObjectId),
+			repo.size,
+		)
+
+		return err
+	})
+}
+
+func (repo *PostgresTimelineRepository) AddPulled(ctx context.Context, users []string, authorId string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(
+		ctx,
+		`UPDATE timelines SET pulled = array_append(pulled, $2)
+		WHERE user_id = ANY($1) AND pulled IS NOT NULL AND NOT $2 = ANY(pulled)`,
+		postgres.IDs(usersObjectId),
+		authorObjectId.Hex(),
+	)
+
+	return err
+}
+
+func (repo *PostgresTimelineRepository) RemovePulled(ctx context.Context, users []string, authorId string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(
+		ctx,
+		`UPDATE timelines SET pulled = array_remove(pulled, $2) WHERE user_id = ANY($1) AND $2 = ANY(pulled)`,
+		postgres.IDs(usersObjectId),
+		authorObjectId.Hex(),
+	)
+
+	return err
+}
+
+func (repo *PostgresTimelineRepository) RemoveByAuthor(ctx context.Context, userId, authorId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(
+		ctx,
+		`DELETE FROM timeline_entries WHERE user_id = $1 AND author_id = $2`,
+		objectId.Hex(),
+		authorObjectId.Hex(),
+	)
+
+	return err
+}
+
+func (repo *PostgresTimelineRepository) RemoveByPost(ctx context.Context, postId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM timeline_entries WHERE post_id = $1`, objectId.Hex())
+
+	return err
+}
+
+func (repo *PostgresTimelineRepository) GetEntries(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*entity.Entry, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT post_id, author_id, created_at FROM timeline_entries WHERE user_id = $1`
+	args := []interface{}{objectId.Hex(), limit}
+
+	if cursor != nil {
+		query += ` AND (created_at, post_id) < ($3, $4)`
+		args = append(args, postgres.Time(cursor.CreatedAt), cursor.ID.Hex())
+	}
+
+	rows, err := repo.db.QueryContext(ctx, query+` ORDER BY created_at DESC, post_id DESC LIMIT $2`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.Entry
+
+	for rows.Next() {
+		var entry entity.Entry
+		if err := rows.Scan(postgres.ScanID(&entry.PostID), postgres.ScanID(&entry.AuthorID), &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		result = append(result, &entry)
+	}
+
+	return result, rows.Err()
+}
+
+// insertEntries adds the entries to the timelines of users that exist, skipping the ones they already have
+func insertEntries(ctx context.Context, tx *sql.Tx, users []primitive.ObjectID, entries []*entity.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var posts, authors []primitive.ObjectID
+	var createdAt []string
+
+	for _, entry := range entries {
+		posts = append(posts, entry.PostID)
+		authors = append(authors, entry.AuthorID)
+		createdAt = append(createdAt, postgres.Time(entry.CreatedAt).Format(time.RFC3339Nano))
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+		SELECT t.user_id, e.post_id, e.author_id, e.created_at
+		FROM timelines t, unnest($2::text[], $3::text[], $4::timestamptz[]) AS e (post_id, author_id, created_at)
+		WHERE t.user_id = ANY($1)
+		ON CONFLICT (user_id, post_id) DO NOTHING`,
+		postgres.IDs(users),
+		postgres.IDs(posts),
+		postgres.IDs(authors),
+		postgres.Strings(createdAt),
+	)
+
+	return err
+}
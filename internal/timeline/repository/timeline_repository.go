@@ -0,0 +1,347 @@
+package timeline_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Exists(ctx context.Context, userId string) (bool, error)
+	GetPulled(ctx context.Context, userId string) ([]string, bool, error)
+	Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error
+	Push(ctx context.Context, users []string, entries []*entity.Entry) error
+	AddPulled(ctx context.Context, users []string, authorId string) error
+	RemovePulled(ctx context.Context, users []string, authorId string) error
+	RemoveByAuthor(ctx context.Context, userId, authorId string) error
+	RemoveByPost(ctx context.Context, postId string) error
+	GetEntries(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*entity.Entry, error)
+}
+
+// NewRepository returns the repository of the driver set on app.storage.driver
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewTimelineRepository(client, configs), nil
+	case "memory":
+		return NewMemoryTimelineRepository(database, configs), nil
+	case "postgres":
+		return NewPostgresTimelineRepository(db, configs), nil
+	default:
+		return nil, fmt.Errorf("timeline_repository: unknown storage driver %q", driver)
+	}
+}
+
+type TimelineRepository struct {
+	collection *mongo.Collection
+	size       int
+}
+
+func NewTimelineRepository(client *mongo.Client, configs *viper.Viper) *TimelineRepository {
+	timelinesCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.timeline-collection"),
+	)
+
+	return &TimelineRepository{
+		collection: timelinesCollection,
+		size:       configs.GetInt("app.timeline.size"),
+	}
+}
+
+func (repo *TimelineRepository) Exists(ctx context.Context, userId string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	count, err := repo.collection.CountDocuments(ctx, bson.M{"_id": objectId})
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetPulled returns the pulled users of a timeline, and false when the timeline does not exist
+// or was materialized before they were kept, so it has to be rebuilt
+func (repo *TimelineRepository) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var timeline entity.Timeline
+
+	err = repo.collection.FindOne(
+		ctx,
+		bson.M{"_id": objectId, "pulled": bson.M{"$exists": true}},
+		options.FindOne().SetProjection(bson.D{{"pulled", 1}}),
+	).Decode(&timeline)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result []string
+
+	for _, objId := range timeline.Pulled {
+		result = append(result, objId.Hex())
+	}
+
+	return result, true, nil
+}
+
+func (repo *TimelineRepository) Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	pulledObjectId, err := toObjectIDs(pulled)
+
+	if err != nil {
+		return err
+	}
+
+	if entries == nil {
+		entries = []*entity.Entry{}
+	}
+
+	if pulledObjectId == nil {
+		pulledObjectId = []primitive.ObjectID{}
+	}
+
+	if len(entries) > repo.size {
+		entries = entries[:repo.size]
+	}
+
+	_, err = repo.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": objectId},
+		entity.Timeline{UserID: objectId, Entries: entries, Pulled: pulledObjectId},
+		options.Replace().SetUpsert(true),
+	)
+
+	return err
+}
+
+// Push only reaches timelines that were already materialized, the ones that
+// do not exist yet are built from the followed users when they are first read
+func (repo *TimelineRepository) Push(ctx context.Context, users []string, entries []*entity.Entry) error {
+	if len(users) == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, user := range users {
+		objId, err := mongodb.ObjectIDFromHex(user)
+		if err != nil {
+			return err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	_, err := repo.collection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": usersObjectId}},
+		bson.D{
+			{"$push", bson.D{
+				{"entries", bson.D{
+					{"$each", entries},
+					{"$sort", bson.D{{"created_at", -1}, {"post_id", -1}}},
+					{"$slice", repo.size},
+				}},
+			}},
+		},
+	)
+
+	return err
+}
+
+// AddPulled adds the author to the pulled users of the timelines of users that keep them
+func (repo *TimelineRepository) AddPulled(ctx context.Context, users []string, authorId string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.collection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": usersObjectId}, "pulled": bson.M{"$exists": true}},
+		bson.D{{"$addToSet", bson.D{{"pulled", authorObjectId}}}},
+	)
+
+	return err
+}
+
+func (repo *TimelineRepository) RemovePulled(ctx context.Context, users []string, authorId string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	usersObjectId, err := toObjectIDs(users)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.collection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": usersObjectId}, "pulled": authorObjectId},
+		bson.D{{"$pull", bson.D{{"pulled", authorObjectId}}}},
+	)
+
+	return err
+}
+
+func (repo *TimelineRepository) RemoveByAuthor(ctx context.Context, userId, authorId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return err
+	}
+
+	authorObjectId, err := mongodb.ObjectIDFromHex(authorId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectId},
+		bson.D{
+			{"$pull", bson.D{
+				{"entries", bson.D{{"author_id", authorObjectId}}},
+			}},
+		},
+	)
+
+	return err
+}
+
+func (repo *TimelineRepository) RemoveByPost(ctx context.Context, postId string) error {
+	objectId, err := mongodb.ObjectIDFromHex(postId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.collection.UpdateMany(
+		ctx,
+		bson.M{"entries.post_id": objectId},
+		bson.D{
+			{"$pull", bson.D{
+				{"entries", bson.D{{"post_id", objectId}}},
+			}},
+		},
+	)
+
+	return err
+}
+
+func (repo *TimelineRepository) GetEntries(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*entity.Entry, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*entity.Entry
+
+	entriesFilter := bson.D{}
+
+	if cursor != nil {
+		entriesFilter = bson.D{
+			{
+				"$or",
+				bson.A{
+					bson.D{{"created_at", bson.D{{"$lt", cursor.CreatedAt}}}},
+					bson.D{
+						{"created_at", cursor.CreatedAt},
+						{"post_id", bson.D{{"$lt", cursor.ID}}},
+					},
+				},
+			},
+		}
+	}
+
+	curr, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{"$match", bson.D{{"_id", objectId}}}},
+		bson.D{{"$unwind", "$entries"}},
+		bson.D{{"$replaceRoot", bson.D{{"newRoot", "$entries"}}}},
+		bson.D{{"$match", entriesFilter}},
+		bson.D{{"$sort", bson.D{{"created_at", -1}, {"post_id", -1}}}},
+		bson.D{{"$limit", limit}},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var entry entity.Entry
+		if err := curr.Decode(&entry); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &entry)
+	}
+
+	return result, curr.Err()
+}
+
+func toObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	var result []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, objId)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,255 @@
+package timeline_repository
+
+import (
+	"context"
+	"database/sql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+	"time"
+)
+
+func TestTimelineRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TimelineRepository Suite")
+}
+
+var (
+	timelineRepository Repository
+	db                 *sql.DB
+	client             *mongo.Client
+	configs            *viper.Viper
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+
+	client = mongodb.NewMongoDBClient(configs)
+
+	// the suite runs against the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	timelineRepository, err = NewRepository(client, db, memory.NewDatabase(), configs)
+
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = AfterSuite(func() {
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE timeline_entries, timelines")
+
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
+	timelinesCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.timeline-collection"),
+	)
+
+	_, err := timelinesCollection.DeleteMany(context.Background(), bson.M{})
+
+	if err != nil {
+		panic(err)
+	}
+})
+
+var _ = Describe("TimelineRepository suite test", func() {
+	Describe("Pushing entries", func() {
+		Context("when the timeline is materialized", func() {
+			It("adds the entries to it", func() {
+				user := primitive.NewObjectID().Hex()
+
+				err := timelineRepository.Replace(context.Background(), user, nil, nil)
+				Expect(err).To(BeNil())
+
+				err = timelineRepository.Push(context.Background(), []string{user}, createEntries(primitive.NewObjectID(), 3))
+				Expect(err).To(BeNil())
+
+				entries, err := timelineRepository.GetEntries(context.Background(), user, nil, 10)
+
+				Expect(err).To(BeNil())
+				Expect(entries).To(HaveLen(3))
+			})
+		})
+
+		Context("when the timeline is not materialized", func() {
+			It("does not create it", func() {
+				user := primitive.NewObjectID().Hex()
+
+				err := timelineRepository.Push(context.Background(), []string{user}, createEntries(primitive.NewObjectID(), 3))
+				Expect(err).To(BeNil())
+
+				exists, err := timelineRepository.Exists(context.Background(), user)
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeFalse())
+			})
+		})
+
+		Context("when the timeline reaches its size", func() {
+			It("keeps only the newest entries", func() {
+				user := primitive.NewObjectID().Hex()
+				size := configs.GetInt("app.timeline.size")
+
+				_ = timelineRepository.Replace(context.Background(), user, createEntries(primitive.NewObjectID(), size), nil)
+
+				newest := createEntries(primitive.NewObjectID(), 1)
+				newest[0].CreatedAt = time.Now().Add(time.Hour)
+
+				_ = timelineRepository.Push(context.Background(), []string{user}, newest)
+
+				entries, err := timelineRepository.GetEntries(context.Background(), user, nil, size+1)
+
+				Expect(err).To(BeNil())
+				Expect(entries).To(HaveLen(size))
+				Expect(entries[0].PostID).To(Equal(newest[0].PostID))
+			})
+		})
+	})
+
+	Describe("Keeping the pulled users", func() {
+		Context("when the timeline is materialized", func() {
+			It("adds and removes them", func() {
+				user := primitive.NewObjectID().Hex()
+				pulled := primitive.NewObjectID().Hex()
+				author := primitive.NewObjectID().Hex()
+
+				err := timelineRepository.Replace(context.Background(), user, createEntries(primitive.NewObjectID(), 2), []string{pulled})
+				Expect(err).To(BeNil())
+
+				err = timelineRepository.AddPulled(context.Background(), []string{user}, author)
+				Expect(err).To(BeNil())
+
+				err = timelineRepository.AddPulled(context.Background(), []string{user}, author)
+				Expect(err).To(BeNil())
+
+				users, exists, err := timelineRepository.GetPulled(context.Background(), user)
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeTrue())
+				Expect(users).To(ConsistOf(pulled, author))
+
+				err = timelineRepository.RemovePulled(context.Background(), []string{user}, pulled)
+				Expect(err).To(BeNil())
+
+				users, _, err = timelineRepository.GetPulled(context.Background(), user)
+
+				Expect(err).To(BeNil())
+				Expect(users).To(Equal([]string{author}))
+
+				entries, err := timelineRepository.GetEntries(context.Background(), user, nil, 10)
+
+				Expect(err).To(BeNil())
+				Expect(entries).To(HaveLen(2))
+			})
+		})
+
+		Context("when the timeline is not materialized", func() {
+			It("does not create it", func() {
+				user := primitive.NewObjectID().Hex()
+
+				err := timelineRepository.AddPulled(context.Background(), []string{user}, primitive.NewObjectID().Hex())
+				Expect(err).To(BeNil())
+
+				users, exists, err := timelineRepository.GetPulled(context.Background(), user)
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeFalse())
+				Expect(users).To(BeEmpty())
+			})
+		})
+
+		Context("when the timeline has no pulled users", func() {
+			It("is materialized", func() {
+				user := primitive.NewObjectID().Hex()
+
+				_ = timelineRepository.Replace(context.Background(), user, nil, nil)
+
+				users, exists, err := timelineRepository.GetPulled(context.Background(), user)
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeTrue())
+				Expect(users).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Removing entries by author", func() {
+		Context("when the timeline has entries from the author", func() {
+			It("removes only them", func() {
+				user := primitive.NewObjectID().Hex()
+				author := primitive.NewObjectID()
+
+				entries := append(createEntries(author, 2), createEntries(primitive.NewObjectID(), 3)...)
+
+				_ = timelineRepository.Replace(context.Background(), user, entries, nil)
+
+				err := timelineRepository.RemoveByAuthor(context.Background(), user, author.Hex())
+				Expect(err).To(BeNil())
+
+				entries, err = timelineRepository.GetEntries(context.Background(), user, nil, 10)
+
+				Expect(err).To(BeNil())
+				Expect(entries).To(HaveLen(3))
+
+				for _, entry := range entries {
+					Expect(entry.AuthorID).NotTo(Equal(author))
+				}
+			})
+		})
+	})
+})
+
+func createEntries(author primitive.ObjectID, numberOfEntries int) []*entity.Entry {
+	var entries []*entity.Entry
+	for i := 0; i < numberOfEntries; i++ {
+		entries = append(entries, &entity.Entry{
+			PostID:    primitive.NewObjectID(),
+			AuthorID:  author,
+			CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	return entries
+}
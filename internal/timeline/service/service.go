@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	"github.com/regiszanandrea/posty/internal/timeline/repository"
+	"github.com/regiszanandrea/posty/internal/tracing"
+	"github.com/regiszanandrea/posty/internal/user/repository/follower"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+	"log"
+	"sort"
+)
+
+type Service interface {
+	FanOut(ctx context.Context, post *post_entity.Post) error
+	Enqueue(ctx context.Context, post *post_entity.Post) error
+	Work(done <-chan struct{})
+	Backfill(ctx context.Context, followerId, followingId string) error
+	Purge(ctx context.Context, followerId, followingId string) error
+	Remove(ctx context.Context, post *post_entity.Post) error
+	GetFeed(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*post_entity.Post, error)
+}
+
+type TimelineService struct {
+	repository         timeline_repository.Repository
+	postRepository     post_repository.Repository
+	followerRepository follower_repository.Repository
+	userRepository     user_repository.Repository
+	configs            *viper.Viper
+	queue              chan *queuedPost
+}
+
+// queuedPost is a post waiting to be fanned out, along with the span of the request that created it
+type queuedPost struct {
+	post *post_entity.Post
+	span trace.SpanContext
+}
+
+func NewTimelineService(
+	repository timeline_repository.Repository,
+	postRepository post_repository.Repository,
+	followerRepository follower_repository.Repository,
+	userRepository user_repository.Repository,
+	configs *viper.Viper,
+) *TimelineService {
+	return &TimelineService{
+		repository:         repository,
+		postRepository:     postRepository,
+		followerRepository: followerRepository,
+		userRepository:     userRepository,
+		configs:            configs,
+		queue:              make(chan *queuedPost, configs.GetInt("app.timeline.fan-out-queue-size")),
+	}
+}
+
+// FanOut pushes the post into the timeline of every follower of its author, posts
+// from accounts with too many followers are left to be merged when the feed is read.
+// Replies only reach the followers that follow the replied user as well
+func (service *TimelineService) FanOut(ctx context.Context, post *post_entity.Post) error {
+	ctx, span := tracing.Start(ctx, "TimelineService.FanOut")
+	defer span.End()
+
+	highFollowers, err := service.isHighFollower(ctx, post.UserID.Hex())
+
+	if err != nil {
+		return err
+	}
+
+	if highFollowers {
+		return nil
+	}
+
+	followers, err := service.followerRepository.GetFollowers(ctx, post.UserID.Hex())
+
+	if err != nil {
+		return err
+	}
+
+	return service.push(ctx, followers, []*post_entity.Post{post})
+}
+
+// Enqueue queues the post to be fanned out by Work, so creating a post does not take longer
+// as its author gets more followers. It only waits while the queue is full
+func (service *TimelineService) Enqueue(ctx context.Context, post *post_entity.Post) error {
+	select {
+	case service.queue <- &queuedPost{post: post, span: trace.SpanContextFromContext(ctx)}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Work fans out the queued posts until done is closed, and then the ones still queued. Each post
+// has its own context, which is not bound to the deadline of the request that created it
+func (service *TimelineService) Work(done <-chan struct{}) {
+	for {
+		select {
+		case queued := <-service.queue:
+			service.fanOutQueued(queued)
+		case <-done:
+			for {
+				select {
+				case queued := <-service.queue:
+					service.fanOutQueued(queued)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// fanOutQueued is best-effort, the post is already persisted and a failure only leaves it out of the timelines
+func (service *TimelineService) fanOutQueued(queued *queuedPost) {
+	ctx, cancel := context.WithTimeout(
+		trace.ContextWithSpanContext(context.Background(), queued.span),
+		service.configs.GetDuration("app.timeline.fan-out-timeout"),
+	)
+	defer cancel()
+
+	if err := service.FanOut(ctx, queued.post); err != nil {
+		log.Printf("could not fan out post %s: %v", queued.post.ID.Hex(), err)
+	}
+}
+
+// Backfill adds the followed user to the timeline of the follower, as one of its pulled
+// users when it has too many followers, or else by pushing its last posts
+func (service *TimelineService) Backfill(ctx context.Context, followerId, followingId string) error {
+	ctx, span := tracing.Start(ctx, "TimelineService.Backfill")
+	defer span.End()
+
+	pulled, err := service.updatePulled(ctx, followingId)
+
+	if err != nil {
+		return err
+	}
+
+	if pulled {
+		return service.repository.AddPulled(ctx, []string{followerId}, followingId)
+	}
+
+	exists, err := service.repository.Exists(ctx, followerId)
+
+	if err != nil || !exists {
+		return err
+	}
+
+	posts, err := service.postRepository.GetLastByUser(ctx, followingId, nil, service.configs.GetInt("app.timeline.size"))
+
+	if err != nil {
+		return err
+	}
+
+	posts, err = service.visible(ctx, followerId, posts)
+
+	if err != nil {
+		return err
+	}
+
+	return service.repository.Push(ctx, []string{followerId}, entity.NewEntries(posts))
+}
+
+func (service *TimelineService) Purge(ctx context.Context, followerId, followingId string) error {
+	ctx, span := tracing.Start(ctx, "TimelineService.Purge")
+	defer span.End()
+
+	err := service.repository.RemoveByAuthor(ctx, followerId, followingId)
+
+	if err != nil {
+		return err
+	}
+
+	err = service.repository.RemovePulled(ctx, []string{followerId}, followingId)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = service.updatePulled(ctx, followingId)
+
+	return err
+}
+
+func (service *TimelineService) Remove(ctx context.Context, post *post_entity.Post) error {
+	ctx, span := tracing.Start(ctx, "TimelineService.Remove")
+	defer span.End()
+
+	return service.repository.RemoveByPost(ctx, post.ID.Hex())
+}
+
+// GetFeed reads the entries of the timeline and merges the posts of its pulled users,
+// which are kept on the timeline, so reading it does not depend on how many users it follows
+func (service *TimelineService) GetFeed(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*post_entity.Post, error) {
+	ctx, span := tracing.Start(ctx, "TimelineService.GetFeed")
+	defer span.End()
+
+	pulled, exists, err := service.repository.GetPulled(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		pulled, err = service.rebuild(ctx, userId)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := service.repository.GetEntries(ctx, userId, cursor, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, entry := range entries {
+		ids = append(ids, entry.PostID.Hex())
+	}
+
+	posts, err := service.postRepository.GetByIDs(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pulled) > 0 {
+		pulledPosts, err := service.pull(ctx, userId, pulled, cursor, limit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, pulledPosts...)
+	}
+
+	return merge(posts, limit), nil
+}
+
+// rebuild materializes the timeline of users that have never had one, like the ones created
+// before timelines existed, from the posts of the users they follow, and returns its pulled users
+func (service *TimelineService) rebuild(ctx context.Context, userId string) ([]string, error) {
+	following, err := service.followerRepository.GetFollowingUsers(ctx, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pulled []string
+	var posts []*post_entity.Post
+
+	if len(following) > 0 {
+		pulled, err = service.userRepository.FilterByMinimumFollowers(
+			ctx,
+			following,
+			service.configs.GetUint("app.timeline.fan-out-maximum-followers"),
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if authors := difference(following, pulled); len(authors) > 0 {
+			posts, err = service.postRepository.GetLastByUsers(ctx, authors, following, nil, service.configs.GetInt("app.timeline.size"))
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pulled, service.repository.Replace(ctx, userId, entity.NewEntries(posts), pulled)
+}
+
+// pull returns the last posts of the pulled users for the feed of userId. Replies to users it
+// does not follow are dropped, so it reads further until there are limit posts or no more of them
+func (service *TimelineService) pull(ctx context.Context, userId string, pulled []string, cursor *post_entity.Cursor, limit int) ([]*post_entity.Post, error) {
+	var result []*post_entity.Post
+
+	for {
+		posts, err := service.postRepository.GetLastByUsers(ctx, pulled, nil, cursor, limit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		visible, err := service.visible(ctx, userId, posts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, visible...)
+
+		if len(posts) < limit || len(result) >= limit {
+			return result, nil
+		}
+
+		last := posts[len(posts)-1]
+		cursor = &post_entity.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// visible drops the replies to users that userId does not follow
+func (service *TimelineService) visible(ctx context.Context, userId string, posts []*post_entity.Post) ([]*post_entity.Post, error) {
+	var replied []string
+
+	seen := make(map[string]bool)
+
+	for _, post := range posts {
+		if post.IsReply() && !seen[post.InReplyToUserID.Hex()] {
+			seen[post.InReplyToUserID.Hex()] = true
+			replied = append(replied, post.InReplyToUserID.Hex())
+		}
+	}
+
+	if len(replied) == 0 {
+		return posts, nil
+	}
+
+	following, err := service.followerRepository.FilterFollowing(ctx, userId, replied)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return visibleTo(posts, following), nil
+}
+
+// push pushes the posts to the timelines of followers, the replies only to the ones following the replied user as well
+func (service *TimelineService) push(ctx context.Context, followers []string, posts []*post_entity.Post) error {
+	if len(followers) == 0 {
+		return nil
+	}
+
+	var others []*post_entity.Post
+
+	replies := make(map[string][]*post_entity.Post)
+
+	for _, post := range posts {
+		if post.IsReply() {
+			replies[post.InReplyToUserID.Hex()] = append(replies[post.InReplyToUserID.Hex()], post)
+		} else {
+			others = append(others, post)
+		}
+	}
+
+	if err := service.repository.Push(ctx, followers, entity.NewEntries(others)); err != nil {
+		return err
+	}
+
+	for repliedUser, posts := range replies {
+		recipients, err := service.followerRepository.FilterFollowers(ctx, repliedUser, followers)
+
+		if err != nil {
+			return err
+		}
+
+		if err = service.repository.Push(ctx, recipients, entity.NewEntries(posts)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updatePulled marks whether the posts of the user are pulled as its followers change, and returns
+// it. When the user crosses app.timeline.fan-out-maximum-followers, the timelines of its followers
+// start to pull its posts, or stop to and get its last posts pushed as they would have been
+func (service *TimelineService) updatePulled(ctx context.Context, userId string) (bool, error) {
+	pulled, changed, err := service.userRepository.UpdatePulled(
+		ctx,
+		userId,
+		service.configs.GetUint("app.timeline.fan-out-maximum-followers"),
+	)
+
+	if err != nil || !changed {
+		return pulled, err
+	}
+
+	followers, err := service.followerRepository.GetFollowers(ctx, userId)
+
+	if err != nil {
+		return false, err
+	}
+
+	if pulled {
+		return true, service.repository.AddPulled(ctx, followers, userId)
+	}
+
+	if err = service.repository.RemovePulled(ctx, followers, userId); err != nil {
+		return false, err
+	}
+
+	posts, err := service.postRepository.GetLastByUser(ctx, userId, nil, service.configs.GetInt("app.timeline.size"))
+
+	if err != nil {
+		return false, err
+	}
+
+	return false, service.push(ctx, followers, posts)
+}
+
+func (service *TimelineService) isHighFollower(ctx context.Context, userId string) (bool, error) {
+	users, err := service.userRepository.FilterByMinimumFollowers(
+		ctx,
+		[]string{userId},
+		service.configs.GetUint("app.timeline.fan-out-maximum-followers"),
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(users) > 0, nil
+}
+
+// visibleTo drops the replies to users that are not on following
+func visibleTo(posts []*post_entity.Post, following []string) []*post_entity.Post {
+	var result []*post_entity.Post
+
+	followed := make(map[string]bool)
+
+	for _, user := range following {
+		followed[user] = true
+	}
+
+	for _, post := range posts {
+		if !post.IsReply() || followed[post.InReplyToUserID.Hex()] {
+			result = append(result, post)
+		}
+	}
+
+	return result
+}
+
+func merge(posts []*post_entity.Post, limit int) []*post_entity.Post {
+	var result []*post_entity.Post
+
+	seen := make(map[string]bool)
+
+	for _, post := range posts {
+		if seen[post.ID.Hex()] {
+			continue
+		}
+
+		seen[post.ID.Hex()] = true
+		result = append(result, post)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID.Hex() > result[j].ID.Hex()
+		}
+
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+func difference(items []string, remove []string) []string {
+	var result []string
+
+	removed := make(map[string]bool)
+
+	for _, item := range remove {
+		removed[item] = true
+	}
+
+	for _, item := range items {
+		if !removed[item] {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/test/mocks/follower"
+	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/timeline"
+	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestTimelineService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Timeline Service Suite")
+}
+
+var (
+	configs *viper.Viper
+	service *TimelineService
+)
+
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
+var _ = Describe("TimelineService suite test", func() {
+	Describe("Fanning out a post", func() {
+		Context("when its author has few followers", func() {
+			It("pushes the post to the followers timelines", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				err := service.FanOut(context.Background(), &post_entity.Post{
+					ID:        primitive.NewObjectID(),
+					UserID:    primitive.NewObjectID(),
+					CreatedAt: time.Now(),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(HaveLen(2))
+			})
+		})
+
+		Context("when its author has too many followers", func() {
+			It("leaves the post to be read on the feed", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.HighFollowersUserRepositoryMock{},
+					configs,
+				)
+
+				err := service.FanOut(context.Background(), &post_entity.Post{
+					ID:        primitive.NewObjectID(),
+					UserID:    primitive.NewObjectID(),
+					CreatedAt: time.Now(),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(BeEmpty())
+			})
+		})
+
+		Context("when the post is queued", func() {
+			It("pushes it on the workers, which finish the queue before stopping", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				err := service.Enqueue(context.Background(), &post_entity.Post{
+					ID:        primitive.NewObjectID(),
+					UserID:    primitive.NewObjectID(),
+					CreatedAt: time.Now(),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(BeEmpty())
+
+				done := make(chan struct{})
+				close(done)
+
+				service.Work(done)
+
+				Expect(timelineRepository.Pushed).To(HaveLen(2))
+			})
+		})
+
+		Context("when the post is a reply to a user its followers do not follow", func() {
+			It("does not push it to their timelines", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.NoMutualFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				err := service.FanOut(context.Background(), &post_entity.Post{
+					ID:              primitive.NewObjectID(),
+					UserID:          primitive.NewObjectID(),
+					InReplyToID:     primitive.NewObjectID(),
+					InReplyToUserID: primitive.NewObjectID(),
+					CreatedAt:       time.Now(),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Backfilling a timeline", func() {
+		Context("when a user follows another with few followers", func() {
+			It("pushes the last posts of the followed user", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				followerId := primitive.NewObjectID().Hex()
+
+				err := service.Backfill(context.Background(), followerId, primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(Equal([]string{followerId}))
+				Expect(timelineRepository.Pulling).To(BeEmpty())
+			})
+		})
+
+		Context("when a user follows another with too many followers", func() {
+			It("pulls the posts of the followed user", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.HighFollowersUserRepositoryMock{},
+					configs,
+				)
+
+				followerId := primitive.NewObjectID().Hex()
+
+				err := service.Backfill(context.Background(), followerId, primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(BeEmpty())
+				Expect(timelineRepository.Pulling).To(Equal([]string{followerId}))
+			})
+		})
+
+		Context("when the followed user crosses the followers to be pulled", func() {
+			It("pulls its posts on the timelines of all its followers", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.CrossingUserRepositoryMock{Pulled: true},
+					configs,
+				)
+
+				followerId := primitive.NewObjectID().Hex()
+
+				err := service.Backfill(context.Background(), followerId, primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pulling).To(HaveLen(3))
+				Expect(timelineRepository.Pulling).To(ContainElement(followerId))
+			})
+		})
+	})
+
+	Describe("Purging a timeline", func() {
+		Context("when a user unfollows another", func() {
+			It("removes the posts of the unfollowed user", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				followingId := primitive.NewObjectID().Hex()
+
+				err := service.Purge(context.Background(), primitive.NewObjectID().Hex(), followingId)
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Removed).To(ContainElement(followingId))
+			})
+		})
+		Context("when the unfollowed user stops to have too many followers", func() {
+			It("pushes its last posts to the timelines of its followers instead of pulling them", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.CrossingUserRepositoryMock{Pulled: false},
+					configs,
+				)
+
+				followerId := primitive.NewObjectID().Hex()
+
+				err := service.Purge(context.Background(), followerId, primitive.NewObjectID().Hex())
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Unpulling).To(HaveLen(3))
+				Expect(timelineRepository.Unpulling).To(ContainElement(followerId))
+				Expect(timelineRepository.Pushed).To(HaveLen(2))
+				Expect(timelineRepository.Pushed).NotTo(ContainElement(followerId))
+			})
+		})
+	})
+
+	Describe("Getting the feed", func() {
+		Context("when the timeline is materialized", func() {
+			It("returns the posts ordered by creation", func() {
+				limit := 5
+
+				service = NewTimelineService(
+					&timeline_mock.SuccessTimelineRepositoryMock{},
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				posts, err := service.GetFeed(context.Background(), primitive.NewObjectID().Hex(), nil, limit)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(HaveLen(limit))
+
+				for i := 1; i < len(posts); i++ {
+					Expect(posts[i].CreatedAt.After(posts[i-1].CreatedAt)).To(BeFalse())
+				}
+			})
+		})
+
+		Context("when the timeline was never materialized", func() {
+			It("builds it before reading", func() {
+				timelineRepository := &timeline_mock.NotMaterializedTimelineRepositoryMock{}
+
+				service = NewTimelineService(
+					timelineRepository,
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				_, err := service.GetFeed(context.Background(), primitive.NewObjectID().Hex(), nil, 5)
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Replaced).To(BeTrue())
+			})
+		})
+
+		Context("when the timeline is materialized", func() {
+			It("does not read the users it follows", func() {
+				service = NewTimelineService(
+					&timeline_mock.SuccessTimelineRepositoryMock{},
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.ErrorOnGettingFollowingRepositoryMock{},
+					&user_mock.SuccessUserRepositoryMock{},
+					configs,
+				)
+
+				_, err := service.GetFeed(context.Background(), primitive.NewObjectID().Hex(), nil, 5)
+
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("when following users with too many followers", func() {
+			It("merges their posts without exceeding the limit", func() {
+				limit := 5
+
+				service = NewTimelineService(
+					&timeline_mock.PullingTimelineRepositoryMock{
+						Pulled: []string{primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()},
+					},
+					&post_mock.SuccessPostRepositoryMock{},
+					&follower_mock.ErrorOnGettingFollowingRepositoryMock{},
+					&user_mock.HighFollowersUserRepositoryMock{},
+					configs,
+				)
+
+				posts, err := service.GetFeed(context.Background(), primitive.NewObjectID().Hex(), nil, limit)
+
+				Expect(err).To(BeNil())
+				Expect(posts).To(HaveLen(limit))
+			})
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package timeline
+
+import (
+	"github.com/regiszanandrea/posty/internal/timeline/repository"
+	"github.com/regiszanandrea/posty/internal/timeline/service"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Options(
+		Provide(
+			timeline_repository.NewRepository,
+			Annotate(
+				service.NewTimelineService,
+				As(new(service.Service)),
+			),
+		),
+	)
+
+	Invokables = Options(
+		Invoke(RegisterWorkers),
+	)
+)
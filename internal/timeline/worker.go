@@ -0,0 +1,49 @@
+package timeline
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/timeline/service"
+	"github.com/spf13/viper"
+	. "go.uber.org/fx"
+	"sync"
+)
+
+// RegisterWorkers runs app.timeline.fan-out-workers workers that fan out the posts queued,
+// when the application stops they finish the posts still queued before it exits, so they are
+// registered before the server, whose requests queue posts until it is shut down
+func RegisterWorkers(lifecycle Lifecycle, service service.Service, configs *viper.Viper) {
+	done := make(chan struct{})
+	workers := sync.WaitGroup{}
+
+	lifecycle.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			for i := 0; i < configs.GetInt("app.timeline.fan-out-workers"); i++ {
+				workers.Add(1)
+
+				go func() {
+					defer workers.Done()
+					service.Work(done)
+				}()
+			}
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+
+			finished := make(chan struct{})
+
+			go func() {
+				workers.Wait()
+				close(finished)
+			}()
+
+			select {
+			case <-finished:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
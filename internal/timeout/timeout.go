@@ -0,0 +1,53 @@
+package timeout
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/spf13/viper"
+	"time"
+)
+
+type Middleware struct {
+	configs *viper.Viper
+}
+
+func NewMiddleware(configs *viper.Viper) *Middleware {
+	return &Middleware{
+		configs: configs,
+	}
+}
+
+// Handle sets the deadline of the operation on app.timeouts.operations, or app.timeouts.default
+// when it has none, on the user context. A request that failed after its deadline was
+// reached fails with apperror.ErrTimeout, whatever error the handler returned
+func (m *Middleware) Handle(operation string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		deadlineCtx, cancel := context.WithTimeout(ctx.UserContext(), m.timeout(operation))
+		defer cancel()
+
+		ctx.SetUserContext(deadlineCtx)
+
+		err := ctx.Next()
+
+		if deadlineCtx.Err() != context.DeadlineExceeded {
+			return err
+		}
+
+		if err == nil && ctx.Response().StatusCode() < fiber.StatusBadRequest {
+			return nil
+		}
+
+		return apperror.ErrTimeout
+	}
+}
+
+func (m *Middleware) timeout(operation string) time.Duration {
+	key := "app.timeouts.operations." + operation
+
+	if m.configs.IsSet(key) {
+		return m.configs.GetDuration(key)
+	}
+
+	return m.configs.GetDuration("app.timeouts.default")
+}
@@ -0,0 +1,9 @@
+package timeout
+
+import (
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Provide(NewMiddleware)
+)
@@ -0,0 +1,75 @@
+package timeout
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/spf13/viper"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Timeout Suite")
+}
+
+var _ = Describe("Timeout middleware suite test", func() {
+	var app *fiber.App
+
+	BeforeEach(func() {
+		configs := viper.New()
+		configs.Set("app.timeouts.default", time.Second)
+		configs.Set("app.timeouts.operations.slow", 10*time.Millisecond)
+
+		middleware := NewMiddleware(configs)
+
+		app = fiber.New(fiber.Config{
+			ErrorHandler: func(ctx *fiber.Ctx, err error) error {
+				return ctx.SendStatus(apperror.From(err).Status())
+			},
+		})
+
+		app.Get("/slow", middleware.Handle("slow"), func(ctx *fiber.Ctx) error {
+			<-ctx.UserContext().Done()
+
+			return ctx.Status(fiber.StatusBadRequest).JSON([]string{ctx.UserContext().Err().Error()})
+		})
+
+		app.Get("/fast", middleware.Handle("fast"), func(ctx *fiber.Ctx) error {
+			deadline, _ := ctx.UserContext().Deadline()
+
+			return ctx.JSON(fiber.Map{"deadline": deadline})
+		})
+	})
+
+	Context("when the operation reaches its deadline", func() {
+		It("returns gateway timeout", func() {
+			resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusGatewayTimeout))
+		})
+	})
+
+	Context("when the operation has no deadline of its own", func() {
+		It("uses the default one", func() {
+			before := time.Now()
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/fast", nil))
+
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
+
+			var body struct {
+				Deadline time.Time `json:"deadline"`
+			}
+
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Deadline).To(BeTemporally("~", before.Add(time.Second), 100*time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a span for every request, continuing the trace sent by the client,
+// and puts it on the user context that handlers pass down to services and repositories
+func Middleware(c *fiber.Ctx) error {
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{&c.Request().Header})
+
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		"HTTP "+c.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(c.Method()),
+			semconv.HTTPTargetKey.String(c.OriginalURL()),
+		),
+	)
+	defer span.End()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+
+	// the error is rendered here so the status recorded is the one sent
+	if err != nil {
+		span.RecordError(err)
+
+		if err := c.App().Config().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	status := c.Response().StatusCode()
+
+	// the route is only known after it was matched
+	span.SetName(c.Method() + " " + c.Route().Path)
+	span.SetAttributes(
+		semconv.HTTPRouteKey.String(c.Route().Path),
+		semconv.HTTPStatusCodeKey.Int(status),
+	)
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+
+	return nil
+}
+
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (carrier headerCarrier) Get(key string) string {
+	return string(carrier.header.Peek(key))
+}
+
+func (carrier headerCarrier) Set(key, value string) {
+	carrier.header.Set(key, value)
+}
+
+func (carrier headerCarrier) Keys() []string {
+	var keys []string
+
+	carrier.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
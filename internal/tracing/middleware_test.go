@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+var _ = Describe("Tracing middleware suite test", func() {
+	var (
+		app      *fiber.App
+		recorder *tracetest.SpanRecorder
+	)
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		app = fiber.New()
+		app.Use(Middleware)
+
+		app.Get("/users/:id", func(c *fiber.Ctx) error {
+			_, span := Start(c.UserContext(), "UserService.GetUser")
+			span.End()
+
+			return c.SendStatus(fiber.StatusOK)
+		})
+	})
+
+	Context("when a route is requested", func() {
+		It("names the span by the route and nests the spans created by the handler", func() {
+			_, err := app.Test(httptest.NewRequest("GET", "/users/1", nil))
+			Expect(err).To(BeNil())
+
+			spans := recorder.Ended()
+
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].Name()).To(Equal("UserService.GetUser"))
+			Expect(spans[1].Name()).To(Equal("GET /users/:id"))
+			Expect(spans[1].SpanKind()).To(Equal(trace.SpanKindServer))
+			Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+		})
+	})
+
+	Context("when the client sends a trace", func() {
+		It("continues it", func() {
+			request := httptest.NewRequest("GET", "/users/1", nil)
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			_, err := app.Test(request)
+			Expect(err).To(BeNil())
+
+			spans := recorder.Ended()
+
+			Expect(spans[1].SpanContext().TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(spans[1].Parent().SpanID().String()).To(Equal("00f067aa0ba902b7"))
+		})
+	})
+})
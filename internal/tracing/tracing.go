@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	. "go.uber.org/fx"
+)
+
+const (
+	tracerName = "github.com/regiszanandrea/posty"
+
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Start creates a span as a child of the one on ctx, the spans are dropped
+// while no exporter is configured
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name)
+}
+
+// NewTracerProvider exports the spans to the exporter set on app.tracing.exporter,
+// which is either none, stdout or otlp
+func NewTracerProvider(configs *viper.Viper) (*sdktrace.TracerProvider, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(configs.GetString("app.name")),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(configs.GetFloat64("app.tracing.sample-ratio")),
+		)),
+	}
+
+	switch exporter := configs.GetString("app.tracing.exporter"); exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		otlpOptions := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(configs.GetString("app.tracing.otlp.endpoint")),
+		}
+
+		if configs.GetBool("app.tracing.otlp.insecure") {
+			otlpOptions = append(otlpOptions, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), otlpOptions...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// RegisterTracing makes the provider the global one, used by Start and by the MongoDB
+// command monitor, and flushes the pending spans when the application stops
+func RegisterTracing(lifecycle Lifecycle, provider *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	lifecycle.Append(Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+}
@@ -0,0 +1,20 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	. "go.uber.org/fx"
+)
+
+var (
+	Module = Provide(NewTracerProvider)
+
+	// Invokables must come before the routes are registered, as the metrics ones
+	Invokables = Invoke(
+		RegisterTracing,
+		RegisterMiddleware,
+	)
+)
+
+func RegisterMiddleware(app *fiber.App) {
+	app.Use(Middleware)
+}
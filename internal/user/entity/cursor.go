@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"encoding/base64"
+	"github.com/regiszanandrea/posty/internal/apperror"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCursor = apperror.Validation("invalid_cursor", "invalid cursor")
+
+// EncodeCursor points to the last follow relationship returned on a page, their
+// ids already grow with the time they were created, so there is no need for created_at
+func EncodeCursor(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id.Hex()))
+}
+
+func DecodeCursor(cursor string) (*primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	objectId, err := primitive.ObjectIDFromHex(string(raw))
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &objectId, nil
+}
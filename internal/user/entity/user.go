@@ -1,19 +1,26 @@
 package entity
 
 import (
-	"errors"
 	"github.com/go-playground/validator/v10"
+	"github.com/regiszanandrea/posty/internal/apperror"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
 type User struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty"`
 	Username       string             `bson:"username" validate:"required,max=14,alphanum"`
+	Password       string             `bson:"-" json:"password,omitempty" validate:"required,min=8,max=72"`
+	PasswordHash   string             `bson:"password_hash,omitempty" json:"-"`
+	Timezone       string             `bson:"timezone,omitempty" validate:"omitempty,timezone"`
 	CreatedAt      time.Time          `bson:"created_at"`
 	FollowersCount uint               `bson:"followers_count"`
 	FollowingCount uint               `bson:"following_count"`
 	PostsCount     uint               `bson:"posts_count"`
+	// Pulled marks the users whose posts are pulled into the feeds instead of pushed, it
+	// follows FollowersCount as it crosses app.timeline.fan-out-maximum-followers
+	Pulled bool `bson:"pulled,omitempty" json:"-"`
 }
 
 type Follower struct {
@@ -23,6 +30,68 @@ type Follower struct {
 	CreatedAt   time.Time          `bson:"created_at"`
 }
 
+// Counters are the statistics stored on a user, signed so the ones that
+// drifted below zero can still be read and fixed
+type Counters struct {
+	UserID         primitive.ObjectID `bson:"_id"`
+	FollowersCount int64              `bson:"followers_count"`
+	FollowingCount int64              `bson:"following_count"`
+	PostsCount     int64              `bson:"posts_count"`
+}
+
+// UserSummary is the part of a user shown on listings
+type UserSummary struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Username       string             `bson:"username"`
+	FollowersCount uint               `bson:"followers_count"`
+	FollowingCount uint               `bson:"following_count"`
+}
+
+// Connection is a follow relationship joined with the user on the other side of it
+type Connection struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	User *UserSummary       `bson:"user"`
+}
+
+type ListConnectionsRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit" validate:"min=0"`
+}
+
+type UserList struct {
+	Data       []*UserSummary `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func NewUserList(connections []*Connection, limit int) *UserList {
+	list := &UserList{Data: []*UserSummary{}}
+
+	// the users of a relationship can be missing, they still count for the cursor
+	for _, connection := range connections {
+		if connection.User != nil {
+			list.Data = append(list.Data, connection.User)
+		}
+	}
+
+	if len(connections) > 0 && len(connections) == limit {
+		list.NextCursor = EncodeCursor(connections[len(connections)-1].ID)
+	}
+
+	return list
+}
+
+// NewUserSummaryList is a list of users that has no further pages
+func NewUserSummaryList(users []*UserSummary) *UserList {
+	list := &UserList{Data: users}
+
+	if list.Data == nil {
+		list.Data = []*UserSummary{}
+	}
+
+	return list
+}
+
 type FollowRequest struct {
 	FollowerID  string `json:"follower_id" validate:"required"`
 	FollowingID string `json:"user_id" validate:"required"`
@@ -33,14 +102,51 @@ type UnfollowRequest struct {
 	FollowingID string `json:"user_id" validate:"required"`
 }
 
+// UpdateTimezoneRequest sets the IANA timezone, like America/Sao_Paulo, that the quota of posts of the user follows
+type UpdateTimezoneRequest struct {
+	UserID   string `json:"user_id" validate:"required"`
+	Timezone string `json:"timezone" validate:"required,timezone"`
+}
+
 var validate = validator.New()
 
+// HashPassword replaces the plain password by its bcrypt hash, so it is never persisted or returned
+func (user *User) HashPassword() error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = string(hash)
+	user.Password = ""
+
+	return nil
+}
+
+func (user *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func ValidateStruct(st interface{}) []error {
+	var errs []error
+
+	err := validate.Struct(st)
+	if err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
+		}
+	}
+
+	return errs
+}
+
 func Validate(user *User) []error {
 	var errs []error
 	err := validate.Struct(user)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
-			errs = append(errs, errors.New("field: "+err.StructField()+" "+err.Tag()))
+			errs = append(errs, apperror.Validation("invalid_field", "field: "+err.StructField()+" "+err.Tag()))
 		}
 	}
 
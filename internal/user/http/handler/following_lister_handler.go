@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/regiszanandrea/posty/internal/user/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FollowingListerHandler struct {
+	service service.Service
+}
+
+func NewFollowingListerHandler(s service.Service) *FollowingListerHandler {
+	return &FollowingListerHandler{
+		service: s,
+	}
+}
+
+func (h *FollowingListerHandler) ListFollowing(ctx *fiber.Ctx) error {
+	list := new(entity.ListConnectionsRequest)
+
+	if err := ctx.QueryParser(list); err != nil {
+		return apperror.Validation("invalid_query", err.Error())
+	}
+
+	list.UserID = ctx.Params("id")
+
+	users, errors := h.service.ListFollowing(ctx.UserContext(), list)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(users)
+}
@@ -7,8 +7,13 @@ import (
 var (
 	Module = Provide(
 		NewUserFinderHandler,
+		NewUsernameFinderHandler,
 		NewUserCreatorHandler,
 		NewFollowUserHandler,
 		NewUnfollowUserHandler,
+		NewFollowersListerHandler,
+		NewFollowingListerHandler,
+		NewRelationshipHandler,
+		NewTimezoneUpdaterHandler,
 	)
 )
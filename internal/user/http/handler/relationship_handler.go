@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/regiszanandrea/posty/internal/user/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RelationshipHandler struct {
+	service service.Service
+}
+
+func NewRelationshipHandler(s service.Service) *RelationshipHandler {
+	return &RelationshipHandler{
+		service: s,
+	}
+}
+
+// IsFollowing answers if the user :id follows the user :userId
+func (h *RelationshipHandler) IsFollowing(ctx *fiber.Ctx) error {
+	following, err := h.service.IsFollowing(ctx.UserContext(), ctx.Params("id"), ctx.Params("userId"))
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{"following": following})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/regiszanandrea/posty/internal/user/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TimezoneUpdaterHandler struct {
+	service service.Service
+}
+
+func NewTimezoneUpdaterHandler(s service.Service) *TimezoneUpdaterHandler {
+	return &TimezoneUpdaterHandler{
+		service: s,
+	}
+}
+
+func (h *TimezoneUpdaterHandler) UpdateTimezone(ctx *fiber.Ctx) error {
+	if ctx.Params("id") != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only change its own timezone")
+	}
+
+	request := new(entity.UpdateTimezoneRequest)
+
+	if err := ctx.BodyParser(request); err != nil {
+		return apperror.Validation("invalid_body", err.Error())
+	}
+
+	request.UserID = ctx.Params("id")
+
+	errors := h.service.UpdateTimezone(ctx.UserContext(), request)
+
+	if errors != nil {
+		return apperror.FromErrors(errors)
+	}
+
+	return ctx.JSON(fiber.Map{"message": "timezone updated with success"})
+}
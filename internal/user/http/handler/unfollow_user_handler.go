@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/internal/user/service"
 
@@ -18,14 +20,17 @@ func NewUnfollowUserHandler(s service.Service) *UnfollowUserHandler {
 }
 
 func (h *UnfollowUserHandler) UnfollowUser(ctx *fiber.Ctx) error {
+	if ctx.Params("followerId") != middleware.AuthenticatedUserID(ctx) {
+		return apperror.Forbidden("forbidden", "a user can only unfollow as itself")
+	}
 
-	err := h.service.Unfollow(&entity.UnfollowRequest{
+	err := h.service.Unfollow(ctx.UserContext(), &entity.UnfollowRequest{
 		FollowerID:  ctx.Params("followerId"),
 		FollowingID: ctx.Params("userId"),
 	})
 
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(err.Error())
+		return err
 	}
 
 	return ctx.JSON(fiber.Map{"message": "user unfollowed with success"})
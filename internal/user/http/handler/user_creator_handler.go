@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"github.com/regiszanandrea/posty/internal/apperror"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/internal/user/service"
 
@@ -21,21 +22,13 @@ func (h UserCreatorHandler) CreateUser(ctx *fiber.Ctx) error {
 	user := new(entity.User)
 
 	if err := ctx.BodyParser(user); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return apperror.Validation("invalid_body", err.Error())
 	}
 
-	id, errors := h.service.CreateUser(user)
+	id, errors := h.service.CreateUser(ctx.UserContext(), user)
 
 	if errors != nil {
-		var errorsStr []string
-
-		for _, e := range errors {
-			errorsStr = append(errorsStr, e.Error())
-		}
-
-		return ctx.Status(fiber.StatusBadRequest).JSON(errorsStr)
+		return apperror.FromErrors(errors)
 	}
 
 	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": id})
@@ -18,14 +18,14 @@ func NewUserFinderHandler(s service.Service) *UserFinderHandler {
 
 func (h UserFinderHandler) FindUser(ctx *fiber.Ctx) error {
 
-	user, err := h.service.GetUser(ctx.Params("id"))
+	user, err := h.service.GetUser(ctx.UserContext(), ctx.Params("id"))
 
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(err.Error())
+		return err
 	}
 
 	if user == nil {
-		return ctx.Status(fiber.StatusNotFound).JSON("no user found")
+		return service.ErrUserNotFound
 	}
 
 	return ctx.JSON(user)
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/regiszanandrea/posty/internal/user/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UsernameFinderHandler struct {
+	service service.Service
+}
+
+func NewUsernameFinderHandler(s service.Service) *UsernameFinderHandler {
+	return &UsernameFinderHandler{
+		service: s,
+	}
+}
+
+func (h UsernameFinderHandler) FindUserByUsername(ctx *fiber.Ctx) error {
+
+	user, err := h.service.GetUserByUsername(ctx.UserContext(), ctx.Params("username"))
+
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return service.ErrUserNotFound
+	}
+
+	return ctx.JSON(user)
+}
@@ -2,20 +2,36 @@ package http
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/regiszanandrea/posty/internal/auth/middleware"
+	"github.com/regiszanandrea/posty/internal/ratelimit"
+	"github.com/regiszanandrea/posty/internal/timeout"
 	"github.com/regiszanandrea/posty/internal/user/http/handler"
 )
 
 func RegisterUserRoutes(
 	app *fiber.App,
 	userFinderHandler *handler.UserFinderHandler,
+	usernameFinderHandler *handler.UsernameFinderHandler,
 	userCreatorHandler *handler.UserCreatorHandler,
 	followUserHandler *handler.FollowUserHandler,
 	unfollowUserHandler *handler.UnfollowUserHandler,
+	followersListerHandler *handler.FollowersListerHandler,
+	followingListerHandler *handler.FollowingListerHandler,
+	relationshipHandler *handler.RelationshipHandler,
+	timezoneUpdaterHandler *handler.TimezoneUpdaterHandler,
+	authMiddleware *middleware.AuthMiddleware,
+	timeoutMiddleware *timeout.Middleware,
+	rateLimitMiddleware *ratelimit.Middleware,
 ) {
 	group := app.Group("/users")
 
-	group.Get("/:id", userFinderHandler.FindUser)
-	group.Post("/", userCreatorHandler.CreateUser)
-	group.Post("/:followerId/follow/:userId", followUserHandler.FollowUser)
-	group.Post("/:followerId/unfollow/:userId", unfollowUserHandler.UnfollowUser)
+	group.Get("/:id", timeoutMiddleware.Handle("find-user"), userFinderHandler.FindUser)
+	group.Get("/by-username/:username", timeoutMiddleware.Handle("find-user-by-username"), usernameFinderHandler.FindUserByUsername)
+	group.Get("/:id/followers", timeoutMiddleware.Handle("list-followers"), followersListerHandler.ListFollowers)
+	group.Get("/:id/following", timeoutMiddleware.Handle("list-following"), followingListerHandler.ListFollowing)
+	group.Get("/:id/following/:userId", timeoutMiddleware.Handle("get-relationship"), relationshipHandler.IsFollowing)
+	group.Post("/", timeoutMiddleware.Handle("create-user"), userCreatorHandler.CreateUser)
+	group.Put("/:id/timezone", timeoutMiddleware.Handle("update-timezone"), authMiddleware.Handle, timezoneUpdaterHandler.UpdateTimezone)
+	group.Post("/:followerId/follow/:userId", timeoutMiddleware.Handle("follow"), authMiddleware.Handle, rateLimitMiddleware.Handle("follow"), followUserHandler.FollowUser)
+	group.Post("/:followerId/unfollow/:userId", timeoutMiddleware.Handle("unfollow"), authMiddleware.Handle, rateLimitMiddleware.Handle("follow"), unfollowUserHandler.UnfollowUser)
 }
@@ -2,22 +2,50 @@ package follower_repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 type Repository interface {
-	Follow(followerId, followingId string) (string, error)
-	Unfollow(followerId, followingId string) error
-	GetFollowingUsers(followerId string) ([]string, error)
+	Follow(ctx context.Context, followerId, followingId string) (bool, error)
+	Unfollow(ctx context.Context, followerId, followingId string) (bool, error)
+	GetFollowingUsers(ctx context.Context, followerId string) ([]string, error)
+	GetFollowers(ctx context.Context, userId string) ([]string, error)
+	FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error)
+	FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error)
+	IsFollowing(ctx context.Context, followerId, followingId string) (bool, error)
+	ListFollowers(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error)
+	ListFollowing(ctx context.Context, followerId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error)
+	CountFollowers(ctx context.Context, userIds []string) (map[string]int64, error)
+	CountFollowing(ctx context.Context, followerIds []string) (map[string]int64, error)
+}
+
+// NewRepository returns the repository of the driver set on app.storage.driver
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewFollowerRepository(client, configs), nil
+	case "memory":
+		return NewMemoryFollowerRepository(database), nil
+	case "postgres":
+		return NewPostgresFollowerRepository(db), nil
+	default:
+		return nil, fmt.Errorf("follower_repository: unknown storage driver %q", driver)
+	}
 }
 
 type FollowerRepository struct {
-	collection *mongo.Collection
+	collection      *mongo.Collection
+	usersCollection *mongo.Collection
 }
 
 func NewFollowerRepository(client *mongo.Client, configs *viper.Viper) *FollowerRepository {
@@ -27,65 +55,106 @@ func NewFollowerRepository(client *mongo.Client, configs *viper.Viper) *Follower
 		configs.GetString("app.mongodb.follower-collection"),
 	)
 
+	usersCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.user-collection"),
+	)
+
 	return &FollowerRepository{
-		collection: followersCollection,
+		collection:      followersCollection,
+		usersCollection: usersCollection,
 	}
 }
 
-func (repo *FollowerRepository) Follow(followerId, followingId string) (string, error) {
-	followerIdObjectId, err := primitive.ObjectIDFromHex(followerId)
+// Follow creates the relationship and increments the counters of both users in a single
+// transaction, following twice keeps one relationship and returns false
+func (repo *FollowerRepository) Follow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
 
 	if err != nil {
-		return "", err
+		return false, err
 	}
 
-	followingIdObjectId, err := primitive.ObjectIDFromHex(followingId)
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
 
 	if err != nil {
-		return "", err
+		return false, err
 	}
 
-	result, err := repo.collection.InsertOne(context.TODO(), bson.M{
-		"follower_id": followerIdObjectId,
-		"user_id":     followingIdObjectId,
+	err = mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		_, err := repo.collection.InsertOne(ctx, bson.M{
+			"follower_id": followerIdObjectId,
+			"user_id":     followingIdObjectId,
+			"created_at":  time.Now(),
+		})
+
+		if err != nil {
+			if mongodb.IsDup(err) {
+				return mongodb.ErrDuplicateKey
+			}
+			return err
+		}
+
+		return repo.incrementCounters(ctx, followerIdObjectId, followingIdObjectId, 1)
 	})
 
-	if err != nil {
-		return "", err
+	if err == mongodb.ErrDuplicateKey {
+		return false, nil
 	}
 
-	objectId := result.InsertedID.(primitive.ObjectID)
+	if err != nil {
+		return false, err
+	}
 
-	return objectId.Hex(), err
+	return true, nil
 }
 
-func (repo *FollowerRepository) Unfollow(followerId, followingId string) error {
-	followerIdObjectId, err := primitive.ObjectIDFromHex(followerId)
+// Unfollow removes the relationship and decrements the counters of both users in a single
+// transaction, it returns false when there was no relationship and nothing was changed
+func (repo *FollowerRepository) Unfollow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
 
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	followingIdObjectId, err := primitive.ObjectIDFromHex(followingId)
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
 
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	_, err = repo.collection.DeleteOne(context.TODO(), bson.M{
-		"follower_id": followerIdObjectId,
-		"user_id":     followingIdObjectId,
+	removed := false
+
+	err = mongodb.Transaction(ctx, repo.collection.Database().Client(), func(ctx mongo.SessionContext) error {
+		result, err := repo.collection.DeleteOne(ctx, bson.M{
+			"follower_id": followerIdObjectId,
+			"user_id":     followingIdObjectId,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		removed = result.DeletedCount > 0
+
+		if !removed {
+			return nil
+		}
+
+		return repo.incrementCounters(ctx, followerIdObjectId, followingIdObjectId, -1)
 	})
 
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return removed, nil
 }
 
-func (repo *FollowerRepository) GetFollowingUsers(followerID string) ([]string, error) {
-	objectId, err := primitive.ObjectIDFromHex(followerID)
+func (repo *FollowerRepository) GetFollowingUsers(ctx context.Context, followerID string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerID)
 
 	if err != nil {
 		return nil, err
@@ -94,11 +163,17 @@ func (repo *FollowerRepository) GetFollowingUsers(followerID string) ([]string,
 	var result []string
 
 	curr, err := repo.collection.Find(
-		context.TODO(), bson.M{"follower_id": objectId},
+		ctx, bson.M{"follower_id": objectId},
 		options.Find().SetProjection(bson.D{{"following_id", 0}}),
 	)
 
-	for curr.Next(context.TODO()) {
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
 		var follower entity.Follower
 		if err := curr.Decode(&follower); err != nil {
 			return nil, err
@@ -107,5 +182,288 @@ func (repo *FollowerRepository) GetFollowingUsers(followerID string) ([]string,
 		result = append(result, follower.FollowingID.Hex())
 	}
 
-	return result, nil
+	return result, curr.Err()
+}
+
+func (repo *FollowerRepository) GetFollowers(ctx context.Context, userId string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	curr, err := repo.collection.Find(
+		ctx, bson.M{"user_id": objectId},
+		options.Find().SetProjection(bson.D{{"follower_id", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var follower entity.Follower
+		if err := curr.Decode(&follower); err != nil {
+			return nil, err
+		}
+
+		result = append(result, follower.FollowerID.Hex())
+	}
+
+	return result, curr.Err()
+}
+
+// FilterFollowers returns which of the candidates follow the user
+func (repo *FollowerRepository) FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var candidatesObjectId []primitive.ObjectID
+
+	for _, candidate := range candidates {
+		objId, err := mongodb.ObjectIDFromHex(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		candidatesObjectId = append(candidatesObjectId, objId)
+	}
+
+	var result []string
+
+	curr, err := repo.collection.Find(
+		ctx,
+		bson.M{"user_id": objectId, "follower_id": bson.M{"$in": candidatesObjectId}},
+		options.Find().SetProjection(bson.D{{"follower_id", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var follower entity.Follower
+		if err := curr.Decode(&follower); err != nil {
+			return nil, err
+		}
+
+		result = append(result, follower.FollowerID.Hex())
+	}
+
+	return result, curr.Err()
+}
+
+// FilterFollowing returns which of the candidates the follower follows
+func (repo *FollowerRepository) FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var candidatesObjectId []primitive.ObjectID
+
+	for _, candidate := range candidates {
+		objId, err := mongodb.ObjectIDFromHex(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		candidatesObjectId = append(candidatesObjectId, objId)
+	}
+
+	var result []string
+
+	curr, err := repo.collection.Find(
+		ctx,
+		bson.M{"follower_id": objectId, "user_id": bson.M{"$in": candidatesObjectId}},
+		options.Find().SetProjection(bson.D{{"user_id", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var follower entity.Follower
+		if err := curr.Decode(&follower); err != nil {
+			return nil, err
+		}
+
+		result = append(result, follower.FollowingID.Hex())
+	}
+
+	return result, curr.Err()
+}
+
+func (repo *FollowerRepository) IsFollowing(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	count, err := repo.collection.CountDocuments(
+		ctx,
+		bson.M{"follower_id": followerIdObjectId, "user_id": followingIdObjectId},
+		options.Count().SetLimit(1),
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ListFollowers returns the users following userId, from the newest follow to the oldest
+func (repo *FollowerRepository) ListFollowers(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(ctx, "user_id", "follower_id", userId, cursor, limit)
+}
+
+// ListFollowing returns the users followed by followerId, from the newest follow to the oldest
+func (repo *FollowerRepository) ListFollowing(ctx context.Context, followerId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(ctx, "follower_id", "user_id", followerId, cursor, limit)
+}
+
+// listConnections matches the relationships by field and joins the users referenced by joinField
+func (repo *FollowerRepository) listConnections(ctx context.Context, field, joinField, id string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{field, objectId}}
+
+	if cursor != nil {
+		filter = append(filter, bson.E{"_id", bson.D{{"$lt", cursor}}})
+	}
+
+	curr, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		{{"$match", filter}},
+		{{"$sort", bson.D{{"_id", -1}}}},
+		{{"$limit", limit}},
+		{{
+			"$lookup",
+			bson.D{
+				{"from", repo.usersCollection.Name()},
+				{"localField", joinField},
+				{"foreignField", "_id"},
+				{"as", "user"},
+			},
+		}},
+		{{"$unwind", bson.D{{"path", "$user"}, {"preserveNullAndEmptyArrays", true}}}},
+		{{
+			"$project",
+			bson.D{
+				{"user._id", 1},
+				{"user.username", 1},
+				{"user.followers_count", 1},
+				{"user.following_count", 1},
+			},
+		}},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*entity.Connection
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var connection entity.Connection
+		if err := curr.Decode(&connection); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &connection)
+	}
+
+	return result, curr.Err()
+}
+
+// CountFollowers returns how many followers each user has, users without followers are left out
+func (repo *FollowerRepository) CountFollowers(ctx context.Context, userIds []string) (map[string]int64, error) {
+	return repo.countBy(ctx, "user_id", userIds)
+}
+
+// CountFollowing returns how many users each follower follows, followers following no one are left out
+func (repo *FollowerRepository) CountFollowing(ctx context.Context, followerIds []string) (map[string]int64, error) {
+	return repo.countBy(ctx, "follower_id", followerIds)
+}
+
+func (repo *FollowerRepository) countBy(ctx context.Context, field string, ids []string) (map[string]int64, error) {
+	var objectIds []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		objectIds = append(objectIds, objId)
+	}
+
+	curr, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		{{"$match", bson.D{{field, bson.D{{"$in", objectIds}}}}}},
+		{{"$group", bson.D{{"_id", "$" + field}, {"count", bson.D{{"$sum", 1}}}}}},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64)
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var count struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		}
+		if err := curr.Decode(&count); err != nil {
+			return nil, err
+		}
+
+		result[count.ID.Hex()] = count.Count
+	}
+
+	return result, curr.Err()
+}
+
+func (repo *FollowerRepository) incrementCounters(ctx context.Context, followerId, followingId primitive.ObjectID, value int) error {
+	_, err := repo.usersCollection.UpdateOne(ctx, bson.M{"_id": followerId},
+		bson.D{{"$inc", bson.D{{"following_count", value}}}},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.usersCollection.UpdateOne(ctx, bson.M{"_id": followingId},
+		bson.D{{"$inc", bson.D{{"followers_count", value}}}},
+	)
+
+	return err
 }
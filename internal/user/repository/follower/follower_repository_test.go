@@ -2,10 +2,15 @@ package follower_repository
 
 import (
 	"context"
+	"database/sql"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
 	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"github.com/regiszanandrea/posty/internal/user/repository/user"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,7 +24,9 @@ func TestFollowerRepository(t *testing.T) {
 }
 
 var (
-	followerRepository *FollowerRepository
+	followerRepository Repository
+	userRepository     user_repository.Repository
+	db                 *sql.DB
 	client             *mongo.Client
 	configs            *viper.Viper
 )
@@ -29,24 +36,74 @@ var _ = BeforeSuite(func() {
 
 	client = mongodb.NewMongoDBClient(configs)
 
-	err := client.Connect(context.Background())
+	// the suite runs against the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		err = mongodb.CreateIndexes(client, configs, context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// the follows change the counters of the users, so both share the database
+	database := memory.NewDatabase()
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
 
 	if err != nil {
 		panic(err)
 	}
 
-	err = mongodb.CreateIndexes(client, configs, context.Background())
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	followerRepository, err = NewRepository(client, db, database, configs)
 
 	if err != nil {
 		panic(err)
 	}
 
-	followerRepository = NewFollowerRepository(
-		client, configs,
-	)
+	userRepository, err = user_repository.NewRepository(client, db, database, configs)
+
+	if err != nil {
+		panic(err)
+	}
 })
 
 var _ = AfterSuite(func() {
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE followers, users")
+
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
+	usersCollection := client.Database(
+		configs.GetString("app.mongodb.database"),
+	).Collection(
+		configs.GetString("app.mongodb.user-collection"),
+	)
+
 	followersCollection := client.Database(
 		configs.GetString("app.mongodb.database"),
 	).Collection(
@@ -58,15 +115,51 @@ var _ = AfterSuite(func() {
 	if err != nil {
 		panic(err)
 	}
+
+	_, err = usersCollection.DeleteMany(context.Background(), bson.M{})
+
+	if err != nil {
+		panic(err)
+	}
 })
 
 var _ = Describe("FollowerRepository suite test", func() {
 	Describe("Following a User", func() {
 		Context("when its given a follower and the followed user", func() {
 			It("persist the following between the two users", func() {
-				_, err := followerRepository.Follow(primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+				created, err := followerRepository.Follow(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
 
 				Expect(err).To(BeNil())
+				Expect(created).To(BeTrue())
+			})
+		})
+
+		Context("when the follower already follows the user", func() {
+			It("keeps a single following and updates the counters once", func() {
+				followerId := primitive.NewObjectID()
+				userId := primitive.NewObjectID()
+
+				_, err := userRepository.Create(context.Background(), &entity.User{ID: followerId, Username: followerId.Hex()})
+
+				Expect(err).To(BeNil())
+
+				_, err = userRepository.Create(context.Background(), &entity.User{ID: userId, Username: userId.Hex()})
+
+				Expect(err).To(BeNil())
+
+				_, _ = followerRepository.Follow(context.Background(), followerId.Hex(), userId.Hex())
+				created, err := followerRepository.Follow(context.Background(), followerId.Hex(), userId.Hex())
+
+				Expect(err).To(BeNil())
+				Expect(created).To(BeFalse())
+
+				followers, _ := followerRepository.GetFollowers(context.Background(), userId.Hex())
+
+				Expect(followers).To(HaveLen(1))
+
+				user, _ := userRepository.Find(context.Background(), userId.Hex())
+
+				Expect(user.FollowersCount).To(BeEquivalentTo(1))
 			})
 		})
 	})
@@ -77,19 +170,21 @@ var _ = Describe("FollowerRepository suite test", func() {
 				followerId := primitive.NewObjectID().Hex()
 				userId := primitive.NewObjectID().Hex()
 
-				_, err := followerRepository.Follow(followerId, userId)
+				_, err := followerRepository.Follow(context.Background(), followerId, userId)
 
-				err = followerRepository.Unfollow(followerId, userId)
+				removed, err := followerRepository.Unfollow(context.Background(), followerId, userId)
 
 				Expect(err).To(BeNil())
+				Expect(removed).To(BeTrue())
 			})
 		})
 
 		Context("when there is no following", func() {
-			It("not returns a error", func() {
-				err := followerRepository.Unfollow(primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+			It("not returns a error and removes nothing", func() {
+				removed, err := followerRepository.Unfollow(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
 
 				Expect(err).To(BeNil())
+				Expect(removed).To(BeFalse())
 			})
 		})
 	})
@@ -101,10 +196,10 @@ var _ = Describe("FollowerRepository suite test", func() {
 
 				numberOfUsersFollowed := 10
 				for i := 0; i < numberOfUsersFollowed; i++ {
-					followerRepository.Follow(followerId, primitive.NewObjectID().Hex())
+					followerRepository.Follow(context.Background(), followerId, primitive.NewObjectID().Hex())
 				}
 
-				users, err := followerRepository.GetFollowingUsers(followerId)
+				users, err := followerRepository.GetFollowingUsers(context.Background(), followerId)
 
 				Expect(err).To(BeNil())
 				Expect(len(users)).To(Equal(numberOfUsersFollowed))
@@ -115,11 +210,91 @@ var _ = Describe("FollowerRepository suite test", func() {
 			It("returns nothing", func() {
 				followerId := primitive.NewObjectID().Hex()
 
-				users, err := followerRepository.GetFollowingUsers(followerId)
+				users, err := followerRepository.GetFollowingUsers(context.Background(), followerId)
 
 				Expect(err).To(BeNil())
 				Expect(users).To(BeEmpty())
 			})
 		})
 	})
+
+	Describe("Getting who follows a user", func() {
+		Context("when there is followers", func() {
+			It("returns all of them", func() {
+				userId := primitive.NewObjectID().Hex()
+
+				numberOfFollowers := 5
+				for i := 0; i < numberOfFollowers; i++ {
+					followerRepository.Follow(context.Background(), primitive.NewObjectID().Hex(), userId)
+				}
+
+				followers, err := followerRepository.GetFollowers(context.Background(), userId)
+
+				Expect(err).To(BeNil())
+				Expect(len(followers)).To(Equal(numberOfFollowers))
+			})
+		})
+	})
+
+	Describe("Checking if a user follows another", func() {
+		Context("when there is a following", func() {
+			It("returns true", func() {
+				followerId := primitive.NewObjectID().Hex()
+				userId := primitive.NewObjectID().Hex()
+
+				_, _ = followerRepository.Follow(context.Background(), followerId, userId)
+
+				following, err := followerRepository.IsFollowing(context.Background(), followerId, userId)
+
+				Expect(err).To(BeNil())
+				Expect(following).To(BeTrue())
+
+				following, err = followerRepository.IsFollowing(context.Background(), userId, followerId)
+
+				Expect(err).To(BeNil())
+				Expect(following).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Filtering the users a user follows", func() {
+		Context("when it follows some of the candidates", func() {
+			It("returns only them", func() {
+				followerId := primitive.NewObjectID().Hex()
+				followed := primitive.NewObjectID().Hex()
+				notFollowed := primitive.NewObjectID().Hex()
+
+				_, _ = followerRepository.Follow(context.Background(), followerId, followed)
+				_, _ = followerRepository.Follow(context.Background(), notFollowed, followerId)
+
+				following, err := followerRepository.FilterFollowing(context.Background(), followerId, []string{followed, notFollowed})
+
+				Expect(err).To(BeNil())
+				Expect(following).To(Equal([]string{followed}))
+			})
+		})
+	})
+
+	Describe("Listing followers", func() {
+		Context("when its given a cursor", func() {
+			It("returns only the followers after it", func() {
+				userId := primitive.NewObjectID().Hex()
+				limit := 3
+
+				for i := 0; i < 5; i++ {
+					_, _ = followerRepository.Follow(context.Background(), primitive.NewObjectID().Hex(), userId)
+				}
+
+				firstPage, err := followerRepository.ListFollowers(context.Background(), userId, nil, limit)
+
+				Expect(err).To(BeNil())
+				Expect(firstPage).To(HaveLen(limit))
+
+				secondPage, err := followerRepository.ListFollowers(context.Background(), userId, &firstPage[len(firstPage)-1].ID, limit)
+
+				Expect(err).To(BeNil())
+				Expect(secondPage).To(HaveLen(2))
+			})
+		})
+	})
 })
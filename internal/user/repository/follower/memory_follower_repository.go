@@ -0,0 +1,336 @@
+package follower_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+)
+
+// MemoryFollowerRepository keeps the relationships on a memory.Database and, as the
+// FollowerRepository, changes the counters of the users with them
+type MemoryFollowerRepository struct {
+	database *memory.Database
+}
+
+func NewMemoryFollowerRepository(database *memory.Database) *MemoryFollowerRepository {
+	return &MemoryFollowerRepository{
+		database: database,
+	}
+}
+
+func (repo *MemoryFollowerRepository) Follow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if repo.find(followerIdObjectId, followingIdObjectId) >= 0 {
+		return false, nil
+	}
+
+	repo.database.Followers = append(repo.database.Followers, &entity.Follower{
+		ID:          primitive.NewObjectID(),
+		FollowerID:  followerIdObjectId,
+		FollowingID: followingIdObjectId,
+		CreatedAt:   memory.Now(),
+	})
+
+	repo.incrementCounters(followerIdObjectId, followingIdObjectId, 1)
+
+	return true, nil
+}
+
+func (repo *MemoryFollowerRepository) Unfollow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.find(followerIdObjectId, followingIdObjectId)
+
+	if i < 0 {
+		return false, nil
+	}
+
+	followers := repo.database.Followers
+
+	// a new slice, so the ones being read without the lock are not changed
+	repo.database.Followers = append(append([]*entity.Follower{}, followers[:i]...), followers[i+1:]...)
+
+	repo.incrementCounters(followerIdObjectId, followingIdObjectId, -1)
+
+	return true, nil
+}
+
+func (repo *MemoryFollowerRepository) GetFollowingUsers(ctx context.Context, followerId string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, follower := range repo.followers() {
+		if follower.FollowerID == objectId {
+			result = append(result, follower.FollowingID.Hex())
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryFollowerRepository) GetFollowers(ctx context.Context, userId string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, follower := range repo.followers() {
+		if follower.FollowingID == objectId {
+			result = append(result, follower.FollowerID.Hex())
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryFollowerRepository) FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidatesObjectId := map[primitive.ObjectID]bool{}
+
+	for _, candidate := range candidates {
+		objId, err := mongodb.ObjectIDFromHex(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		candidatesObjectId[objId] = true
+	}
+
+	var result []string
+
+	for _, follower := range repo.followers() {
+		if follower.FollowingID == objectId && candidatesObjectId[follower.FollowerID] {
+			result = append(result, follower.FollowerID.Hex())
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryFollowerRepository) FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidatesObjectId := map[primitive.ObjectID]bool{}
+
+	for _, candidate := range candidates {
+		objId, err := mongodb.ObjectIDFromHex(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		candidatesObjectId[objId] = true
+	}
+
+	var result []string
+
+	for _, follower := range repo.followers() {
+		if follower.FollowerID == objectId && candidatesObjectId[follower.FollowingID] {
+			result = append(result, follower.FollowingID.Hex())
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryFollowerRepository) IsFollowing(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	return repo.find(followerIdObjectId, followingIdObjectId) >= 0, nil
+}
+
+func (repo *MemoryFollowerRepository) ListFollowers(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(userId, cursor, limit, func(follower *entity.Follower) (primitive.ObjectID, primitive.ObjectID) {
+		return follower.FollowingID, follower.FollowerID
+	})
+}
+
+func (repo *MemoryFollowerRepository) ListFollowing(ctx context.Context, followerId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(followerId, cursor, limit, func(follower *entity.Follower) (primitive.ObjectID, primitive.ObjectID) {
+		return follower.FollowerID, follower.FollowingID
+	})
+}
+
+// listConnections matches the relationships by the first id that sides returns and joins the user of the second one
+func (repo *MemoryFollowerRepository) listConnections(
+	id string,
+	cursor *primitive.ObjectID,
+	limit int,
+	sides func(follower *entity.Follower) (primitive.ObjectID, primitive.ObjectID),
+) ([]*entity.Connection, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var matched []*entity.Follower
+
+	for _, follower := range repo.database.Followers {
+		matchedId, _ := sides(follower)
+
+		if matchedId == objectId && (cursor == nil || memory.CompareIDs(follower.ID, *cursor) < 0) {
+			matched = append(matched, follower)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return memory.CompareIDs(matched[i].ID, matched[j].ID) > 0
+	})
+
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+
+	var result []*entity.Connection
+
+	for _, follower := range matched {
+		connection := &entity.Connection{ID: follower.ID}
+
+		if _, joinedId := sides(follower); repo.database.Users[joinedId] != nil {
+			user := repo.database.Users[joinedId]
+
+			connection.User = &entity.UserSummary{
+				ID:             user.ID,
+				Username:       user.Username,
+				FollowersCount: user.FollowersCount,
+				FollowingCount: user.FollowingCount,
+			}
+		}
+
+		result = append(result, connection)
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryFollowerRepository) CountFollowers(ctx context.Context, userIds []string) (map[string]int64, error) {
+	return repo.countBy(userIds, func(follower *entity.Follower) primitive.ObjectID {
+		return follower.FollowingID
+	})
+}
+
+func (repo *MemoryFollowerRepository) CountFollowing(ctx context.Context, followerIds []string) (map[string]int64, error) {
+	return repo.countBy(followerIds, func(follower *entity.Follower) primitive.ObjectID {
+		return follower.FollowerID
+	})
+}
+
+func (repo *MemoryFollowerRepository) countBy(ids []string, field func(follower *entity.Follower) primitive.ObjectID) (map[string]int64, error) {
+	objectIds := map[primitive.ObjectID]bool{}
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		objectIds[objId] = true
+	}
+
+	result := make(map[string]int64)
+
+	for _, follower := range repo.followers() {
+		if objectIds[field(follower)] {
+			result[field(follower).Hex()]++
+		}
+	}
+
+	return result, nil
+}
+
+// followers returns the relationships as they are now, the slice is replaced and never changed in place
+func (repo *MemoryFollowerRepository) followers() []*entity.Follower {
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	return repo.database.Followers
+}
+
+// find returns the index of the relationship or -1, the lock must be held
+func (repo *MemoryFollowerRepository) find(followerId, followingId primitive.ObjectID) int {
+	for i, follower := range repo.database.Followers {
+		if follower.FollowerID == followerId && follower.FollowingID == followingId {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// incrementCounters changes the users as the follower one does, the lock must be held
+func (repo *MemoryFollowerRepository) incrementCounters(followerId, followingId primitive.ObjectID, value int) {
+	if user, ok := repo.database.Users[followerId]; ok {
+		updated := *user
+		memory.Increment(&updated.FollowingCount, value)
+		repo.database.Users[followerId] = &updated
+	}
+
+	if user, ok := repo.database.Users[followingId]; ok {
+		updated := *user
+		memory.Increment(&updated.FollowersCount, value)
+		repo.database.Users[followingId] = &updated
+	}
+}
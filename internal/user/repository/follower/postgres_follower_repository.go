@@ -0,0 +1,338 @@
+package follower_repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostgresFollowerRepository keeps the relationships on the followers table and, as the
+// FollowerRepository, changes the counters of the users in the same transaction
+type PostgresFollowerRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresFollowerRepository(db *sql.DB) *PostgresFollowerRepository {
+	return &PostgresFollowerRepository{
+		db: db,
+	}
+}
+
+func (repo *PostgresFollowerRepository) Follow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	created := false
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO followers (id, follower_id, user_id, created_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (follower_id, user_id) DO NOTHING`,
+			primitive.NewObjectID().Hex(),
+			followerIdObjectId.Hex(),
+			followingIdObjectId.Hex(),
+			postgres.Now(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		inserted, err := result.RowsAffected()
+
+		if err != nil || inserted == 0 {
+			return err
+		}
+
+		created = true
+
+		return incrementCounters(ctx, tx, followerIdObjectId, followingIdObjectId, 1)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (repo *PostgresFollowerRepository) Unfollow(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+
+	err = postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM followers WHERE follower_id = $1 AND user_id = $2`,
+			followerIdObjectId.Hex(),
+			followingIdObjectId.Hex(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		removed = true
+
+		return incrementCounters(ctx, tx, followerIdObjectId, followingIdObjectId, -1)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+func (repo *PostgresFollowerRepository) GetFollowingUsers(ctx context.Context, followerId string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryIDs(ctx, repo.db, `SELECT user_id FROM followers WHERE follower_id = $1`, objectId.Hex())
+}
+
+func (repo *PostgresFollowerRepository) GetFollowers(ctx context.Context, userId string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryIDs(ctx, repo.db, `SELECT follower_id FROM followers WHERE user_id = $1`, objectId.Hex())
+}
+
+func (repo *PostgresFollowerRepository) FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidatesObjectId, err := toObjectIDs(candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryIDs(
+		ctx,
+		repo.db,
+		`SELECT follower_id FROM followers WHERE user_id = $1 AND follower_id = ANY($2)`,
+		objectId.Hex(),
+		postgres.IDs(candidatesObjectId),
+	)
+}
+
+func (repo *PostgresFollowerRepository) FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error) {
+	objectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	candidatesObjectId, err := toObjectIDs(candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryIDs(
+		ctx,
+		repo.db,
+		`SELECT user_id FROM followers WHERE follower_id = $1 AND user_id = ANY($2)`,
+		objectId.Hex(),
+		postgres.IDs(candidatesObjectId),
+	)
+}
+
+func (repo *PostgresFollowerRepository) IsFollowing(ctx context.Context, followerId, followingId string) (bool, error) {
+	followerIdObjectId, err := mongodb.ObjectIDFromHex(followerId)
+
+	if err != nil {
+		return false, err
+	}
+
+	followingIdObjectId, err := mongodb.ObjectIDFromHex(followingId)
+
+	if err != nil {
+		return false, err
+	}
+
+	var following bool
+
+	err = repo.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM followers WHERE follower_id = $1 AND user_id = $2)`,
+		followerIdObjectId.Hex(),
+		followingIdObjectId.Hex(),
+	).Scan(&following)
+
+	return following, err
+}
+
+func (repo *PostgresFollowerRepository) ListFollowers(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(ctx, "user_id", "follower_id", userId, cursor, limit)
+}
+
+func (repo *PostgresFollowerRepository) ListFollowing(ctx context.Context, followerId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return repo.listConnections(ctx, "follower_id", "user_id", followerId, cursor, limit)
+}
+
+// listConnections matches the relationships by column and joins the users referenced by joinColumn
+func (repo *PostgresFollowerRepository) listConnections(ctx context.Context, column, joinColumn, id string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty cursor is after every id, so the first page has no condition
+	before := "~"
+
+	if cursor != nil {
+		before = cursor.Hex()
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT f.id, u.id, u.username, u.followers_count, u.following_count
+		FROM followers f
+		LEFT JOIN users u ON u.id = f.`+joinColumn+`
+		WHERE f.`+column+` = $1 AND f.id < $2
+		ORDER BY f.id DESC
+		LIMIT $3`,
+		objectId.Hex(),
+		before,
+		limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.Connection
+
+	for rows.Next() {
+		var connection entity.Connection
+		var user entity.UserSummary
+		var username sql.NullString
+		var followersCount, followingCount sql.NullInt64
+
+		if err := rows.Scan(
+			postgres.ScanID(&connection.ID),
+			postgres.ScanID(&user.ID),
+			&username,
+			&followersCount,
+			&followingCount,
+		); err != nil {
+			return nil, err
+		}
+
+		if username.Valid {
+			user.Username = username.String
+			user.FollowersCount = uint(followersCount.Int64)
+			user.FollowingCount = uint(followingCount.Int64)
+			connection.User = &user
+		}
+
+		result = append(result, &connection)
+	}
+
+	return result, rows.Err()
+}
+
+func (repo *PostgresFollowerRepository) CountFollowers(ctx context.Context, userIds []string) (map[string]int64, error) {
+	return repo.countBy(ctx, "user_id", userIds)
+}
+
+func (repo *PostgresFollowerRepository) CountFollowing(ctx context.Context, followerIds []string) (map[string]int64, error) {
+	return repo.countBy(ctx, "follower_id", followerIds)
+}
+
+func (repo *PostgresFollowerRepository) countBy(ctx context.Context, column string, ids []string) (map[string]int64, error) {
+	objectIds, err := toObjectIDs(ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.QueryCounts(
+		ctx,
+		repo.db,
+		`SELECT `+column+`, count(*) FROM followers WHERE `+column+` = ANY($1) GROUP BY `+column,
+		postgres.IDs(objectIds),
+	)
+}
+
+// incrementCounters changes the users as the follower one does, never taking a counter below zero
+func incrementCounters(ctx context.Context, tx *sql.Tx, followerId, followingId primitive.ObjectID, value int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE users SET following_count = following_count + $2 WHERE id = $1 AND following_count + $2 >= 0`,
+		followerId.Hex(),
+		value,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE users SET followers_count = followers_count + $2 WHERE id = $1 AND followers_count + $2 >= 0`,
+		followingId.Hex(),
+		value,
+	)
+
+	return err
+}
+
+func toObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	var result []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, objId)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,351 @@
+package user_repository
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+)
+
+// MemoryUserRepository keeps the users on a memory.Database, with the same unique
+// usernames and orderings as the UserRepository
+type MemoryUserRepository struct {
+	database *memory.Database
+}
+
+func NewMemoryUserRepository(database *memory.Database) *MemoryUserRepository {
+	return &MemoryUserRepository{
+		database: database,
+	}
+}
+
+func (repo *MemoryUserRepository) Create(ctx context.Context, user *entity.User) (string, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, stored := range repo.database.Users {
+		if stored.Username == user.Username {
+			return "", mongodb.ErrDuplicateKey
+		}
+	}
+
+	stored := *user
+	stored.Password = ""
+	stored.CreatedAt = memory.Time(user.CreatedAt)
+
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+
+	repo.database.Users[stored.ID] = &stored
+
+	return stored.ID.Hex(), nil
+}
+
+func (repo *MemoryUserRepository) Find(ctx context.Context, id string) (*entity.User, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	user, ok := repo.database.Users[objectId]
+
+	if !ok {
+		return nil, nil
+	}
+
+	found := *user
+
+	return &found, nil
+}
+
+func (repo *MemoryUserRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	for _, user := range repo.database.Users {
+		if user.Username == username {
+			found := *user
+
+			return &found, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *MemoryUserRepository) FindByUsernames(ctx context.Context, usernames []string) ([]*entity.UserSummary, error) {
+	wanted := map[string]bool{}
+
+	for _, username := range usernames {
+		wanted[username] = true
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var users []*entity.UserSummary
+
+	for _, user := range repo.database.Users {
+		if wanted[user.Username] {
+			users = append(users, summary(user))
+		}
+	}
+
+	return users, nil
+}
+
+func (repo *MemoryUserRepository) FindByUsernamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.UserSummary, error) {
+	repo.database.RLock()
+
+	var users []*entity.UserSummary
+
+	for _, user := range repo.database.Users {
+		if strings.HasPrefix(user.Username, prefix) {
+			users = append(users, summary(user))
+		}
+	}
+
+	repo.database.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].FollowersCount != users[j].FollowersCount {
+			return users[i].FollowersCount > users[j].FollowersCount
+		}
+
+		return users[i].Username < users[j].Username
+	})
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+func (repo *MemoryUserRepository) IncrementFollowers(ctx context.Context, id string) error {
+	return repo.incrementField(id, "followers_count", 1)
+}
+
+func (repo *MemoryUserRepository) IncrementFollowing(ctx context.Context, id string) error {
+	return repo.incrementField(id, "following_count", 1)
+}
+
+func (repo *MemoryUserRepository) DecrementFollowers(ctx context.Context, id string) error {
+	return repo.incrementField(id, "followers_count", -1)
+}
+
+func (repo *MemoryUserRepository) DecrementFollowing(ctx context.Context, id string) error {
+	return repo.incrementField(id, "following_count", -1)
+}
+
+func (repo *MemoryUserRepository) SetTimezone(ctx context.Context, id, timezone string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	user, ok := repo.database.Users[objectId]
+
+	if !ok {
+		return false, nil
+	}
+
+	updated := *user
+	updated.Timezone = timezone
+
+	repo.database.Users[objectId] = &updated
+
+	return true, nil
+}
+
+func (repo *MemoryUserRepository) FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	repo.database.RLock()
+	defer repo.database.RUnlock()
+
+	var result []string
+
+	for _, objId := range usersObjectId {
+		if user, ok := repo.database.Users[objId]; ok && user.FollowersCount >= followers {
+			result = append(result, objId.Hex())
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *MemoryUserRepository) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	user, ok := repo.database.Users[objectId]
+
+	if !ok {
+		return false, false, nil
+	}
+
+	pulled := user.FollowersCount >= followers
+
+	if pulled == user.Pulled {
+		return pulled, false, nil
+	}
+
+	updated := *user
+	updated.Pulled = pulled
+
+	repo.database.Users[objectId] = &updated
+
+	return pulled, true, nil
+}
+
+func (repo *MemoryUserRepository) GetCounters(ctx context.Context, after string, limit int) ([]*entity.Counters, error) {
+	var afterObjectId primitive.ObjectID
+
+	if after != "" {
+		objectId, err := mongodb.ObjectIDFromHex(after)
+
+		if err != nil {
+			return nil, err
+		}
+
+		afterObjectId = objectId
+	}
+
+	repo.database.RLock()
+
+	var result []*entity.Counters
+
+	for _, user := range repo.database.Users {
+		if after == "" || memory.CompareIDs(user.ID, afterObjectId) > 0 {
+			result = append(result, &entity.Counters{
+				UserID:         user.ID,
+				FollowersCount: int64(user.FollowersCount),
+				FollowingCount: int64(user.FollowingCount),
+				PostsCount:     int64(user.PostsCount),
+			})
+		}
+	}
+
+	repo.database.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return memory.CompareIDs(result[i].UserID, result[j].UserID) < 0
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// ReplaceCounters counters can not drift below zero on memory, so the stored ones are compared as they are
+func (repo *MemoryUserRepository) ReplaceCounters(ctx context.Context, stored, actual []*entity.Counters) (int, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	modified := 0
+
+	for i, counters := range actual {
+		user, ok := repo.database.Users[stored[i].UserID]
+
+		if !ok ||
+			int64(user.FollowersCount) != stored[i].FollowersCount ||
+			int64(user.FollowingCount) != stored[i].FollowingCount ||
+			int64(user.PostsCount) != stored[i].PostsCount {
+			continue
+		}
+
+		updated := *user
+		updated.FollowersCount = uint(counters.FollowersCount)
+		updated.FollowingCount = uint(counters.FollowingCount)
+		updated.PostsCount = uint(counters.PostsCount)
+
+		if updated != *user {
+			repo.database.Users[user.ID] = &updated
+			modified++
+		}
+	}
+
+	return modified, nil
+}
+
+// incrementField never takes a counter below zero, as the IncrementField of the UserRepository
+func (repo *MemoryUserRepository) incrementField(id, field string, value int) error {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	user, ok := repo.database.Users[objectId]
+
+	if !ok {
+		return nil
+	}
+
+	updated := *user
+
+	if !memory.Increment(counter(&updated, field), value) {
+		return nil
+	}
+
+	repo.database.Users[objectId] = &updated
+
+	return nil
+}
+
+func counter(user *entity.User, field string) *uint {
+	switch field {
+	case "followers_count":
+		return &user.FollowersCount
+	case "following_count":
+		return &user.FollowingCount
+	default:
+		return &user.PostsCount
+	}
+}
+
+func summary(user *entity.User) *entity.UserSummary {
+	return &entity.UserSummary{
+		ID:             user.ID,
+		Username:       user.Username,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
+	}
+}
@@ -0,0 +1,341 @@
+package user_repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/user/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const userColumns = "id, username, password_hash, timezone, created_at, followers_count, following_count, posts_count"
+
+// PostgresUserRepository keeps the users on the users table, with the same unique
+// usernames and orderings as the UserRepository
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{
+		db: db,
+	}
+}
+
+func (repo *PostgresUserRepository) Create(ctx context.Context, user *entity.User) (string, error) {
+	id := user.ID
+
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+
+	_, err := repo.db.ExecContext(
+		ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		id.Hex(),
+		user.Username,
+		user.PasswordHash,
+		user.Timezone,
+		postgres.Time(user.CreatedAt),
+		user.FollowersCount,
+		user.FollowingCount,
+		user.PostsCount,
+	)
+
+	if err != nil {
+		if postgres.IsDup(err) {
+			return "", mongodb.ErrDuplicateKey
+		}
+		return "", err
+	}
+
+	return id.Hex(), nil
+}
+
+func (repo *PostgresUserRepository) Find(ctx context.Context, id string) (*entity.User, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.findOne(ctx, "id = $1", objectId.Hex())
+}
+
+func (repo *PostgresUserRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return repo.findOne(ctx, "username = $1", username)
+}
+
+func (repo *PostgresUserRepository) findOne(ctx context.Context, condition string, arg interface{}) (*entity.User, error) {
+	var user entity.User
+
+	err := repo.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE `+condition, arg).Scan(
+		postgres.ScanID(&user.ID),
+		&user.Username,
+		&user.PasswordHash,
+		&user.Timezone,
+		&user.CreatedAt,
+		&user.FollowersCount,
+		&user.FollowingCount,
+		&user.PostsCount,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	user.CreatedAt = user.CreatedAt.UTC()
+
+	return &user, nil
+}
+
+func (repo *PostgresUserRepository) FindByUsernames(ctx context.Context, usernames []string) ([]*entity.UserSummary, error) {
+	return repo.querySummaries(
+		ctx,
+		`SELECT id, username, followers_count, following_count FROM users WHERE username = ANY($1)`,
+		postgres.Strings(usernames),
+	)
+}
+
+// FindByUsernamePrefix matches the prefix with LIKE, which the index of the "C" collation of username serves as a range scan
+func (repo *PostgresUserRepository) FindByUsernamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.UserSummary, error) {
+	return repo.querySummaries(
+		ctx,
+		`SELECT id, username, followers_count, following_count FROM users
+		WHERE username LIKE $1
+		ORDER BY followers_count DESC, username
+		LIMIT $2`,
+		likeEscaper.Replace(prefix)+"%",
+		limit,
+	)
+}
+
+func (repo *PostgresUserRepository) querySummaries(ctx context.Context, query string, args ...interface{}) ([]*entity.UserSummary, error) {
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var users []*entity.UserSummary
+
+	for rows.Next() {
+		var user entity.UserSummary
+		if err := rows.Scan(postgres.ScanID(&user.ID), &user.Username, &user.FollowersCount, &user.FollowingCount); err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, rows.Err()
+}
+
+func (repo *PostgresUserRepository) IncrementFollowers(ctx context.Context, id string) error {
+	return repo.incrementField(ctx, id, "followers_count", 1)
+}
+
+func (repo *PostgresUserRepository) IncrementFollowing(ctx context.Context, id string) error {
+	return repo.incrementField(ctx, id, "following_count", 1)
+}
+
+func (repo *PostgresUserRepository) DecrementFollowers(ctx context.Context, id string) error {
+	return repo.incrementField(ctx, id, "followers_count", -1)
+}
+
+func (repo *PostgresUserRepository) DecrementFollowing(ctx context.Context, id string) error {
+	return repo.incrementField(ctx, id, "following_count", -1)
+}
+
+func (repo *PostgresUserRepository) SetTimezone(ctx context.Context, id, timezone string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := repo.db.ExecContext(ctx, `UPDATE users SET timezone = $2 WHERE id = $1`, objectId.Hex(), timezone)
+
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+
+	return updated > 0, err
+}
+
+func (repo *PostgresUserRepository) FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	return postgres.QueryIDs(
+		ctx,
+		repo.db,
+		`SELECT id FROM users WHERE id = ANY($1) AND followers_count >= $2`,
+		postgres.IDs(usersObjectId),
+		followers,
+	)
+}
+
+// UpdatePulled locks the user to read whether it was pulled, as the UserRepository reads it atomically
+func (repo *PostgresUserRepository) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	var pulled, wasPulled bool
+
+	err = repo.db.QueryRowContext(
+		ctx,
+		`WITH previous AS (
+			SELECT id, pulled FROM users WHERE id = $1 FOR UPDATE
+		)
+		UPDATE users u SET pulled = u.followers_count >= $2
+		FROM previous
+		WHERE u.id = previous.id
+		RETURNING u.pulled, previous.pulled`,
+		objectId.Hex(),
+		followers,
+	).Scan(&pulled, &wasPulled)
+
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+
+	if err != nil {
+		return false, false, err
+	}
+
+	return pulled, pulled != wasPulled, nil
+}
+
+func (repo *PostgresUserRepository) GetCounters(ctx context.Context, after string, limit int) ([]*entity.Counters, error) {
+	afterId := ""
+
+	if after != "" {
+		objectId, err := mongodb.ObjectIDFromHex(after)
+
+		if err != nil {
+			return nil, err
+		}
+
+		afterId = objectId.Hex()
+	}
+
+	rows, err := repo.db.QueryContext(
+		ctx,
+		`SELECT id, followers_count, following_count, posts_count FROM users WHERE id > $1 ORDER BY id LIMIT $2`,
+		afterId,
+		limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var result []*entity.Counters
+
+	for rows.Next() {
+		var counters entity.Counters
+		if err := rows.Scan(
+			postgres.ScanID(&counters.UserID),
+			&counters.FollowersCount,
+			&counters.FollowingCount,
+			&counters.PostsCount,
+		); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &counters)
+	}
+
+	return result, rows.Err()
+}
+
+// ReplaceCounters sets the actual counters only on the users whose counters are still the stored ones,
+// as the UserRepository does, counters can not be missing on the users table
+func (repo *PostgresUserRepository) ReplaceCounters(ctx context.Context, stored, actual []*entity.Counters) (int, error) {
+	modified := 0
+
+	err := postgres.Transaction(ctx, repo.db, func(tx *sql.Tx) error {
+		for i, counters := range actual {
+			result, err := tx.ExecContext(
+				ctx,
+				`UPDATE users SET followers_count = $5, following_count = $6, posts_count = $7
+				WHERE id = $1 AND followers_count = $2 AND following_count = $3 AND posts_count = $4
+				AND (followers_count, following_count, posts_count) <> ($5, $6, $7)`,
+				stored[i].UserID.Hex(),
+				stored[i].FollowersCount,
+				stored[i].FollowingCount,
+				stored[i].PostsCount,
+				counters.FollowersCount,
+				counters.FollowingCount,
+				counters.PostsCount,
+			)
+
+			if err != nil {
+				return err
+			}
+
+			updated, err := result.RowsAffected()
+
+			if err != nil {
+				return err
+			}
+
+			modified += int(updated)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return modified, nil
+}
+
+// incrementField never takes a counter below zero, as the IncrementField of the UserRepository
+func (repo *PostgresUserRepository) incrementField(ctx context.Context, id, field string, value int) error {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.ExecContext(
+		ctx,
+		`UPDATE users SET `+field+` = `+field+` + $2 WHERE id = $1 AND `+field+` + $2 >= 0`,
+		objectId.Hex(),
+		value,
+	)
+
+	return err
+}
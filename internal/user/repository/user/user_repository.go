@@ -2,22 +2,48 @@ package user_repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/regiszanandrea/posty/internal/memory"
 	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 )
 
 type Repository interface {
-	Create(user *entity.User) (string, error)
-	Find(id string) (*entity.User, error)
-	IncrementFollowers(id string) error
-	IncrementFollowing(id string) error
-	DecrementFollowers(id string) error
-	DecrementFollowing(id string) error
-	IncreasePostsCount(id string) error
+	Create(ctx context.Context, user *entity.User) (string, error)
+	Find(ctx context.Context, id string) (*entity.User, error)
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	FindByUsernames(ctx context.Context, usernames []string) ([]*entity.UserSummary, error)
+	FindByUsernamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.UserSummary, error)
+	IncrementFollowers(ctx context.Context, id string) error
+	IncrementFollowing(ctx context.Context, id string) error
+	DecrementFollowers(ctx context.Context, id string) error
+	DecrementFollowing(ctx context.Context, id string) error
+	SetTimezone(ctx context.Context, id, timezone string) (bool, error)
+	FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error)
+	UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error)
+	GetCounters(ctx context.Context, after string, limit int) ([]*entity.Counters, error)
+	ReplaceCounters(ctx context.Context, stored, actual []*entity.Counters) (int, error)
+}
+
+// NewRepository returns the repository of the driver set on app.storage.driver
+func NewRepository(client *mongo.Client, db *sql.DB, database *memory.Database, configs *viper.Viper) (Repository, error) {
+	switch driver := configs.GetString("app.storage.driver"); driver {
+	case "mongodb":
+		return NewUserRepository(client, configs), nil
+	case "memory":
+		return NewMemoryUserRepository(database), nil
+	case "postgres":
+		return NewPostgresUserRepository(db), nil
+	default:
+		return nil, fmt.Errorf("user_repository: unknown storage driver %q", driver)
+	}
 }
 
 type UserRepository struct {
@@ -36,8 +62,8 @@ func NewUserRepository(client *mongo.Client, configs *viper.Viper) *UserReposito
 	}
 }
 
-func (repo *UserRepository) Create(user *entity.User) (string, error) {
-	result, err := repo.collection.InsertOne(context.TODO(), user)
+func (repo *UserRepository) Create(ctx context.Context, user *entity.User) (string, error) {
+	result, err := repo.collection.InsertOne(ctx, user)
 
 	if err != nil {
 		if mongodb.IsDup(err) {
@@ -51,17 +77,17 @@ func (repo *UserRepository) Create(user *entity.User) (string, error) {
 	return objectId.Hex(), err
 }
 
-func (repo *UserRepository) Find(id string) (*entity.User, error) {
+func (repo *UserRepository) Find(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
 
-	objectId, err := primitive.ObjectIDFromHex(id)
+	objectId, err := mongodb.ObjectIDFromHex(id)
 
 	if err != nil {
 		return nil, err
 	}
 
 	err = repo.collection.FindOne(
-		context.TODO(), bson.M{"_id": objectId},
+		ctx, bson.M{"_id": objectId},
 	).Decode(&user)
 
 	if err != nil {
@@ -74,34 +100,292 @@ func (repo *UserRepository) Find(id string) (*entity.User, error) {
 	return &user, nil
 }
 
-func (repo *UserRepository) IncrementFollowers(id string) error {
-	return repo.IncrementField(id, "followers_count", 1)
+func (repo *UserRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var user entity.User
+
+	err := repo.collection.FindOne(
+		ctx, bson.M{"username": username},
+	).Decode(&user)
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// FindByUsernames returns the users that exist among usernames, in no particular order
+func (repo *UserRepository) FindByUsernames(ctx context.Context, usernames []string) ([]*entity.UserSummary, error) {
+	curr, err := repo.collection.Find(
+		ctx,
+		bson.M{"username": bson.M{"$in": usernames}},
+		options.Find().SetProjection(bson.D{{"username", 1}, {"followers_count", 1}, {"following_count", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*entity.UserSummary
+
+	if err := curr.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// FindByUsernamePrefix returns the users whose username starts with prefix, the most followed first.
+// An anchored regex only reads the part of the username index that starts with prefix
+func (repo *UserRepository) FindByUsernamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.UserSummary, error) {
+	curr, err := repo.collection.Find(
+		ctx,
+		bson.M{"username": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}},
+		options.Find().
+			SetSort(bson.D{{"followers_count", -1}, {"username", 1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.D{{"username", 1}, {"followers_count", 1}, {"following_count", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*entity.UserSummary
+
+	if err := curr.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepository) IncrementFollowers(ctx context.Context, id string) error {
+	return repo.IncrementField(ctx, id, "followers_count", 1)
+}
+
+func (repo *UserRepository) IncrementFollowing(ctx context.Context, id string) error {
+	return repo.IncrementField(ctx, id, "following_count", 1)
+}
+
+func (repo *UserRepository) DecrementFollowers(ctx context.Context, id string) error {
+	return repo.IncrementField(ctx, id, "followers_count", -1)
+}
+
+func (repo *UserRepository) DecrementFollowing(ctx context.Context, id string) error {
+	return repo.IncrementField(ctx, id, "following_count", -1)
+}
+
+func (repo *UserRepository) FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var usersObjectId []primitive.ObjectID
+
+	for _, id := range ids {
+		objId, err := mongodb.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+
+		usersObjectId = append(usersObjectId, objId)
+	}
+
+	var result []string
+
+	curr, err := repo.collection.Find(
+		ctx,
+		bson.M{
+			"_id":             bson.M{"$in": usersObjectId},
+			"followers_count": bson.M{"$gte": followers},
+		},
+		options.Find().SetProjection(bson.D{{"_id", 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var user entity.User
+		if err := curr.Decode(&user); err != nil {
+			return nil, err
+		}
+
+		result = append(result, user.ID.Hex())
+	}
+
+	return result, curr.Err()
+}
+
+// UpdatePulled marks the user as pulled when it has at least the given followers and unmarks it when
+// it has fewer, it returns whether the user is pulled and whether this call changed it, so a single
+// caller sees each time a user crosses the number of followers, even when many follow it at once
+func (repo *UserRepository) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	var previous entity.User
+
+	err = repo.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objectId},
+		mongo.Pipeline{
+			{{"$set", bson.D{{"pulled", bson.D{{"$gte", bson.A{"$followers_count", followers}}}}}}},
+		},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.Before).
+			SetProjection(bson.D{{"followers_count", 1}, {"pulled", 1}}),
+	).Decode(&previous)
+
+	if err == mongo.ErrNoDocuments {
+		return false, false, nil
+	}
+
+	if err != nil {
+		return false, false, err
+	}
+
+	pulled := previous.FollowersCount >= followers
+
+	return pulled, pulled != previous.Pulled, nil
 }
 
-func (repo *UserRepository) IncrementFollowing(id string) error {
-	return repo.IncrementField(id, "following_count", 1)
+// GetCounters returns the counters of the users ordered by id, starting after the given one when it is not empty
+func (repo *UserRepository) GetCounters(ctx context.Context, after string, limit int) ([]*entity.Counters, error) {
+	filter := bson.M{}
+
+	if after != "" {
+		objectId, err := mongodb.ObjectIDFromHex(after)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filter["_id"] = bson.M{"$gt": objectId}
+	}
+
+	curr, err := repo.collection.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"_id", 1}}).
+			SetLimit(int64(limit)).
+			SetProjection(bson.D{
+				{"followers_count", 1},
+				{"following_count", 1},
+				{"posts_count", 1},
+			}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*entity.Counters
+
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var counters entity.Counters
+		if err := curr.Decode(&counters); err != nil {
+			return nil, err
+		}
+
+		result = append(result, &counters)
+	}
+
+	return result, curr.Err()
 }
 
-func (repo *UserRepository) DecrementFollowers(id string) error {
-	return repo.IncrementField(id, "followers_count", -1)
+// ReplaceCounters sets the actual counters only on the users whose counters are still
+// the stored ones, so the ones changed meanwhile are left to the next run.
+// It returns how many users were updated
+func (repo *UserRepository) ReplaceCounters(ctx context.Context, stored, actual []*entity.Counters) (int, error) {
+	if len(actual) == 0 {
+		return 0, nil
+	}
+
+	var models []mongo.WriteModel
+
+	for i, counters := range actual {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"_id":             stored[i].UserID,
+				"followers_count": storedCounter(stored[i].FollowersCount),
+				"following_count": storedCounter(stored[i].FollowingCount),
+				"posts_count":     storedCounter(stored[i].PostsCount),
+			}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"followers_count": counters.FollowersCount,
+				"following_count": counters.FollowingCount,
+				"posts_count":     counters.PostsCount,
+			}}),
+		)
+	}
+
+	result, err := repo.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.ModifiedCount), nil
 }
 
-func (repo *UserRepository) DecrementFollowing(id string) error {
-	return repo.IncrementField(id, "following_count", -1)
+// storedCounter matches a counter read as zero, which is also how a missing counter is read
+func storedCounter(value int64) interface{} {
+	if value == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return value
 }
 
-func (repo *UserRepository) IncreasePostsCount(id string) error {
-	return repo.IncrementField(id, "posts_count", 1)
+// SetTimezone returns false when the user does not exist
+func (repo *UserRepository) SetTimezone(ctx context.Context, id, timezone string) (bool, error) {
+	objectId, err := mongodb.ObjectIDFromHex(id)
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := repo.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.D{
+		{"$set", bson.D{{"timezone", timezone}}},
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
 }
 
-func (repo *UserRepository) IncrementField(id, field string, value int) error {
-	objectId, err := primitive.ObjectIDFromHex(id)
+// IncrementField never takes a counter below zero, a decrement on a counter
+// that already is zero is ignored and left to the reconciliation
+func (repo *UserRepository) IncrementField(ctx context.Context, id, field string, value int) error {
+	objectId, err := mongodb.ObjectIDFromHex(id)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = repo.collection.UpdateOne(context.TODO(), bson.M{"_id": objectId},
+	filter := bson.M{"_id": objectId}
+
+	if value < 0 {
+		filter[field] = bson.M{"$gte": -value}
+	}
+
+	_, err = repo.collection.UpdateOne(ctx, filter,
 		bson.D{
 			{"$inc",
 				bson.D{
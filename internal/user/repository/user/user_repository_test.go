@@ -2,7 +2,9 @@ package user_repository
 
 import (
 	"context"
+	"database/sql"
 	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/postgres"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +15,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/memory"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 )
 
@@ -22,7 +25,8 @@ func TestRepository(t *testing.T) {
 }
 
 var (
-	userRepository *UserRepository
+	userRepository Repository
+	db             *sql.DB
 	client         *mongo.Client
 	configs        *viper.Viper
 )
@@ -32,24 +36,59 @@ var _ = BeforeSuite(func() {
 
 	client = mongodb.NewMongoDBClient(configs)
 
-	err := client.Connect(context.Background())
+	// the suite runs against the driver set on app.storage.driver, memory needs no database
+	if mongodb.IsStorage(configs) {
+		err := client.Connect(context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+
+		err = mongodb.CreateIndexes(client, configs, context.Background())
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var err error
+
+	db, err = postgres.NewPostgresDB(configs)
 
 	if err != nil {
 		panic(err)
 	}
 
-	err = mongodb.CreateIndexes(client, configs, context.Background())
+	if postgres.IsStorage(configs) {
+		err = postgres.Migrate(context.Background(), db)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	userRepository, err = NewRepository(client, db, memory.NewDatabase(), configs)
 
 	if err != nil {
 		panic(err)
 	}
-
-	userRepository = NewUserRepository(
-		client, configs,
-	)
 })
 
 var _ = AfterSuite(func() {
+	if postgres.IsStorage(configs) {
+		_, err := db.Exec("TRUNCATE users")
+
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if !mongodb.IsStorage(configs) {
+		return
+	}
+
 	usersCollection := client.Database(
 		configs.GetString("app.mongodb.database"),
 	).Collection(
@@ -74,7 +113,7 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				id, err := userRepository.Create(&user)
+				id, err := userRepository.Create(context.Background(), &user)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(id)).To(BeTrue())
@@ -90,9 +129,9 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				_, err := userRepository.Create(&user)
+				_, err := userRepository.Create(context.Background(), &user)
 
-				_, err = userRepository.Create(&user)
+				_, err = userRepository.Create(context.Background(), &user)
 
 				Expect(err).To(Equal(mongodb.ErrDuplicateKey))
 			})
@@ -109,15 +148,52 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
 
-				userFound, err := userRepository.Find(id)
+				userFound, err := userRepository.Find(context.Background(), id)
 				Expect(err).To(BeNil())
 				Expect(userFound.Username).To(Equal(user.Username))
 			})
 		})
 	})
 
+	Describe("Finding Users by username", func() {
+		Context("when some of the usernames exist", func() {
+			It("returns only the existing users", func() {
+				user := entity.User{
+					Username:  "mentioned",
+					CreatedAt: time.Now(),
+				}
+
+				id, _ := userRepository.Create(context.Background(), &user)
+
+				users, err := userRepository.FindByUsernames(context.Background(), []string{"mentioned", "nobody"})
+
+				Expect(err).To(BeNil())
+				Expect(users).To(HaveLen(1))
+				Expect(users[0].ID.Hex()).To(Equal(id))
+				Expect(users[0].Username).To(Equal("mentioned"))
+			})
+		})
+	})
+
+	Describe("Finding Users by the start of the username", func() {
+		Context("when some of the usernames start with it", func() {
+			It("returns only them, the most followed first", func() {
+				_, _ = userRepository.Create(context.Background(), &entity.User{Username: "prefixed", FollowersCount: 1})
+				_, _ = userRepository.Create(context.Background(), &entity.User{Username: "prefixedtop", FollowersCount: 5})
+				_, _ = userRepository.Create(context.Background(), &entity.User{Username: "notprefixed", FollowersCount: 9})
+
+				users, err := userRepository.FindByUsernamePrefix(context.Background(), "prefixed", 10)
+
+				Expect(err).To(BeNil())
+				Expect(users).To(HaveLen(2))
+				Expect(users[0].Username).To(Equal("prefixedtop"))
+				Expect(users[1].Username).To(Equal("prefixed"))
+			})
+		})
+	})
+
 	Describe("Increment User's followers", func() {
 		Context("when increment the user's followers", func() {
 			It("increments only by one", func() {
@@ -130,11 +206,11 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
 
-				_ = userRepository.IncrementFollowers(id)
+				_ = userRepository.IncrementFollowers(context.Background(), id)
 
-				userFound, err := userRepository.Find(id)
+				userFound, err := userRepository.Find(context.Background(), id)
 
 				Expect(err).To(BeNil())
 				Expect(userFound.FollowersCount).To(Equal(expectedFollowers))
@@ -154,11 +230,11 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
 
-				_ = userRepository.IncrementFollowing(id)
+				_ = userRepository.IncrementFollowing(context.Background(), id)
 
-				userFound, err := userRepository.Find(id)
+				userFound, err := userRepository.Find(context.Background(), id)
 
 				Expect(err).To(BeNil())
 				Expect(userFound.FollowingCount).To(Equal(expectedFollowing))
@@ -178,11 +254,11 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 0,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
 
-				_ = userRepository.DecrementFollowers(id)
+				_ = userRepository.DecrementFollowers(context.Background(), id)
 
-				userFound, err := userRepository.Find(id)
+				userFound, err := userRepository.Find(context.Background(), id)
 
 				Expect(err).To(BeNil())
 				Expect(userFound.FollowersCount).To(Equal(expectedFollowers))
@@ -190,6 +266,28 @@ var _ = Describe("UserRepository suite test", func() {
 		})
 	})
 
+	Describe("Decrement User's followers when there are none", func() {
+		Context("when the user's followers is already zero", func() {
+			It("keeps it at zero", func() {
+				user := entity.User{
+					Username:  "testDecrementZero",
+					CreatedAt: time.Now(),
+				}
+
+				id, _ := userRepository.Create(context.Background(), &user)
+
+				err := userRepository.DecrementFollowers(context.Background(), id)
+
+				Expect(err).To(BeNil())
+
+				userFound, err := userRepository.Find(context.Background(), id)
+
+				Expect(err).To(BeNil())
+				Expect(userFound.FollowersCount).To(BeEquivalentTo(0))
+			})
+		})
+	})
+
 	Describe("Decrement User's following number", func() {
 		Context("when decrement the user's following number", func() {
 			It("decrements only by one", func() {
@@ -202,11 +300,11 @@ var _ = Describe("UserRepository suite test", func() {
 					FollowingCount: 1,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
 
-				_ = userRepository.DecrementFollowing(id)
+				_ = userRepository.DecrementFollowing(context.Background(), id)
 
-				userFound, err := userRepository.Find(id)
+				userFound, err := userRepository.Find(context.Background(), id)
 
 				Expect(err).To(BeNil())
 				Expect(userFound.FollowingCount).To(Equal(expectedFollowing))
@@ -214,27 +312,44 @@ var _ = Describe("UserRepository suite test", func() {
 		})
 	})
 
-	Describe("Increment User's posts number", func() {
-		Context("when increment the user's posts number", func() {
-			It("increment only by one", func() {
-				var expectedPostsCount uint = 1
-
+	Describe("Updating whether a user is pulled", func() {
+		Context("when the user crosses the followers", func() {
+			It("reports the change only once", func() {
 				user := entity.User{
-					Username:       "testIncrementPosts",
+					Username:       "testUpdatePulled",
 					CreatedAt:      time.Now(),
-					FollowersCount: 0,
-					FollowingCount: 0,
-					PostsCount:     0,
+					FollowersCount: 2,
 				}
 
-				id, _ := userRepository.Create(&user)
+				id, _ := userRepository.Create(context.Background(), &user)
+
+				pulled, changed, err := userRepository.UpdatePulled(context.Background(), id, 3)
+
+				Expect(err).To(BeNil())
+				Expect(pulled).To(BeFalse())
+				Expect(changed).To(BeFalse())
+
+				_ = userRepository.IncrementFollowers(context.Background(), id)
+
+				pulled, changed, err = userRepository.UpdatePulled(context.Background(), id, 3)
+
+				Expect(err).To(BeNil())
+				Expect(pulled).To(BeTrue())
+				Expect(changed).To(BeTrue())
+
+				pulled, changed, err = userRepository.UpdatePulled(context.Background(), id, 3)
+
+				Expect(err).To(BeNil())
+				Expect(pulled).To(BeTrue())
+				Expect(changed).To(BeFalse())
 
-				_ = userRepository.IncreasePostsCount(id)
+				_ = userRepository.DecrementFollowers(context.Background(), id)
 
-				userFound, err := userRepository.Find(id)
+				pulled, changed, err = userRepository.UpdatePulled(context.Background(), id, 3)
 
 				Expect(err).To(BeNil())
-				Expect(userFound.PostsCount).To(Equal(expectedPostsCount))
+				Expect(pulled).To(BeFalse())
+				Expect(changed).To(BeTrue())
 			})
 		})
 	})
@@ -1,35 +1,65 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"github.com/regiszanandrea/posty/internal/apperror"
+	"github.com/regiszanandrea/posty/internal/metrics"
+	timeline_service "github.com/regiszanandrea/posty/internal/timeline/service"
+	"github.com/regiszanandrea/posty/internal/tracing"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/internal/user/repository/follower"
 	"github.com/regiszanandrea/posty/internal/user/repository/user"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"strings"
 	"time"
 )
 
+var (
+	ErrUserNotFound = apperror.NotFound("user_not_found", "no user found")
+	ErrFollowItself = apperror.Validation("follow_itself", "A user cannot follow itself")
+	ErrNotFollowing = apperror.NotFound("not_following", "user is not following the given user")
+)
+
 type Service interface {
-	GetUser(id string) (*entity.User, error)
-	CreateUser(user *entity.User) (*string, []error)
-	Follow(followRequest *entity.FollowRequest) error
-	Unfollow(unfollowRequest *entity.UnfollowRequest) error
-	IncreaseNumberOfPosts(id string) error
+	GetUser(ctx context.Context, id string) (*entity.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	CreateUser(ctx context.Context, user *entity.User) (*string, []error)
+	Follow(ctx context.Context, followRequest *entity.FollowRequest) error
+	Unfollow(ctx context.Context, unfollowRequest *entity.UnfollowRequest) error
+	ListFollowers(ctx context.Context, listRequest *entity.ListConnectionsRequest) (*entity.UserList, []error)
+	ListFollowing(ctx context.Context, listRequest *entity.ListConnectionsRequest) (*entity.UserList, []error)
+	IsFollowing(ctx context.Context, followerId, followingId string) (bool, error)
+	UpdateTimezone(ctx context.Context, updateTimezoneRequest *entity.UpdateTimezoneRequest) []error
 }
 
 type UserService struct {
 	userRepository     user_repository.Repository
 	followerRepository follower_repository.Repository
+	timelineService    timeline_service.Service
+	configs            *viper.Viper
 }
 
-func NewUserService(userRepo user_repository.Repository, followerRepo follower_repository.Repository) *UserService {
+func NewUserService(
+	userRepo user_repository.Repository,
+	followerRepo follower_repository.Repository,
+	timelineService timeline_service.Service,
+	configs *viper.Viper,
+) *UserService {
 	return &UserService{
 		userRepository:     userRepo,
 		followerRepository: followerRepo,
+		timelineService:    timelineService,
+		configs:            configs,
 	}
 }
 
-func (service *UserService) GetUser(id string) (*entity.User, error) {
-	user, err := service.userRepository.Find(id)
+func (service *UserService) GetUser(ctx context.Context, id string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	user, err := service.userRepository.Find(ctx, id)
 
 	if err != nil {
 		return nil, err
@@ -38,16 +68,38 @@ func (service *UserService) GetUser(id string) (*entity.User, error) {
 	return user, nil
 }
 
-func (service *UserService) CreateUser(user *entity.User) (*string, []error) {
+func (service *UserService) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByUsername")
+	defer span.End()
+
+	user, err := service.userRepository.FindByUsername(ctx, strings.TrimPrefix(username, "@"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (service *UserService) CreateUser(ctx context.Context, user *entity.User) (*string, []error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	errs := entity.Validate(user)
 
 	if errs != nil {
 		return nil, errs
 	}
 
+	err := user.HashPassword()
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
 	user.CreatedAt = time.Now()
 
-	id, err := service.userRepository.Create(user)
+	id, err := service.userRepository.Create(ctx, user)
 
 	if err != nil {
 		return nil, []error{err}
@@ -55,54 +107,134 @@ func (service *UserService) CreateUser(user *entity.User) (*string, []error) {
 	return &id, nil
 }
 
-func (service *UserService) Follow(followRequest *entity.FollowRequest) error {
+// Follow is idempotent, following a user twice keeps a single relationship and does not change the counters
+func (service *UserService) Follow(ctx context.Context, followRequest *entity.FollowRequest) error {
+	ctx, span := tracing.Start(ctx, "UserService.Follow")
+	defer span.End()
+
 	if followRequest.FollowerID == followRequest.FollowingID {
-		return errors.New("A user cannot follow itself")
+		return ErrFollowItself
 	}
 
-	_, err := service.followerRepository.Follow(followRequest.FollowerID, followRequest.FollowingID)
+	created, err := service.followerRepository.Follow(ctx, followRequest.FollowerID, followRequest.FollowingID)
 
-	if err != nil {
+	if err != nil || !created {
 		return err
 	}
 
-	err = service.userRepository.IncrementFollowers(followRequest.FollowingID)
+	metrics.Follows.Inc()
 
-	if err != nil {
-		return err
-	}
-
-	err = service.userRepository.IncrementFollowing(followRequest.FollowerID)
+	// the follow is stored, a retry would not backfill again, so a failure is left to the rebuild of the timeline
+	err = service.timelineService.Backfill(ctx, followRequest.FollowerID, followRequest.FollowingID)
 
 	if err != nil {
-		return err
+		log.Printf("could not backfill the timeline of %s with %s: %v", followRequest.FollowerID, followRequest.FollowingID, err)
 	}
 
 	return nil
 }
 
-func (service *UserService) Unfollow(unfollowRequest *entity.UnfollowRequest) error {
-	err := service.followerRepository.Unfollow(unfollowRequest.FollowerID, unfollowRequest.FollowingID)
+func (service *UserService) Unfollow(ctx context.Context, unfollowRequest *entity.UnfollowRequest) error {
+	ctx, span := tracing.Start(ctx, "UserService.Unfollow")
+	defer span.End()
+
+	removed, err := service.followerRepository.Unfollow(ctx, unfollowRequest.FollowerID, unfollowRequest.FollowingID)
 
 	if err != nil {
 		return err
 	}
 
-	err = service.userRepository.DecrementFollowers(unfollowRequest.FollowingID)
+	if !removed {
+		return ErrNotFollowing
+	}
+
+	metrics.Unfollows.Inc()
+
+	// as on Follow, the unfollow is stored and a retry would not purge again
+	err = service.timelineService.Purge(ctx, unfollowRequest.FollowerID, unfollowRequest.FollowingID)
 
 	if err != nil {
-		return err
+		log.Printf("could not purge %s from the timeline of %s: %v", unfollowRequest.FollowingID, unfollowRequest.FollowerID, err)
 	}
 
-	err = service.userRepository.DecrementFollowing(unfollowRequest.FollowerID)
+	return nil
+}
+
+func (service *UserService) ListFollowers(ctx context.Context, listRequest *entity.ListConnectionsRequest) (*entity.UserList, []error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListFollowers")
+	defer span.End()
+
+	return service.listConnections(ctx, listRequest, service.followerRepository.ListFollowers)
+}
+
+func (service *UserService) ListFollowing(ctx context.Context, listRequest *entity.ListConnectionsRequest) (*entity.UserList, []error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListFollowing")
+	defer span.End()
+
+	return service.listConnections(ctx, listRequest, service.followerRepository.ListFollowing)
+}
+
+func (service *UserService) IsFollowing(ctx context.Context, followerId, followingId string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserService.IsFollowing")
+	defer span.End()
+
+	return service.followerRepository.IsFollowing(ctx, followerId, followingId)
+}
+
+func (service *UserService) UpdateTimezone(ctx context.Context, updateTimezoneRequest *entity.UpdateTimezoneRequest) []error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateTimezone")
+	defer span.End()
+
+	errs := entity.ValidateStruct(updateTimezoneRequest)
+
+	if errs != nil {
+		return errs
+	}
+
+	found, err := service.userRepository.SetTimezone(ctx, updateTimezoneRequest.UserID, updateTimezoneRequest.Timezone)
 
 	if err != nil {
-		return err
+		return []error{err}
+	}
+
+	if !found {
+		return []error{ErrUserNotFound}
 	}
 
 	return nil
 }
 
-func (service *UserService) IncreaseNumberOfPosts(id string) error {
-	return service.userRepository.IncreasePostsCount(id)
+func (service *UserService) listConnections(ctx context.Context,
+	listRequest *entity.ListConnectionsRequest,
+	list func(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error),
+) (*entity.UserList, []error) {
+	errs := entity.ValidateStruct(listRequest)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	if listRequest.Limit == 0 {
+		listRequest.Limit = service.configs.GetInt("app.users.list-connections-limit")
+	}
+
+	var cursor *primitive.ObjectID
+
+	if listRequest.Cursor != "" {
+		var err error
+
+		cursor, err = entity.DecodeCursor(listRequest.Cursor)
+
+		if err != nil {
+			return nil, []error{err}
+		}
+	}
+
+	connections, err := list(ctx, listRequest.UserID, cursor, listRequest.Limit)
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return entity.NewUserList(connections, listRequest.Limit), nil
 }
@@ -1,11 +1,17 @@
 package service
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/regiszanandrea/posty/configs/app"
+	timeline_service "github.com/regiszanandrea/posty/internal/timeline/service"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/test/mocks/follower"
+	"github.com/regiszanandrea/posty/test/mocks/post"
+	"github.com/regiszanandrea/posty/test/mocks/timeline"
 	"github.com/regiszanandrea/posty/test/mocks/user"
+	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 )
@@ -16,9 +22,14 @@ func TestFollowerRepository(t *testing.T) {
 }
 
 var (
+	configs *viper.Viper
 	service *UserService
 )
 
+var _ = BeforeSuite(func() {
+	configs = app.RegisterAppConfigs()
+})
+
 var _ = Describe("UserService suite test", func() {
 
 	Describe("Getting a user", func() {
@@ -27,10 +38,12 @@ var _ = Describe("UserService suite test", func() {
 				service = NewUserService(
 					&user_mock.SuccessUserRepositoryMock{},
 					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
 				)
 
 				userId := primitive.NewObjectID().Hex()
-				user, err := service.GetUser(userId)
+				user, err := service.GetUser(context.Background(), userId)
 
 				Expect(err).To(BeNil())
 				Expect(userId).To(Equal(user.ID.Hex()))
@@ -43,27 +56,84 @@ var _ = Describe("UserService suite test", func() {
 				service = NewUserService(
 					&user_mock.ErrorOnFindingUserRepositoryMock{},
 					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
 				)
 
-				_, err := service.GetUser(primitive.NewObjectID().Hex())
+				_, err := service.GetUser(context.Background(), primitive.NewObjectID().Hex())
 
 				Expect(err).NotTo(BeNil())
 			})
 		})
 	})
 
+	Describe("Getting a user by username", func() {
+		Context("when its given a username with the @", func() {
+			It("returns the user without it", func() {
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
+				)
+
+				user, err := service.GetUserByUsername(context.Background(), "@alice")
+
+				Expect(err).To(BeNil())
+				Expect(user.Username).To(Equal("alice"))
+			})
+		})
+
+		Context("when there is no user with the username", func() {
+			It("returns no user", func() {
+				service = NewUserService(
+					&user_mock.NotFoundUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
+				)
+
+				user, err := service.GetUserByUsername(context.Background(), "nobody")
+
+				Expect(err).To(BeNil())
+				Expect(user).To(BeNil())
+			})
+		})
+	})
+
 	Describe("Creating a user", func() {
 		Context("when its given a valid user", func() {
 			It("creates it without error", func() {
 				service = NewUserService(
 					&user_mock.SuccessUserRepositoryMock{},
 					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
 				)
 
-				id, err := service.CreateUser(&entity.User{Username: "testd"})
+				user := &entity.User{Username: "testd", Password: "a-secret-password"}
+
+				id, err := service.CreateUser(context.Background(), user)
 
 				Expect(err).To(BeNil())
 				Expect(primitive.IsValidObjectID(*id)).To(BeTrue())
+				Expect(user.Password).To(BeEmpty())
+				Expect(user.CheckPassword("a-secret-password")).To(BeTrue())
+			})
+		})
+
+		Context("when its given a user without password", func() {
+			It("returns error", func() {
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
+				)
+
+				_, err := service.CreateUser(context.Background(), &entity.User{Username: "testd"})
+
+				Expect(err).NotTo(BeNil())
 			})
 		})
 	})
@@ -73,6 +143,8 @@ var _ = Describe("UserService suite test", func() {
 			service = NewUserService(
 				&user_mock.SuccessUserRepositoryMock{},
 				&follower_mock.SuccessFollowerRepositoryMock{},
+				newTimelineService(),
+				configs,
 			)
 		})
 
@@ -83,7 +155,7 @@ var _ = Describe("UserService suite test", func() {
 					FollowerID:  primitive.NewObjectID().Hex(),
 				}
 
-				err := service.Follow(&request)
+				err := service.Follow(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 			})
@@ -96,9 +168,60 @@ var _ = Describe("UserService suite test", func() {
 					FollowerID:  userId,
 				}
 
-				err := service.Follow(&request)
+				err := service.Follow(context.Background(), &request)
 
-				Expect(err).NotTo(BeNil())
+				Expect(err).To(Equal(ErrFollowItself))
+			})
+		})
+
+		Context("when the timeline of the follower can not be backfilled", func() {
+			It("still follows the user, the timeline is left to its rebuild", func() {
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					timeline_service.NewTimelineService(
+						&timeline_mock.ErrorOnWritingTimelineRepositoryMock{},
+						&post_mock.SuccessPostRepositoryMock{},
+						&follower_mock.SuccessFollowerRepositoryMock{},
+						&user_mock.SuccessUserRepositoryMock{},
+						configs,
+					),
+					configs,
+				)
+
+				err := service.Follow(context.Background(), &entity.FollowRequest{
+					FollowingID: primitive.NewObjectID().Hex(),
+					FollowerID:  primitive.NewObjectID().Hex(),
+				})
+
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("when the follower already follows the user", func() {
+			It("succeeds without backfilling the timeline again", func() {
+				timelineRepository := &timeline_mock.SuccessTimelineRepositoryMock{}
+
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.AlreadyFollowingRepositoryMock{},
+					timeline_service.NewTimelineService(
+						timelineRepository,
+						&post_mock.SuccessPostRepositoryMock{},
+						&follower_mock.AlreadyFollowingRepositoryMock{},
+						&user_mock.SuccessUserRepositoryMock{},
+						configs,
+					),
+					configs,
+				)
+
+				err := service.Follow(context.Background(), &entity.FollowRequest{
+					FollowingID: primitive.NewObjectID().Hex(),
+					FollowerID:  primitive.NewObjectID().Hex(),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(timelineRepository.Pushed).To(BeEmpty())
 			})
 		})
 	})
@@ -108,6 +231,8 @@ var _ = Describe("UserService suite test", func() {
 			service = NewUserService(
 				&user_mock.SuccessUserRepositoryMock{},
 				&follower_mock.SuccessFollowerRepositoryMock{},
+				newTimelineService(),
+				configs,
 			)
 		})
 
@@ -118,27 +243,148 @@ var _ = Describe("UserService suite test", func() {
 					FollowerID:  primitive.NewObjectID().Hex(),
 				}
 
-				err := service.Unfollow(&request)
+				err := service.Unfollow(context.Background(), &request)
 
 				Expect(err).To(BeNil())
 			})
 		})
+
+		Context("when the timeline of the follower can not be purged", func() {
+			It("still unfollows the user, the timeline is left to its rebuild", func() {
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					timeline_service.NewTimelineService(
+						&timeline_mock.ErrorOnWritingTimelineRepositoryMock{},
+						&post_mock.SuccessPostRepositoryMock{},
+						&follower_mock.SuccessFollowerRepositoryMock{},
+						&user_mock.SuccessUserRepositoryMock{},
+						configs,
+					),
+					configs,
+				)
+
+				err := service.Unfollow(context.Background(), &entity.UnfollowRequest{
+					FollowingID: primitive.NewObjectID().Hex(),
+					FollowerID:  primitive.NewObjectID().Hex(),
+				})
+
+				Expect(err).To(BeNil())
+			})
+		})
+
+		Context("when the follower does not follow the user", func() {
+			It("returns not following", func() {
+				service = NewUserService(
+					&user_mock.SuccessUserRepositoryMock{},
+					&follower_mock.NotFollowingRepositoryMock{},
+					newTimelineService(),
+					configs,
+				)
+
+				err := service.Unfollow(context.Background(), &entity.UnfollowRequest{
+					FollowingID: primitive.NewObjectID().Hex(),
+					FollowerID:  primitive.NewObjectID().Hex(),
+				})
+
+				Expect(err).To(Equal(ErrNotFollowing))
+			})
+		})
 	})
 
-	Describe("Incrementing user number of posts", func() {
+	Describe("Listing followers", func() {
 		BeforeEach(func() {
 			service = NewUserService(
 				&user_mock.SuccessUserRepositoryMock{},
 				&follower_mock.SuccessFollowerRepositoryMock{},
+				newTimelineService(),
+				configs,
 			)
 		})
 
-		Context("when increment the user's posts number", func() {
-			It("increments without error", func() {
-				err := service.IncreaseNumberOfPosts(primitive.NewObjectID().Hex())
+		Context("when its given a user", func() {
+			It("returns the followers with a cursor", func() {
+				followers, errors := service.ListFollowers(context.Background(), &entity.ListConnectionsRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					Limit:  2,
+				})
 
-				Expect(err).To(BeNil())
+				Expect(errors).To(BeNil())
+				Expect(followers.Data).To(HaveLen(2))
+				Expect(followers.NextCursor).NotTo(BeEmpty())
+			})
+		})
+
+		Context("when its given an invalid cursor", func() {
+			It("returns error", func() {
+				_, errors := service.ListFollowing(context.Background(), &entity.ListConnectionsRequest{
+					UserID: primitive.NewObjectID().Hex(),
+					Cursor: "not a cursor",
+				})
+
+				Expect(errors[0]).To(Equal(entity.ErrInvalidCursor))
+			})
+		})
+	})
+
+	Describe("Updating the timezone of a user", func() {
+		BeforeEach(func() {
+			service = NewUserService(
+				&user_mock.SuccessUserRepositoryMock{},
+				&follower_mock.SuccessFollowerRepositoryMock{},
+				newTimelineService(),
+				configs,
+			)
+		})
+
+		Context("when its given a valid timezone", func() {
+			It("updates it without error", func() {
+				errors := service.UpdateTimezone(context.Background(), &entity.UpdateTimezoneRequest{
+					UserID:   primitive.NewObjectID().Hex(),
+					Timezone: "America/Sao_Paulo",
+				})
+
+				Expect(errors).To(BeNil())
+			})
+		})
+
+		Context("when its given an unknown timezone", func() {
+			It("returns a validation error", func() {
+				errors := service.UpdateTimezone(context.Background(), &entity.UpdateTimezoneRequest{
+					UserID:   primitive.NewObjectID().Hex(),
+					Timezone: "Mars/Olympus_Mons",
+				})
+
+				Expect(errors).To(HaveLen(1))
+			})
+		})
+
+		Context("when the user does not exist", func() {
+			It("returns not found", func() {
+				service = NewUserService(
+					&user_mock.NotFoundUserRepositoryMock{},
+					&follower_mock.SuccessFollowerRepositoryMock{},
+					newTimelineService(),
+					configs,
+				)
+
+				errors := service.UpdateTimezone(context.Background(), &entity.UpdateTimezoneRequest{
+					UserID:   primitive.NewObjectID().Hex(),
+					Timezone: "America/Sao_Paulo",
+				})
+
+				Expect(errors[0]).To(Equal(ErrUserNotFound))
 			})
 		})
 	})
 })
+
+func newTimelineService() *timeline_service.TimelineService {
+	return timeline_service.NewTimelineService(
+		&timeline_mock.SuccessTimelineRepositoryMock{},
+		&post_mock.SuccessPostRepositoryMock{},
+		&follower_mock.SuccessFollowerRepositoryMock{},
+		&user_mock.SuccessUserRepositoryMock{},
+		configs,
+	)
+}
@@ -13,14 +13,8 @@ import (
 var (
 	Module = Options(
 		Provide(
-			Annotate(
-				user_repository.NewUserRepository,
-				As(new(user_repository.Repository)),
-			),
-			Annotate(
-				follower_repository.NewFollowerRepository,
-				As(new(follower_repository.Repository)),
-			),
+			user_repository.NewRepository,
+			follower_repository.NewRepository,
 			Annotate(
 				service.NewUserService,
 				As(new(service.Service)),
@@ -1,18 +1,18 @@
 package post_test
 
 import (
-	"context"
 	"encoding/json"
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/regiszanandrea/posty/configs/app"
+	"github.com/regiszanandrea/posty/internal/apperror"
 	"github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/post/http/handler"
 	helper "github.com/regiszanandrea/posty/test"
 	"github.com/spf13/viper"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/fx"
+	"net/http"
 	"strconv"
 	"testing"
 
@@ -21,11 +21,8 @@ import (
 )
 
 var (
-	application         *fx.App
-	configs             *viper.Viper
-	postsCollection     *mongo.Collection
-	followersCollection *mongo.Collection
-	usersCollection     *mongo.Collection
+	application *fx.App
+	configs     *viper.Viper
 )
 
 func TestPostApi(t *testing.T) {
@@ -37,49 +34,21 @@ var _ = BeforeSuite(func() {
 	application = helper.SetUpFXApp()
 
 	configs = app.RegisterAppConfigs()
-
-	postsCollection = helper.MongoClient.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.post-collection"),
-	)
-
-	usersCollection = helper.MongoClient.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.user-collection"),
-	)
-
-	followersCollection = helper.MongoClient.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.follower-collection"),
-	)
 })
 
 var _ = AfterSuite(func() {
 	helper.StopFXApp(application)
 
-	_, err := usersCollection.DeleteMany(context.Background(), bson.M{})
-
-	if err != nil {
-		panic(err)
-	}
-
-	_, err = postsCollection.DeleteMany(context.Background(), bson.M{})
-
-	if err != nil {
-		panic(err)
-	}
+	helper.CleanUp()
 })
 
 var _ = Describe("Post API test", func() {
 	Describe("Creating post", func() {
 		Context("when its given a valid post", func() {
 			It("creates it without error", func() {
-				user := helper.CreateUsers(1, usersCollection)
+				user := helper.CreateUsers(1)
 
-				userId := user.InsertedIDs[0].(primitive.ObjectID).Hex()
+				userId := user[0].Hex()
 
 				requestBody := map[string]string{
 					"content": faker.Paragraph(),
@@ -87,21 +56,28 @@ var _ = Describe("Post API test", func() {
 
 				endpoint := "/users/" + userId + "/posts"
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, requestBody)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					requestBody,
+					helper.GenerateToken(configs, userId),
+				)
 
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusCreated))
+				Expect(resp.Header.Get(handler.HeaderPostQuotaLimit)).To(Equal(configs.GetString("app.quota.posts.limit")))
+				Expect(resp.Header.Get(handler.HeaderPostQuotaRemaining)).To(Equal(strconv.Itoa(configs.GetInt("app.quota.posts.limit") - 1)))
 			})
 		})
 
 		Context("when its given a quoted-post", func() {
 			It("creates it without error", func() {
-				user := helper.CreateUsers(1, usersCollection)
+				user := helper.CreateUsers(1)
 
-				userId := user.InsertedIDs[0].(primitive.ObjectID)
+				userId := user[0]
 
-				post := helper.CreatePosts(1, userId, postsCollection)
+				post := helper.CreatePosts(1, userId)
 
-				postId := post.InsertedIDs[0].(primitive.ObjectID).Hex()
+				postId := post[0].Hex()
 
 				requestBody := map[string]string{
 					"content":   faker.Paragraph(),
@@ -110,7 +86,12 @@ var _ = Describe("Post API test", func() {
 
 				endpoint := "/users/" + userId.Hex() + "/posts"
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, requestBody)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					requestBody,
+					helper.GenerateToken(configs, userId.Hex()),
+				)
 
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusCreated))
 			})
@@ -119,13 +100,13 @@ var _ = Describe("Post API test", func() {
 
 		Context("when its given a repost", func() {
 			It("creates it without error", func() {
-				user := helper.CreateUsers(1, usersCollection)
+				user := helper.CreateUsers(1)
 
-				userId := user.InsertedIDs[0].(primitive.ObjectID)
+				userId := user[0]
 
-				post := helper.CreatePosts(1, userId, postsCollection)
+				post := helper.CreatePosts(1, userId)
 
-				postId := post.InsertedIDs[0].(primitive.ObjectID).Hex()
+				postId := post[0].Hex()
 
 				requestBody := map[string]string{
 					"parent_id": postId,
@@ -133,19 +114,66 @@ var _ = Describe("Post API test", func() {
 
 				endpoint := "/users/" + userId.Hex() + "/posts"
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, requestBody)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					requestBody,
+					helper.GenerateToken(configs, userId.Hex()),
+				)
 
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusCreated))
 			})
 		})
 
-		Context("when its reach maximum number of posts per day", func() {
+		Context("when its given another user's id", func() {
+			It("returns forbidden", func() {
+				users := helper.CreateUsers(2)
+
+				userId := users[0].Hex()
+				anotherUserId := users[1].Hex()
+
+				requestBody := map[string]string{
+					"content": faker.Paragraph(),
+				}
+
+				endpoint := "/users/" + anotherUserId + "/posts"
+
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					requestBody,
+					helper.GenerateToken(configs, userId),
+				)
+
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusForbidden))
+			})
+		})
+
+		Context("when its not authenticated", func() {
+			It("returns unauthorized", func() {
+				user := helper.CreateUsers(1)
+
+				userId := user[0].Hex()
+
+				requestBody := map[string]string{
+					"content": faker.Paragraph(),
+				}
+
+				endpoint := "/users/" + userId + "/posts"
+
+				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, requestBody)
+
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusUnauthorized))
+			})
+		})
+
+		Context("when its reach the quota of posts", func() {
 			It("returns error and not creates a new post", func() {
-				user := helper.CreateUsers(1, usersCollection)
+				user := helper.CreateUsers(1)
 
-				userId := user.InsertedIDs[0].(primitive.ObjectID)
+				userId := user[0]
 
-				helper.CreatePosts(configs.GetInt("app.posts.maximum-per-day"), userId, postsCollection)
+				helper.CreatePosts(configs.GetInt("app.quota.posts.limit"), userId)
 
 				requestBody := map[string]string{
 					"content": faker.Paragraph(),
@@ -153,9 +181,141 @@ var _ = Describe("Post API test", func() {
 
 				endpoint := "/users/" + userId.Hex() + "/posts"
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, requestBody)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					requestBody,
+					helper.GenerateToken(configs, userId.Hex()),
+				)
+
+				var problem apperror.Problem
+
+				json.NewDecoder(resp.Body).Decode(&problem)
+
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusTooManyRequests))
+				Expect(resp.Header.Get(fiber.HeaderContentType)).To(Equal(apperror.ProblemContentType))
+				Expect(problem.Code).To(Equal("posts_quota_reached"))
+				Expect(resp.Header.Get(handler.HeaderPostQuotaRemaining)).To(Equal("0"))
+				Expect(resp.Header.Get(fiber.HeaderRetryAfter)).NotTo(BeEmpty())
+			})
+		})
+
+		Describe("Deleting a post", func() {
+			Context("when its given a post from the user", func() {
+				It("deletes it without error", func() {
+					user := helper.CreateUsers(1)
+
+					userId := user[0]
+
+					post := helper.CreatePosts(1, userId)
+
+					postId := post[0].Hex()
+
+					endpoint := "/users/" + userId.Hex() + "/posts/" + postId
+
+					resp := helper.MakeAuthenticatedRequest(
+						http.MethodDelete,
+						configs.GetString("app.fiber.address"),
+						endpoint,
+						nil,
+						helper.GenerateToken(configs, userId.Hex()),
+					)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 
-				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusBadRequest))
+					resp = helper.MakeAuthenticatedRequest(
+						http.MethodDelete,
+						configs.GetString("app.fiber.address"),
+						endpoint,
+						nil,
+						helper.GenerateToken(configs, userId.Hex()),
+					)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusNotFound))
+				})
+			})
+		})
+
+		Describe("Editing a post", func() {
+			Context("when its given a post from the user on the edit window", func() {
+				It("edits it and keeps the previous version on its history", func() {
+					user := helper.CreateUsers(1)
+
+					userId := user[0]
+
+					post := helper.CreatePosts(1, userId)
+
+					postId := post[0].Hex()
+
+					endpoint := "/users/" + userId.Hex() + "/posts/" + postId
+
+					resp := helper.MakeAuthenticatedRequest(
+						http.MethodPatch,
+						configs.GetString("app.fiber.address"),
+						endpoint,
+						map[string]string{"content": "this is a post without typos"},
+						helper.GenerateToken(configs, userId.Hex()),
+					)
+
+					var edited entity.Post
+
+					json.NewDecoder(resp.Body).Decode(&edited)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
+					Expect(edited.Content).To(Equal("this is a post without typos"))
+					Expect(edited.EditedAt).NotTo(BeNil())
+
+					resp = helper.MakeGetRequest(configs.GetString("app.fiber.address"), endpoint+"/history", nil)
+
+					var history entity.PostHistory
+
+					json.NewDecoder(resp.Body).Decode(&history)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
+					Expect(history.Post.Content).To(Equal("this is a post without typos"))
+					Expect(history.Versions).To(HaveLen(1))
+				})
+			})
+		})
+
+		Describe("Getting a conversation", func() {
+			Context("when its given a replied post", func() {
+				It("returns its replies", func() {
+					user := helper.CreateUsers(1)
+
+					userId := user[0]
+
+					post := helper.CreatePosts(1, userId)
+
+					postId := post[0].Hex()
+
+					resp := helper.MakeAuthenticatedPostRequest(
+						configs.GetString("app.fiber.address"),
+						"/users/"+userId.Hex()+"/posts",
+						map[string]string{
+							"content":        faker.Paragraph(),
+							"in_reply_to_id": postId,
+						},
+						helper.GenerateToken(configs, userId.Hex()),
+					)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusCreated))
+
+					resp = helper.MakeGetRequest(
+						configs.GetString("app.fiber.address"),
+						"/posts/"+postId+"/conversation",
+						map[string]string{},
+					)
+
+					var conversation entity.Conversation
+
+					json.NewDecoder(resp.Body).Decode(&conversation)
+
+					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
+					Expect(conversation.Root.ID.Hex()).To(Equal(postId))
+					Expect(conversation.Root.RepliesCount).To(BeEquivalentTo(1))
+					Expect(conversation.Replies).To(HaveLen(1))
+				})
 			})
 		})
 
@@ -164,24 +324,23 @@ var _ = Describe("Post API test", func() {
 				It("returns posts from this user", func() {
 					expectedPostsReturned := 5
 
-					user := helper.CreateUsers(1, usersCollection)
+					user := helper.CreateUsers(1)
 
-					userId := user.InsertedIDs[0].(primitive.ObjectID)
+					userId := user[0]
 
-					helper.CreatePosts(10, userId, postsCollection)
+					helper.CreatePosts(10, userId)
 
 					endpoint := "/users/" + userId.Hex() + "/posts"
 
 					resp := helper.MakeGetRequest(configs.GetString("app.fiber.address"), endpoint, map[string]string{
-						"page":  "1",
 						"limit": strconv.Itoa(expectedPostsReturned),
 					})
 
-					var posts []entity.Post
+					var posts entity.PostList
 
 					json.NewDecoder(resp.Body).Decode(&posts)
 
-					Expect(len(posts)).To(Equal(expectedPostsReturned))
+					Expect(len(posts.Data)).To(Equal(expectedPostsReturned))
 					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 				})
 			})
@@ -192,42 +351,39 @@ var _ = Describe("Post API test", func() {
 				It("returns posts from its followers", func() {
 					expectedPostsReturned := 10
 
-					users := helper.CreateUsers(5, usersCollection)
-					follower := users.InsertedIDs[0].(primitive.ObjectID)
+					users := helper.CreateUsers(5)
+					follower := users[0]
 
-					firstFollowed := users.InsertedIDs[1].(primitive.ObjectID)
-					secondFollowed := users.InsertedIDs[2].(primitive.ObjectID)
+					firstFollowed := users[1]
+					secondFollowed := users[2]
 
 					helper.CreateFollower(
 						follower,
 						firstFollowed,
-						followersCollection,
 					)
 
 					helper.CreateFollower(
 						follower,
 						secondFollowed,
-						followersCollection,
 					)
 
-					helper.CreatePosts(5, firstFollowed, postsCollection)
-					helper.CreatePosts(5, secondFollowed, postsCollection)
+					helper.CreatePosts(5, firstFollowed)
+					helper.CreatePosts(5, secondFollowed)
 
 					endpoint := "/users/" + follower.Hex() + "/feed"
 
 					resp := helper.MakeGetRequest(configs.GetString("app.fiber.address"), endpoint, map[string]string{
-						"page":  "1",
 						"limit": strconv.Itoa(expectedPostsReturned),
 					})
 
-					var posts []entity.Post
+					var posts entity.PostList
 
 					json.NewDecoder(resp.Body).Decode(&posts)
 
-					Expect(len(posts)).To(Equal(expectedPostsReturned))
+					Expect(len(posts.Data)).To(Equal(expectedPostsReturned))
 					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 
-					for _, post := range posts {
+					for _, post := range posts.Data {
 						Expect([]primitive.ObjectID{firstFollowed, secondFollowed}).To(ContainElement(post.UserID))
 					}
 				})
@@ -235,22 +391,20 @@ var _ = Describe("Post API test", func() {
 
 			Context("when its given a user that doesnt follow anyone", func() {
 				It("returns no posts", func() {
-					user := helper.CreateUsers(1, usersCollection)
-					userId := user.InsertedIDs[0].(primitive.ObjectID)
+					user := helper.CreateUsers(1)
+					userId := user[0]
 
 					endpoint := "/users/" + userId.Hex() + "/feed"
 
-					resp := helper.MakeGetRequest(configs.GetString("app.fiber.address"), endpoint, map[string]string{
-						"page": "1",
-					})
+					resp := helper.MakeGetRequest(configs.GetString("app.fiber.address"), endpoint, nil)
 
-					var posts []entity.Post
+					var posts entity.PostList
 
 					json.NewDecoder(resp.Body).Decode(&posts)
 
 					Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 
-					Expect(len(posts)).To(Equal(0))
+					Expect(len(posts.Data)).To(Equal(0))
 				})
 			})
 		})
@@ -1,7 +1,6 @@
 package user_test
 
 import (
-	"context"
 	"encoding/json"
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofiber/fiber/v2"
@@ -11,18 +10,14 @@ import (
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/test"
 	"github.com/spf13/viper"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/fx"
 	"testing"
 )
 
 var (
-	application         *fx.App
-	configs             *viper.Viper
-	followersCollection *mongo.Collection
-	usersCollection     *mongo.Collection
+	application *fx.App
+	configs     *viper.Viper
 )
 
 func TestUserApi(t *testing.T) {
@@ -34,34 +29,12 @@ var _ = BeforeSuite(func() {
 	application = helper.SetUpFXApp()
 
 	configs = app.RegisterAppConfigs()
-
-	usersCollection = helper.MongoClient.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.user-collection"),
-	)
-
-	followersCollection = helper.MongoClient.Database(
-		configs.GetString("app.mongodb.database"),
-	).Collection(
-		configs.GetString("app.mongodb.follower-collection"),
-	)
 })
 
 var _ = AfterSuite(func() {
 	helper.StopFXApp(application)
 
-	_, err := usersCollection.DeleteMany(context.Background(), bson.M{})
-
-	if err != nil {
-		panic(err)
-	}
-
-	_, err = followersCollection.DeleteMany(context.Background(), bson.M{})
-
-	if err != nil {
-		panic(err)
-	}
+	helper.CleanUp()
 })
 
 var _ = Describe("User API test", func() {
@@ -70,6 +43,7 @@ var _ = Describe("User API test", func() {
 			It("creates it without error", func() {
 				requestBody := map[string]string{
 					"username": faker.Username(),
+					"password": faker.Password(),
 				}
 
 				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), "/users", requestBody)
@@ -82,11 +56,12 @@ var _ = Describe("User API test", func() {
 			It("returns error", func() {
 				requestBody := map[string]string{
 					"username": faker.Username(),
+					"password": faker.Password(),
 				}
 
 				helper.MakePostRequest(configs.GetString("app.fiber.address"), "/users", requestBody)
 				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), "/users", requestBody)
-				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusBadRequest))
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusConflict))
 			})
 		})
 	})
@@ -96,6 +71,7 @@ var _ = Describe("User API test", func() {
 			It("returns it", func() {
 				requestBody := map[string]string{
 					"username": faker.Username(),
+					"password": faker.Password(),
 				}
 				var user, userReturned entity.User
 
@@ -123,14 +99,19 @@ var _ = Describe("User API test", func() {
 	Describe("Following a user", func() {
 		Context("when its given a follower and the followed user", func() {
 			It("follows without error", func() {
-				results := helper.CreateUsers(2, usersCollection)
+				results := helper.CreateUsers(2)
 
-				followerId := results.InsertedIDs[0].(primitive.ObjectID).Hex()
-				followingId := results.InsertedIDs[1].(primitive.ObjectID).Hex()
+				followerId := results[0].Hex()
+				followingId := results[1].Hex()
 
 				endpoint := "/users/" + followerId + "/follow/" + followingId
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, nil)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					nil,
+					helper.GenerateToken(configs, followerId),
+				)
 
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 
@@ -146,33 +127,85 @@ var _ = Describe("User API test", func() {
 
 				endpoint := "/users/" + userId + "/follow/" + userId
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, nil)
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					nil,
+					helper.GenerateToken(configs, userId),
+				)
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusBadRequest))
 			})
 		})
 	})
 
+	Describe("Following as another user", func() {
+		Context("when the token does not belong to the follower", func() {
+			It("returns forbidden", func() {
+				results := helper.CreateUsers(2)
+
+				followerId := results[0].Hex()
+				followingId := results[1].Hex()
+
+				endpoint := "/users/" + followerId + "/follow/" + followingId
+
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					nil,
+					helper.GenerateToken(configs, followingId),
+				)
+
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusForbidden))
+			})
+		})
+	})
+
 	Describe("Unfollowing a user", func() {
 		Context("when its given a follower and the followed user", func() {
 			It("unfollows without error", func() {
-				results := helper.CreateUsers(2, usersCollection)
+				results := helper.CreateUsers(2)
+
+				followerId := results[0].Hex()
+				followingId := results[1].Hex()
 
-				followerId := results.InsertedIDs[0].(primitive.ObjectID).Hex()
-				followingId := results.InsertedIDs[1].(primitive.ObjectID).Hex()
+				token := helper.GenerateToken(configs, followerId)
 
 				endpoint := "/users/" + followerId + "/follow/" + followingId
 
-				helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, nil)
+				helper.MakeAuthenticatedPostRequest(configs.GetString("app.fiber.address"), endpoint, nil, token)
 
 				endpoint = "/users/" + followerId + "/unfollow/" + followingId
 
-				resp := helper.MakePostRequest(configs.GetString("app.fiber.address"), endpoint, nil)
+				resp := helper.MakeAuthenticatedPostRequest(configs.GetString("app.fiber.address"), endpoint, nil, token)
 				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusOK))
 
 				assertNumberOfFollowing(followerId, 0)
 				assertNumberOfFollowers(followingId, 0)
 			})
 		})
+
+		Context("when the follower does not follow the user", func() {
+			It("returns not found and keeps the counters", func() {
+				results := helper.CreateUsers(2)
+
+				followerId := results[0].Hex()
+				followingId := results[1].Hex()
+
+				endpoint := "/users/" + followerId + "/unfollow/" + followingId
+
+				resp := helper.MakeAuthenticatedPostRequest(
+					configs.GetString("app.fiber.address"),
+					endpoint,
+					nil,
+					helper.GenerateToken(configs, followerId),
+				)
+
+				Expect(resp.StatusCode).To(BeEquivalentTo(fiber.StatusNotFound))
+
+				assertNumberOfFollowing(followerId, 0)
+				assertNumberOfFollowers(followingId, 0)
+			})
+		})
 	})
 })
 
@@ -3,52 +3,93 @@ package helper
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"github.com/bxcodec/faker/v3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/regiszanandrea/posty/internal"
+	"github.com/regiszanandrea/posty/internal/auth"
+	auth_service "github.com/regiszanandrea/posty/internal/auth/service"
+	"github.com/regiszanandrea/posty/internal/health"
+	"github.com/regiszanandrea/posty/internal/memory"
+	"github.com/regiszanandrea/posty/internal/metrics"
 	"github.com/regiszanandrea/posty/internal/mongodb"
 	"github.com/regiszanandrea/posty/internal/post"
 	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	post_repository "github.com/regiszanandrea/posty/internal/post/repository"
+	"github.com/regiszanandrea/posty/internal/postgres"
+	"github.com/regiszanandrea/posty/internal/search"
+	"github.com/regiszanandrea/posty/internal/tracing"
 	"github.com/regiszanandrea/posty/internal/user"
 	"github.com/regiszanandrea/posty/internal/user/entity"
+	follower_repository "github.com/regiszanandrea/posty/internal/user/repository/follower"
+	user_repository "github.com/regiszanandrea/posty/internal/user/repository/user"
 	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/fx"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
 )
 
+// the repositories seed the suites, so they run against the driver set on app.storage.driver
 var (
-	MongoClient *mongo.Client
+	userRepository     user_repository.Repository
+	followerRepository follower_repository.Repository
+	postRepository     post_repository.Repository
+	database           *memory.Database
+	db                 *sql.DB
+	client             *mongo.Client
+	configs            *viper.Viper
 )
 
+// SetUpFXApp builds the application listening on a free port, which is set on app.fiber.address,
+// so suites running in parallel do not answer each other's requests
 func SetUpFXApp() *fx.App {
-	return fx.New(
+	app := fx.New(
 		fx.NopLogger,
 		internal.ApplicationModule,
-		fx.Invoke(RegisterMongoDB),
+		metrics.Invokables,
+		tracing.Invokables,
+		fx.Invoke(RegisterMongoDB, RegisterPostgres),
+		auth.Invokables,
 		user.Invokables,
 		post.Invokables,
-		fx.Invoke(func(fa *fiber.App, c *viper.Viper) {
-			go Boot(c)(fa)
-		}),
+		search.Invokables,
+		health.Invokables,
+		fx.Invoke(Listen),
+		fx.Populate(&userRepository, &followerRepository, &postRepository, &database, &db, &client, &configs),
 	)
+
+	if err := app.Err(); err != nil {
+		panic(err)
+	}
+
+	return app
 }
 
-func Boot(c *viper.Viper) func(app *fiber.App) error {
-	return func(app *fiber.App) error {
-		if err := app.Listen(c.GetString("app.fiber.address")); err != nil {
-			return err
-		}
+func Listen(app *fiber.App, c *viper.Viper) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
 
-		return nil
+	if err != nil {
+		panic(err)
 	}
+
+	c.Set("app.fiber.address", listener.Addr().String())
+
+	go func() {
+		_ = app.Listener(listener)
+	}()
 }
 
 func RegisterMongoDB(client *mongo.Client, configs *viper.Viper) {
+	if !mongodb.IsUsed(configs) {
+		return
+	}
+
 	err := client.Connect(context.Background())
 
 	if err != nil {
@@ -60,8 +101,19 @@ func RegisterMongoDB(client *mongo.Client, configs *viper.Viper) {
 	if err != nil {
 		panic(err)
 	}
+}
+
+// RegisterPostgres migrates the database when a driver uses it, the application is not started
+func RegisterPostgres(db *sql.DB, configs *viper.Viper) {
+	if !postgres.IsUsed(configs) {
+		return
+	}
+
+	err := postgres.Migrate(context.Background(), db)
 
-	MongoClient = client
+	if err != nil {
+		panic(err)
+	}
 }
 
 func StopFXApp(app *fx.App) {
@@ -94,6 +146,41 @@ func MakePostRequest(host string, endpoint string, body map[string]string) *http
 	return resp
 }
 
+func MakeAuthenticatedPostRequest(host string, endpoint string, body map[string]string, token string) *http.Response {
+	return MakeAuthenticatedRequest(http.MethodPost, host, endpoint, body, token)
+}
+
+func MakeAuthenticatedRequest(method string, host string, endpoint string, body map[string]string, token string) *http.Response {
+	requestBody, _ := json.Marshal(body)
+
+	req, err := http.NewRequest(method, "http://"+host+endpoint, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		panic(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return resp
+}
+
+func GenerateToken(c *viper.Viper, userId string) string {
+	token, err := auth_service.GenerateToken(userId, c.GetDuration("app.auth.token-ttl"), c.GetString("app.auth.secret"))
+
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
+
 func MakeGetRequest(host string, endpoint string, queryParams map[string]string) *http.Response {
 	base, _ := url.Parse("http://" + host + endpoint)
 
@@ -114,47 +201,91 @@ func MakeGetRequest(host string, endpoint string, queryParams map[string]string)
 	return resp
 }
 
-func CreateUsers(numberOfUsers int, usersCollection *mongo.Collection) *mongo.InsertManyResult {
-	var users []interface{}
+// CleanUp removes everything the suite stored, whichever the driver is
+func CleanUp() {
+	switch configs.GetString("app.storage.driver") {
+	case "memory":
+		database.Reset()
+	case "postgres":
+		_, err := db.Exec("TRUNCATE users, followers, posts, post_versions, likes, timelines, timeline_entries")
+
+		if err != nil {
+			panic(err)
+		}
+	case "mongodb":
+		for _, collection := range []string{
+			"app.mongodb.user-collection",
+			"app.mongodb.follower-collection",
+			"app.mongodb.post-collection",
+			"app.mongodb.post-version-collection",
+			"app.mongodb.like-collection",
+			"app.mongodb.timeline-collection",
+		} {
+			_, err := client.Database(
+				configs.GetString("app.mongodb.database"),
+			).Collection(
+				configs.GetString(collection),
+			).DeleteMany(context.Background(), bson.M{})
+
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+func CreateUsers(numberOfUsers int) []primitive.ObjectID {
+	var ids []primitive.ObjectID
 
 	for i := 0; i < numberOfUsers; i++ {
-		u := entity.User{
+		id, err := userRepository.Create(context.Background(), &entity.User{
 			Username:  faker.Username(),
 			CreatedAt: time.Now(),
+		})
+
+		if err != nil {
+			panic(err)
 		}
 
-		users = append(users, u)
+		ids = append(ids, toObjectID(id))
 	}
 
-	results, _ := usersCollection.InsertMany(context.Background(), users)
-	return results
+	return ids
 }
 
-func CreatePosts(numberOfPosts int, userId primitive.ObjectID, postsCollection *mongo.Collection) *mongo.InsertManyResult {
-	var users []interface{}
+func CreatePosts(numberOfPosts int, userId primitive.ObjectID) []primitive.ObjectID {
+	var ids []primitive.ObjectID
 
 	for i := 0; i < numberOfPosts; i++ {
-		u := post_entity.Post{
-			UserID:    userId,
-			Content:   faker.Paragraph(),
-			CreatedAt: time.Now(),
+		id, err := postRepository.Create(context.Background(), &post_entity.Post{
+			UserID:  userId,
+			Content: faker.Paragraph(),
+		})
+
+		if err != nil {
+			panic(err)
 		}
 
-		users = append(users, u)
+		ids = append(ids, toObjectID(id))
 	}
 
-	results, _ := postsCollection.InsertMany(context.Background(), users)
-	return results
+	return ids
 }
 
-func CreateFollower(followerId primitive.ObjectID, followingId primitive.ObjectID, followersCollection *mongo.Collection) *mongo.InsertOneResult {
-	follower := entity.Follower{
-		FollowerID:  followerId,
-		FollowingID: followingId,
-		CreatedAt:   time.Now(),
+func CreateFollower(followerId primitive.ObjectID, followingId primitive.ObjectID) {
+	_, err := followerRepository.Follow(context.Background(), followerId.Hex(), followingId.Hex())
+
+	if err != nil {
+		panic(err)
 	}
+}
+
+func toObjectID(id string) primitive.ObjectID {
+	objectId, err := primitive.ObjectIDFromHex(id)
 
-	result, _ := followersCollection.InsertOne(context.Background(), follower)
+	if err != nil {
+		panic(err)
+	}
 
-	return result
+	return objectId
 }
@@ -1,6 +1,9 @@
 package follower_mock
 
 import (
+	"context"
+	"errors"
+	"github.com/regiszanandrea/posty/internal/user/entity"
 	follower_repository "github.com/regiszanandrea/posty/internal/user/repository/follower"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -9,7 +12,7 @@ type SuccessFollowerRepositoryMock struct {
 	follower_repository.Repository
 }
 
-func (repo *SuccessFollowerRepositoryMock) GetFollowingUsers(followerID string) ([]string, error) {
+func (repo *SuccessFollowerRepositoryMock) GetFollowingUsers(ctx context.Context, followerID string) ([]string, error) {
 	return []string{
 		primitive.NewObjectID().Hex(),
 		primitive.NewObjectID().Hex(),
@@ -17,9 +20,113 @@ func (repo *SuccessFollowerRepositoryMock) GetFollowingUsers(followerID string)
 	}, nil
 }
 
-func (repo *SuccessFollowerRepositoryMock) Follow(followerId, followingId string) (string, error) {
-	return primitive.NewObjectID().Hex(), nil
+func (repo *SuccessFollowerRepositoryMock) GetFollowers(ctx context.Context, userId string) ([]string, error) {
+	return []string{
+		primitive.NewObjectID().Hex(),
+		primitive.NewObjectID().Hex(),
+	}, nil
+}
+
+// FilterFollowers considers that every candidate follows the user
+func (repo *SuccessFollowerRepositoryMock) FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error) {
+	return candidates, nil
+}
+
+// FilterFollowing considers that the user follows every candidate
+func (repo *SuccessFollowerRepositoryMock) FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error) {
+	return candidates, nil
+}
+
+func (repo *SuccessFollowerRepositoryMock) IsFollowing(ctx context.Context, followerId, followingId string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessFollowerRepositoryMock) ListFollowers(ctx context.Context, userId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return newConnections(limit), nil
 }
-func (repo *SuccessFollowerRepositoryMock) Unfollow(followerId, followingId string) error {
-	return nil
+
+func (repo *SuccessFollowerRepositoryMock) ListFollowing(ctx context.Context, followerId string, cursor *primitive.ObjectID, limit int) ([]*entity.Connection, error) {
+	return newConnections(limit), nil
+}
+
+func (repo *SuccessFollowerRepositoryMock) Follow(ctx context.Context, followerId, followingId string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessFollowerRepositoryMock) Unfollow(ctx context.Context, followerId, followingId string) (bool, error) {
+	return true, nil
+}
+
+// NoMutualFollowerRepositoryMock has followers that follow no one else
+type NoMutualFollowerRepositoryMock struct {
+	SuccessFollowerRepositoryMock
+}
+
+func (repo *NoMutualFollowerRepositoryMock) FilterFollowers(ctx context.Context, userId string, candidates []string) ([]string, error) {
+	return nil, nil
+}
+
+func (repo *NoMutualFollowerRepositoryMock) FilterFollowing(ctx context.Context, followerId string, candidates []string) ([]string, error) {
+	return nil, nil
+}
+
+// CountFollowers considers that every user has 2 followers
+func (repo *SuccessFollowerRepositoryMock) CountFollowers(ctx context.Context, userIds []string) (map[string]int64, error) {
+	return newCounts(userIds, 2), nil
+}
+
+// CountFollowing considers that every user follows 3 users
+func (repo *SuccessFollowerRepositoryMock) CountFollowing(ctx context.Context, followerIds []string) (map[string]int64, error) {
+	return newCounts(followerIds, 3), nil
+}
+
+func newCounts(ids []string, count int64) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, id := range ids {
+		counts[id] = count
+	}
+
+	return counts
+}
+
+func newConnections(number int) []*entity.Connection {
+	var connections []*entity.Connection
+	for i := 0; i < number; i++ {
+		connections = append(connections, &entity.Connection{
+			ID: primitive.NewObjectID(),
+			User: &entity.UserSummary{
+				ID:       primitive.NewObjectID(),
+				Username: "test",
+			},
+		})
+	}
+
+	return connections
+}
+
+// AlreadyFollowingRepositoryMock has every user already following each other
+type AlreadyFollowingRepositoryMock struct {
+	SuccessFollowerRepositoryMock
+}
+
+func (repo *AlreadyFollowingRepositoryMock) Follow(ctx context.Context, followerId, followingId string) (bool, error) {
+	return false, nil
+}
+
+// NotFollowingRepositoryMock has no relationship between any users
+type NotFollowingRepositoryMock struct {
+	SuccessFollowerRepositoryMock
+}
+
+func (repo *NotFollowingRepositoryMock) Unfollow(ctx context.Context, followerId, followingId string) (bool, error) {
+	return false, nil
+}
+
+// ErrorOnGettingFollowingRepositoryMock fails to list the users a user follows
+type ErrorOnGettingFollowingRepositoryMock struct {
+	SuccessFollowerRepositoryMock
+}
+
+func (repo *ErrorOnGettingFollowingRepositoryMock) GetFollowingUsers(ctx context.Context, followerID string) ([]string, error) {
+	return nil, errors.New("error on getting following")
 }
@@ -0,0 +1,63 @@
+package like_mock
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/mongodb"
+	"github.com/regiszanandrea/posty/internal/post/entity"
+	like_repository "github.com/regiszanandrea/posty/internal/post/repository/like"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type SuccessLikeRepositoryMock struct {
+	like_repository.Repository
+	Likes int
+}
+
+func (repo *SuccessLikeRepositoryMock) Like(ctx context.Context, userId, postId string) (string, error) {
+	repo.Likes++
+	return primitive.NewObjectID().Hex(), nil
+}
+
+func (repo *SuccessLikeRepositoryMock) Unlike(ctx context.Context, userId, postId string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessLikeRepositoryMock) GetByPost(ctx context.Context, postId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	postObjectId, _ := primitive.ObjectIDFromHex(postId)
+
+	return createLikes(primitive.NewObjectID, func() primitive.ObjectID { return postObjectId }, limit), nil
+}
+
+func (repo *SuccessLikeRepositoryMock) GetByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Like, error) {
+	userObjectId, _ := primitive.ObjectIDFromHex(userId)
+
+	return createLikes(func() primitive.ObjectID { return userObjectId }, primitive.NewObjectID, limit), nil
+}
+
+type AlreadyLikedRepositoryMock struct {
+	SuccessLikeRepositoryMock
+}
+
+func (repo *AlreadyLikedRepositoryMock) Like(ctx context.Context, userId, postId string) (string, error) {
+	return "", mongodb.ErrDuplicateKey
+}
+
+func (repo *AlreadyLikedRepositoryMock) Unlike(ctx context.Context, userId, postId string) (bool, error) {
+	return false, nil
+}
+
+func createLikes(user func() primitive.ObjectID, post func() primitive.ObjectID, numberOfLikes int) []*entity.Like {
+	var likes []*entity.Like
+
+	for i := 0; i < numberOfLikes; i++ {
+		likes = append(likes, &entity.Like{
+			ID:        primitive.NewObjectID(),
+			UserID:    user(),
+			PostID:    post(),
+			CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute),
+		})
+	}
+
+	return likes
+}
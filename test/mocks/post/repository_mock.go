@@ -1,6 +1,7 @@
 package post_mock
 
 import (
+	"context"
 	"github.com/regiszanandrea/posty/internal/post/entity"
 	"github.com/regiszanandrea/posty/internal/post/repository"
 	"github.com/spf13/viper"
@@ -10,17 +11,135 @@ import (
 
 type SuccessPostRepositoryMock struct {
 	post_repository.Repository
+	CountedSince time.Time
+	Created      *entity.Post
+	Edited       *entity.Post
+	EditedSince  time.Time
 }
 
-func (repo *SuccessPostRepositoryMock) GetNumberOfUsersPostsByDay(id string, day time.Time) (int, error) {
+func (repo *SuccessPostRepositoryMock) CountByUserSince(ctx context.Context, id string, since time.Time) (int, error) {
+	repo.CountedSince = since
 	return 0, nil
 }
 
-func (repo *SuccessPostRepositoryMock) Create(userId, content, parentId string) (string, error) {
-	return primitive.NewObjectID().Hex(), nil
+func (repo *SuccessPostRepositoryMock) FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error) {
+	return nil, nil
 }
 
-func (repo *SuccessPostRepositoryMock) GetLastByUser(userId string, page, limit int) ([]*entity.Post, error) {
+func (repo *SuccessPostRepositoryMock) Create(ctx context.Context, post *entity.Post) (string, error) {
+	post.ID = primitive.NewObjectID()
+	repo.Created = post
+	return post.ID.Hex(), nil
+}
+
+func (repo *SuccessPostRepositoryMock) Delete(ctx context.Context, id string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessPostRepositoryMock) Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error) {
+	editedAt := time.Now()
+	post.EditedAt = &editedAt
+	repo.Edited = post
+	repo.EditedSince = since
+	return true, nil
+}
+
+// GetHistory returns a single version, the one the post had when it was created
+func (repo *SuccessPostRepositoryMock) GetHistory(ctx context.Context, id string) ([]*entity.PostVersion, error) {
+	return []*entity.PostVersion{
+		{Content: "this is a pots", CreatedAt: time.Now().Add(-time.Minute)},
+	}, nil
+}
+
+func (repo *SuccessPostRepositoryMock) Find(ctx context.Context, id string) (*entity.Post, error) {
+	objectId, _ := primitive.ObjectIDFromHex(id)
+
+	return &entity.Post{
+		ID:        objectId,
+		UserID:    primitive.NewObjectID(),
+		Content:   "this is a post",
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func (repo *SuccessPostRepositoryMock) GetByIDs(ctx context.Context, ids []string) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	for _, id := range ids {
+		objectId, _ := primitive.ObjectIDFromHex(id)
+
+		posts = append(posts, &entity.Post{
+			ID:        objectId,
+			UserID:    primitive.NewObjectID(),
+			Content:   "this is a post",
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return posts, nil
+}
+
+func (repo *SuccessPostRepositoryMock) GetLastByHashtag(ctx context.Context, hashtag string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	return []*entity.Post{
+		{
+			UserID:   primitive.NewObjectID(),
+			Content:  "this is a post about #" + hashtag,
+			Hashtags: []string{hashtag},
+		},
+	}, nil
+}
+
+func (repo *SuccessPostRepositoryMock) GetLastByMention(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
+	objectId, _ := primitive.ObjectIDFromHex(userId)
+
+	return []*entity.Post{
+		{
+			UserID:   primitive.NewObjectID(),
+			Content:  "hi @test",
+			Mentions: []*entity.Mention{{UserID: objectId, Username: "test", Start: 3, End: 8}},
+		},
+	}, nil
+}
+
+func (repo *SuccessPostRepositoryMock) GetTrendingHashtags(ctx context.Context, since time.Time, limit int) ([]*entity.TrendingHashtag, error) {
+	return []*entity.TrendingHashtag{
+		{Tag: "golang", Count: 3},
+		{Tag: "mongodb", Count: 1},
+	}, nil
+}
+
+// GetReplies returns limit replies, each one with a reply of its own
+func (repo *SuccessPostRepositoryMock) GetReplies(ctx context.Context, postId string, cursor *entity.Cursor, limit, depth int) ([]*entity.Reply, error) {
+	objectId, _ := primitive.ObjectIDFromHex(postId)
+
+	var replies []*entity.Reply
+	for i := 0; i < limit; i++ {
+		reply := &entity.Reply{
+			Post: entity.Post{
+				ID:          primitive.NewObjectID(),
+				UserID:      primitive.NewObjectID(),
+				InReplyToID: objectId,
+				Content:     "this is a reply",
+				CreatedAt:   time.Now(),
+			},
+		}
+
+		reply.Descendants = []*entity.Post{
+			{
+				ID:          primitive.NewObjectID(),
+				UserID:      primitive.NewObjectID(),
+				InReplyToID: reply.ID,
+				Content:     "this is a reply of a reply",
+				CreatedAt:   time.Now(),
+			},
+		}
+
+		replies = append(replies, reply)
+	}
+
+	return replies, nil
+}
+
+func (repo *SuccessPostRepositoryMock) GetLastByUser(ctx context.Context, userId string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
 	objectId, _ := primitive.ObjectIDFromHex(userId)
 
 	return []*entity.Post{
@@ -35,7 +154,7 @@ func (repo *SuccessPostRepositoryMock) GetLastByUser(userId string, page, limit
 	}, nil
 }
 
-func (repo *SuccessPostRepositoryMock) GetLastByUsers(users []string, page, limit int) ([]*entity.Post, error) {
+func (repo *SuccessPostRepositoryMock) GetLastByUsers(ctx context.Context, users, following []string, cursor *entity.Cursor, limit int) ([]*entity.Post, error) {
 	var posts []*entity.Post
 	for _, user := range users {
 		objectId, _ := primitive.ObjectIDFromHex(user)
@@ -49,11 +168,76 @@ func (repo *SuccessPostRepositoryMock) GetLastByUsers(users []string, page, limi
 	return posts, nil
 }
 
-type MaximumPostsCreatedOnDayRepositoryMock struct {
+// CountByUsers considers that every user has 5 posts
+func (repo *SuccessPostRepositoryMock) CountByUsers(ctx context.Context, users []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, user := range users {
+		counts[user] = 5
+	}
+
+	return counts, nil
+}
+
+// OwnedPostRepositoryMock finds posts that always belong to UserID, created at CreatedAt
+// when it is set, and that are reposts when Repost is set. Deleting them loses to a
+// concurrent delete when DeletedConcurrently is set, and editing them finds their edit
+// window closed when EditWindowClosed is set
+type OwnedPostRepositoryMock struct {
+	SuccessPostRepositoryMock
+	UserID              string
+	CreatedAt           time.Time
+	Repost              bool
+	DeletedConcurrently bool
+	EditWindowClosed    bool
+}
+
+func (repo *OwnedPostRepositoryMock) Delete(ctx context.Context, id string) (bool, error) {
+	return !repo.DeletedConcurrently, nil
+}
+
+func (repo *OwnedPostRepositoryMock) Edit(ctx context.Context, post *entity.Post, since time.Time) (bool, error) {
+	if repo.EditWindowClosed {
+		return false, nil
+	}
+
+	return repo.SuccessPostRepositoryMock.Edit(ctx, post, since)
+}
+
+func (repo *OwnedPostRepositoryMock) Find(ctx context.Context, id string) (*entity.Post, error) {
+	post, _ := repo.SuccessPostRepositoryMock.Find(ctx, id)
+	post.UserID, _ = primitive.ObjectIDFromHex(repo.UserID)
+
+	if !repo.CreatedAt.IsZero() {
+		post.CreatedAt = repo.CreatedAt
+	}
+
+	if repo.Repost {
+		post.ParentID = primitive.NewObjectID()
+		post.Content = ""
+	}
+
+	return post, nil
+}
+
+type NotFoundPostRepositoryMock struct {
+	SuccessPostRepositoryMock
+}
+
+func (repo *NotFoundPostRepositoryMock) Find(ctx context.Context, id string) (*entity.Post, error) {
+	return nil, nil
+}
+
+// MaximumPostsCreatedRepositoryMock has a user that used the whole quota, the oldest post of the window was created an hour ago
+type MaximumPostsCreatedRepositoryMock struct {
 	post_repository.Repository
 	Configs *viper.Viper
 }
 
-func (repo *MaximumPostsCreatedOnDayRepositoryMock) GetNumberOfUsersPostsByDay(id string, day time.Time) (int, error) {
-	return repo.Configs.GetInt("app.posts.maximum-per-day"), nil
+func (repo *MaximumPostsCreatedRepositoryMock) CountByUserSince(ctx context.Context, id string, since time.Time) (int, error) {
+	return repo.Configs.GetInt("app.quota.posts.limit"), nil
+}
+
+func (repo *MaximumPostsCreatedRepositoryMock) FindOldestCreatedAtSince(ctx context.Context, id string, since time.Time) (*time.Time, error) {
+	oldest := time.Now().Add(-time.Hour)
+	return &oldest, nil
 }
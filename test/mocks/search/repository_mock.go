@@ -0,0 +1,27 @@
+package search_mock
+
+import (
+	"context"
+	"github.com/regiszanandrea/posty/internal/search/entity"
+	"github.com/regiszanandrea/posty/internal/search/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SuccessSearchRepositoryMock finds Found posts and keeps the last search
+type SuccessSearchRepositoryMock struct {
+	search_repository.Repository
+	Found  int
+	Search *entity.PostSearch
+}
+
+func (repo *SuccessSearchRepositoryMock) SearchPosts(ctx context.Context, search *entity.PostSearch) ([]string, error) {
+	repo.Search = search
+
+	var ids []string
+
+	for i := 0; i < repo.Found; i++ {
+		ids = append(ids, primitive.NewObjectID().Hex())
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,118 @@
+package timeline_mock
+
+import (
+	"context"
+	"errors"
+	post_entity "github.com/regiszanandrea/posty/internal/post/entity"
+	"github.com/regiszanandrea/posty/internal/timeline/entity"
+	timeline_repository "github.com/regiszanandrea/posty/internal/timeline/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type SuccessTimelineRepositoryMock struct {
+	timeline_repository.Repository
+	Pushed  []string
+	Removed []string
+	// Pulling and Unpulling are the timelines that started and stopped to pull the posts of a user
+	Pulling   []string
+	Unpulling []string
+}
+
+func (repo *SuccessTimelineRepositoryMock) Exists(ctx context.Context, userId string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	return nil, true, nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error {
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) Push(ctx context.Context, users []string, entries []*entity.Entry) error {
+	if len(entries) > 0 {
+		repo.Pushed = append(repo.Pushed, users...)
+	}
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) AddPulled(ctx context.Context, users []string, authorId string) error {
+	repo.Pulling = append(repo.Pulling, users...)
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) RemovePulled(ctx context.Context, users []string, authorId string) error {
+	repo.Unpulling = append(repo.Unpulling, users...)
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) RemoveByAuthor(ctx context.Context, userId, authorId string) error {
+	repo.Removed = append(repo.Removed, authorId)
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) RemoveByPost(ctx context.Context, postId string) error {
+	repo.Removed = append(repo.Removed, postId)
+	return nil
+}
+
+func (repo *SuccessTimelineRepositoryMock) GetEntries(ctx context.Context, userId string, cursor *post_entity.Cursor, limit int) ([]*entity.Entry, error) {
+	var entries []*entity.Entry
+
+	for i := 0; i < limit; i++ {
+		entries = append(entries, &entity.Entry{
+			PostID:    primitive.NewObjectID(),
+			AuthorID:  primitive.NewObjectID(),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return entries, nil
+}
+
+type NotMaterializedTimelineRepositoryMock struct {
+	SuccessTimelineRepositoryMock
+	Replaced bool
+}
+
+func (repo *NotMaterializedTimelineRepositoryMock) Exists(ctx context.Context, userId string) (bool, error) {
+	return repo.Replaced, nil
+}
+
+func (repo *NotMaterializedTimelineRepositoryMock) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	return nil, repo.Replaced, nil
+}
+
+func (repo *NotMaterializedTimelineRepositoryMock) Replace(ctx context.Context, userId string, entries []*entity.Entry, pulled []string) error {
+	repo.Replaced = true
+	return nil
+}
+
+// PullingTimelineRepositoryMock has timelines that pull the posts of Pulled
+type PullingTimelineRepositoryMock struct {
+	SuccessTimelineRepositoryMock
+	Pulled []string
+}
+
+func (repo *PullingTimelineRepositoryMock) GetPulled(ctx context.Context, userId string) ([]string, bool, error) {
+	return repo.Pulled, true, nil
+}
+
+// ErrorOnWritingTimelineRepositoryMock fails to read and change the timelines, as on a deadline
+type ErrorOnWritingTimelineRepositoryMock struct {
+	SuccessTimelineRepositoryMock
+}
+
+func (repo *ErrorOnWritingTimelineRepositoryMock) Exists(ctx context.Context, userId string) (bool, error) {
+	return false, errors.New("error on reading the timeline")
+}
+
+func (repo *ErrorOnWritingTimelineRepositoryMock) AddPulled(ctx context.Context, users []string, authorId string) error {
+	return errors.New("error on writing the timeline")
+}
+
+func (repo *ErrorOnWritingTimelineRepositoryMock) RemoveByAuthor(ctx context.Context, userId, authorId string) error {
+	return errors.New("error on writing the timeline")
+}
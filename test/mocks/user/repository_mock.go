@@ -1,11 +1,13 @@
 package user_mock
 
 import (
+	"context"
 	"errors"
 	"github.com/regiszanandrea/posty/internal/user/entity"
 	"github.com/regiszanandrea/posty/internal/user/repository/user"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -13,7 +15,7 @@ type SuccessUserRepositoryMock struct {
 	user_repository.Repository
 }
 
-func (repo *SuccessUserRepositoryMock) Find(id string) (*entity.User, error) {
+func (repo *SuccessUserRepositoryMock) Find(ctx context.Context, id string) (*entity.User, error) {
 	objectId, _ := primitive.ObjectIDFromHex(id)
 
 	return &entity.User{
@@ -26,31 +28,159 @@ func (repo *SuccessUserRepositoryMock) Find(id string) (*entity.User, error) {
 	}, nil
 }
 
-func (repo *SuccessUserRepositoryMock) Create(user *entity.User) (string, error) {
+// FindByUsername returns a user whose password is the username itself
+func (repo *SuccessUserRepositoryMock) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	user := &entity.User{
+		ID:        primitive.NewObjectID(),
+		Username:  username,
+		Password:  username,
+		CreatedAt: time.Now(),
+	}
+
+	if err := user.HashPassword(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// FindByUsernames finds every user, but the ones whose username starts with ghost
+func (repo *SuccessUserRepositoryMock) FindByUsernames(ctx context.Context, usernames []string) ([]*entity.UserSummary, error) {
+	var users []*entity.UserSummary
+
+	for _, username := range usernames {
+		if !strings.HasPrefix(username, "ghost") {
+			users = append(users, &entity.UserSummary{ID: primitive.NewObjectID(), Username: username})
+		}
+	}
+
+	return users, nil
+}
+
+// FindByUsernamePrefix finds a popular user, the user with exactly the prefix and a new user, in this order
+func (repo *SuccessUserRepositoryMock) FindByUsernamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.UserSummary, error) {
+	users := []*entity.UserSummary{
+		{ID: primitive.NewObjectID(), Username: prefix + "popular", FollowersCount: 100},
+		{ID: primitive.NewObjectID(), Username: prefix, FollowersCount: 10},
+		{ID: primitive.NewObjectID(), Username: prefix + "new"},
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+func (repo *SuccessUserRepositoryMock) Create(ctx context.Context, user *entity.User) (string, error) {
 	return primitive.NewObjectID().Hex(), nil
 }
 
-func (repo *SuccessUserRepositoryMock) IncrementFollowers(id string) error {
+func (repo *SuccessUserRepositoryMock) IncrementFollowers(ctx context.Context, id string) error {
 	return nil
 }
-func (repo *SuccessUserRepositoryMock) IncrementFollowing(id string) error {
+func (repo *SuccessUserRepositoryMock) IncrementFollowing(ctx context.Context, id string) error {
 	return nil
 }
-func (repo *SuccessUserRepositoryMock) DecrementFollowers(id string) error {
+func (repo *SuccessUserRepositoryMock) DecrementFollowers(ctx context.Context, id string) error {
 	return nil
 }
-func (repo *SuccessUserRepositoryMock) DecrementFollowing(id string) error {
+func (repo *SuccessUserRepositoryMock) DecrementFollowing(ctx context.Context, id string) error {
 	return nil
 }
 
-func (repo *SuccessUserRepositoryMock) IncreasePostsCount(id string) error {
-	return nil
+func (repo *SuccessUserRepositoryMock) SetTimezone(ctx context.Context, id, timezone string) (bool, error) {
+	return true, nil
+}
+
+func (repo *SuccessUserRepositoryMock) FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error) {
+	return nil, nil
+}
+
+func (repo *SuccessUserRepositoryMock) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	return false, false, nil
+}
+
+type HighFollowersUserRepositoryMock struct {
+	SuccessUserRepositoryMock
+}
+
+func (repo *HighFollowersUserRepositoryMock) FilterByMinimumFollowers(ctx context.Context, ids []string, followers uint) ([]string, error) {
+	return ids, nil
+}
+
+func (repo *HighFollowersUserRepositoryMock) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	return true, false, nil
+}
+
+// CrossingUserRepositoryMock has users that just crossed the followers to be pulled, or
+// to stop to be pulled when Pulled is false
+type CrossingUserRepositoryMock struct {
+	SuccessUserRepositoryMock
+	Pulled bool
+}
+
+func (repo *CrossingUserRepositoryMock) UpdatePulled(ctx context.Context, id string, followers uint) (bool, bool, error) {
+	return repo.Pulled, true, nil
+}
+
+type NotFoundUserRepositoryMock struct {
+	SuccessUserRepositoryMock
+}
+
+func (repo *NotFoundUserRepositoryMock) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (repo *NotFoundUserRepositoryMock) SetTimezone(ctx context.Context, id, timezone string) (bool, error) {
+	return false, nil
+}
+
+// TimezoneUserRepositoryMock has users that set their timezone
+type TimezoneUserRepositoryMock struct {
+	SuccessUserRepositoryMock
+	Timezone string
+}
+
+func (repo *TimezoneUserRepositoryMock) Find(ctx context.Context, id string) (*entity.User, error) {
+	user, err := repo.SuccessUserRepositoryMock.Find(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.Timezone = repo.Timezone
+
+	return user, nil
 }
 
 type ErrorOnFindingUserRepositoryMock struct {
 	user_repository.Repository
 }
 
-func (repo *ErrorOnFindingUserRepositoryMock) Find(id string) (*entity.User, error) {
+func (repo *ErrorOnFindingUserRepositoryMock) Find(ctx context.Context, id string) (*entity.User, error) {
 	return nil, errors.New("error on finding")
 }
+
+// DriftedCountersUserRepositoryMock has two users, the first one with the counters of the other mocks
+// and the second one with its followers below zero
+type DriftedCountersUserRepositoryMock struct {
+	SuccessUserRepositoryMock
+	Replaced []*entity.Counters
+}
+
+func (repo *DriftedCountersUserRepositoryMock) GetCounters(ctx context.Context, after string, limit int) ([]*entity.Counters, error) {
+	if after != "" {
+		return nil, nil
+	}
+
+	return []*entity.Counters{
+		{UserID: primitive.NewObjectID(), FollowersCount: 2, FollowingCount: 3, PostsCount: 5},
+		{UserID: primitive.NewObjectID(), FollowersCount: -1, FollowingCount: 3, PostsCount: 5},
+	}, nil
+}
+
+func (repo *DriftedCountersUserRepositoryMock) ReplaceCounters(ctx context.Context, stored, actual []*entity.Counters) (int, error) {
+	repo.Replaced = append(repo.Replaced, actual...)
+	return len(actual), nil
+}